@@ -0,0 +1,575 @@
+package taggolib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Monkey's Audio format flags which specify the bits per sample of a stream, in versions
+	// prior to 3.98
+	apeFlag8Bit  = 0x0001
+	apeFlag24Bit = 0x0008
+)
+
+var (
+	// apeMagicNumber is the magic number used to identify a Monkey's Audio stream
+	apeMagicNumber = []byte("MAC ")
+)
+
+// APEParser represents a Monkey's Audio audio metadata tag parser.  A *APEParser is returned by New for
+// Monkey's Audio streams, and may be type-asserted to access methods specific to the format.
+type APEParser struct {
+	endPos  int64
+	header  *APEHeader
+	options Options
+	reader  io.ReadSeeker
+	tags    map[string]string
+}
+
+// init registers the Monkey's Audio format with New
+func init() {
+	registerFormat(FormatAPE, apeMagicNumber, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newAPEParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
+// Album returns the Album tag for this stream
+func (a APEParser) Album() string {
+	return a.tags[tagAlbum]
+}
+
+// AlbumArtist returns the AlbumArtist tag for this stream
+func (a APEParser) AlbumArtist() string {
+	return a.tags[tagAlbumArtist]
+}
+
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (a APEParser) AlbumArtistSort() string {
+	return a.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (a APEParser) AlbumSort() string {
+	return a.tags[tagAlbumSort]
+}
+
+// Artist returns the Artist tag for this stream
+func (a APEParser) Artist() string {
+	return a.tags[tagArtist]
+}
+
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (a APEParser) ArtistSort() string {
+	return a.tags[tagArtistSort]
+}
+
+// BitDepth returns the bits-per-sample of this stream
+func (a APEParser) BitDepth() int {
+	return int(a.header.BitsPerSample)
+}
+
+// Bitrate calculates the audio bitrate for this stream
+func (a APEParser) Bitrate() int {
+	// Check for zero duration or end position, to prevent a division-by-zero panic
+	seconds := int64(a.Duration().Seconds())
+	if a.endPos == 0 || seconds == 0 {
+		return 0
+	}
+
+	return int(((a.endPos * 8) / seconds) / 1024)
+}
+
+// BPM returns the BPM (beats per minute) tag for this stream
+func (a APEParser) BPM() int {
+	bpm, err := strconv.Atoi(a.tags[tagBPM])
+	if err != nil {
+		return 0
+	}
+
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, such as "mono", "stereo", or "5.1"
+func (a APEParser) ChannelLayout() string {
+	return channelLayout(a.Channels())
+}
+
+// Channels returns the number of channels for this stream
+func (a APEParser) Channels() int {
+	return int(a.header.Channels)
+}
+
+// Comment returns the Comment tag for this stream
+func (a APEParser) Comment() string {
+	return a.tags[tagComment]
+}
+
+// Composer returns the Composer tag for this stream
+func (a APEParser) Composer() string {
+	return a.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (a APEParser) Conductor() string {
+	return a.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (a APEParser) ContentType() string {
+	return a.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (a APEParser) Copyright() string {
+	return a.tags[tagCopyright]
+}
+
+// Date returns the Date tag for this stream
+func (a APEParser) Date() string {
+	return a.tags[tagDate]
+}
+
+// DiscNumber returns the DiscNumber tag for this stream
+func (a APEParser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(a.tags[tagDiscNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return disc
+}
+
+// Duration returns the time duration for this stream
+func (a APEParser) Duration() time.Duration {
+	return samplesDuration(uint64(a.header.totalBlocks()), uint64(a.header.SampleRate))
+}
+
+// EncodedBy returns the EncodedBy tag for this stream
+func (a APEParser) EncodedBy() string {
+	return a.tags[tagEncodedBy]
+}
+
+// Encoder returns the encoder for this stream, preferring the ENCODER tag over the Monkey's
+// Audio version which encoded the stream
+func (a APEParser) Encoder() string {
+	if encoder := a.tags[tagEncoder]; encoder != "" {
+		return encoder
+	}
+
+	return fmt.Sprintf("Monkey's Audio %.2f", float64(a.header.Version)/1000)
+}
+
+// Format returns the name of the Monkey's Audio format
+func (a APEParser) Format() string {
+	return a.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (a APEParser) FormatID() Format {
+	return FormatAPE
+}
+
+// Genre returns the Genre tag for this stream
+func (a APEParser) Genre() string {
+	return a.tags[tagGenre]
+}
+
+// Grouping returns the Grouping (content group) tag for this stream
+func (a APEParser) Grouping() string {
+	return a.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+// BUG(mdlayher): Monkey's Audio: cover art stored in binary APEv2 items is not detected
+func (a APEParser) HasPicture() bool {
+	return false
+}
+
+// HeaderFingerprint returns a stable identifier derived from the header and size of this stream
+func (a APEParser) HeaderFingerprint() []byte {
+	return headerFingerprint(a.endPos, *a.header)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (a APEParser) InitialKey() string {
+	return firstTag(a.tags, tagInitialKey, tagKey)
+}
+
+// Language returns the Language tag for this stream
+func (a APEParser) Language() string {
+	return a.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream
+func (a APEParser) Lyrics() string {
+	return a.tags[tagLyrics]
+}
+
+// Mood returns the Mood tag for this stream
+func (a APEParser) Mood() string {
+	return a.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (a APEParser) OriginalFilename() string {
+	return a.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (a APEParser) Properties() AudioProperties {
+	return audioProperties(&a)
+}
+
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (a APEParser) Publisher() string {
+	return firstTag(a.tags, tagPublisher, tagLabel, tagOrganization)
+}
+
+// Raw returns a copy of the format-specific header parsed from this stream, as an APEHeader
+func (a APEParser) Raw() interface{} {
+	return *a.header
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (a APEParser) Remixer() string {
+	return firstTag(a.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (a APEParser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(a.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (a APEParser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(a.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (a APEParser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(a.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (a APEParser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(a.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new Monkey's Audio stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (a *APEParser) Reset(reader io.ReadSeeker) error {
+	*a = APEParser{
+		options: a.options,
+		reader:  reader,
+	}
+
+	return a.parse()
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (a APEParser) SampleCount() uint64 {
+	return uint64(a.header.totalBlocks())
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (a APEParser) SampleFormat() SampleFormat {
+	return wavSampleFormat(a.BitDepth())
+}
+
+// SampleRate returns the sample rate in Hertz for this stream
+func (a APEParser) SampleRate() int {
+	return int(a.header.SampleRate)
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (a APEParser) String() string {
+	return summarize(&a)
+}
+
+// Tag attempts to return the raw, unprocessed tag with the specified name for this stream
+func (a APEParser) Tag(name string) string {
+	return a.tags[strings.ToUpper(name)]
+}
+
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream
+func (a APEParser) TagMulti(name string) []string {
+	return tagValues(a.tags, name)
+}
+
+// Title returns the Title tag for this stream
+func (a APEParser) Title() string {
+	return a.tags[tagTitle]
+}
+
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (a APEParser) TitleSort() string {
+	return a.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (a APEParser) TotalDiscs() int {
+	return parseTotal(a.tags[tagDiscNumber], firstTag(a.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (a APEParser) TotalTracks() int {
+	return parseTotal(a.tags[tagTrackNumber], firstTag(a.tags, tagTrackTotal, tagTotalTracks))
+}
+
+// TrackNumber returns the TrackNumber tag for this stream
+func (a APEParser) TrackNumber() int {
+	// Check for a /, such as 2/8
+	track, err := strconv.Atoi(strings.Split(a.tags[tagTrackNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return track
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (a APEParser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range a.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (a APEParser) Work() string {
+	return a.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (a APEParser) Year() int {
+	return parseYear(a.tags[tagDate])
+}
+
+// newAPEParser creates a parser for Monkey's Audio streams
+func newAPEParser(reader io.ReadSeeker, options Options) (*APEParser, error) {
+	// Create Monkey's Audio parser
+	parser := &APEParser{
+		options: options,
+		reader:  reader,
+	}
+
+	// Parse the stream
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a Monkey's Audio stream from the reader of this parser
+func (a *APEParser) parse() error {
+	// Verify the magic number at the start of the stream
+	if err := readMagicNumber(a.reader, apeMagicNumber, a.Format()); err != nil {
+		return err
+	}
+
+	// Parse the header for stream properties
+	if err := a.parseHeader(); err != nil {
+		return err
+	}
+
+	// If only a prefix of the stream is available, the APEv2 tag at the end of the stream
+	// cannot be read
+	if a.options.streaming {
+		return nil
+	}
+
+	// Determine the size of the stream
+	n, err := streamSize(a.reader)
+	if err != nil {
+		return err
+	}
+	a.endPos = n
+
+	// Parse tags from the APEv2 tag at the end of the stream
+	tags, err := parseAPEv2(a.reader)
+	if err != nil && !a.options.lenient() {
+		return err
+	}
+	a.options.normalizeTags(tags)
+	a.tags = tags
+
+	return nil
+}
+
+// APEHeader represents the stream properties contained in a Monkey's Audio header.  A copy of
+// the APEHeader for a Monkey's Audio stream is returned by Raw.
+type APEHeader struct {
+	Version          uint16
+	CompressionLevel uint16
+	FormatFlags      uint16
+	BlocksPerFrame   uint32
+	FinalFrameBlocks uint32
+	TotalFrames      uint32
+	BitsPerSample    uint16
+	Channels         uint16
+	SampleRate       uint32
+}
+
+// totalBlocks returns the total number of blocks (samples per channel) in a Monkey's Audio stream
+func (h APEHeader) totalBlocks() int64 {
+	if h.TotalFrames == 0 {
+		return 0
+	}
+
+	return int64(h.TotalFrames-1)*int64(h.BlocksPerFrame) + int64(h.FinalFrameBlocks)
+}
+
+// parseHeader parses the descriptor and header at the start of a Monkey's Audio stream
+func (a *APEParser) parseHeader() error {
+	// Read file version, following the magic number
+	header := new(APEHeader)
+	if err := binary.Read(a.reader, binary.LittleEndian, &header.Version); err != nil {
+		return err
+	}
+
+	// Version 3.98 and newer contain a descriptor before the header, and store the header
+	// fields in a different order than older versions
+	if header.Version >= 3980 {
+		if err := a.parseDescriptorHeader(header); err != nil {
+			return err
+		}
+	} else {
+		if err := a.parseLegacyHeader(header); err != nil {
+			return err
+		}
+	}
+
+	// Ensure sample rate and channel count are greater than 0, to prevent a division-by-zero
+	// panic when calculating duration
+	if header.SampleRate == 0 || header.Channels == 0 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  a.Format(),
+			Details: "sample rate and channel count must be greater than 0",
+		}
+	}
+
+	// Store header
+	a.header = header
+	return nil
+}
+
+// parseDescriptorHeader parses the descriptor and header used by Monkey's Audio version 3.98 and newer
+func (a *APEParser) parseDescriptorHeader(header *APEHeader) error {
+	// Read padding and descriptor length
+	var descriptor struct {
+		Padding         uint16
+		DescriptorBytes uint32
+	}
+	if err := binary.Read(a.reader, binary.LittleEndian, &descriptor); err != nil {
+		return err
+	}
+
+	// Seek past the remainder of the descriptor, which has already been partially read
+	//   4 - magic number
+	//   2 - version
+	//   2 - padding
+	//   4 - descriptor length
+	if descriptor.DescriptorBytes < 12 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  a.Format(),
+			Details: fmt.Sprintf("invalid descriptor length: %d", descriptor.DescriptorBytes),
+		}
+	}
+
+	if _, err := a.reader.Seek(int64(descriptor.DescriptorBytes)-12, 1); err != nil {
+		return err
+	}
+
+	// Read header fields
+	var fields struct {
+		CompressionLevel uint16
+		FormatFlags      uint16
+		BlocksPerFrame   uint32
+		FinalFrameBlocks uint32
+		TotalFrames      uint32
+		BitsPerSample    uint16
+		Channels         uint16
+		SampleRate       uint32
+	}
+	if err := binary.Read(a.reader, binary.LittleEndian, &fields); err != nil {
+		return err
+	}
+
+	header.CompressionLevel = fields.CompressionLevel
+	header.FormatFlags = fields.FormatFlags
+	header.BlocksPerFrame = fields.BlocksPerFrame
+	header.FinalFrameBlocks = fields.FinalFrameBlocks
+	header.TotalFrames = fields.TotalFrames
+	header.BitsPerSample = fields.BitsPerSample
+	header.Channels = fields.Channels
+	header.SampleRate = fields.SampleRate
+	return nil
+}
+
+// parseLegacyHeader parses the header used by Monkey's Audio versions prior to 3.98
+func (a *APEParser) parseLegacyHeader(header *APEHeader) error {
+	// Read header fields
+	var fields struct {
+		CompressionLevel uint16
+		FormatFlags      uint16
+		Channels         uint16
+		SampleRate       uint32
+		HeaderBytes      uint32
+		TerminatingBytes uint32
+		TotalFrames      uint32
+		FinalFrameBlocks uint32
+	}
+	if err := binary.Read(a.reader, binary.LittleEndian, &fields); err != nil {
+		return err
+	}
+
+	header.CompressionLevel = fields.CompressionLevel
+	header.FormatFlags = fields.FormatFlags
+	header.Channels = fields.Channels
+	header.SampleRate = fields.SampleRate
+	header.TotalFrames = fields.TotalFrames
+	header.FinalFrameBlocks = fields.FinalFrameBlocks
+
+	// Bits per sample are specified using format flags
+	switch {
+	case fields.FormatFlags&apeFlag8Bit != 0:
+		header.BitsPerSample = 8
+	case fields.FormatFlags&apeFlag24Bit != 0:
+		header.BitsPerSample = 24
+	default:
+		header.BitsPerSample = 16
+	}
+
+	// Blocks per frame are determined by version and compression level
+	switch {
+	case header.Version >= 3950:
+		header.BlocksPerFrame = 73728 * 4
+	case header.Version >= 3900, header.Version >= 3800 && header.CompressionLevel == 4000:
+		header.BlocksPerFrame = 73728
+	default:
+		header.BlocksPerFrame = 9216
+	}
+
+	return nil
+}
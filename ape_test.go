@@ -0,0 +1,93 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// apeStream generates a Monkey's Audio version 3.99 stream with the specified properties, followed
+// by an APEv2 tag containing the specified items
+func apeStream(sampleRate uint32, channels uint16, bitsPerSample uint16, totalFrames uint32, items ...[]byte) []byte {
+	// Descriptor, 52 bytes
+	descriptor := make([]byte, 52)
+	copy(descriptor, apeMagicNumber)
+	binary.LittleEndian.PutUint16(descriptor[4:6], 3990)
+	binary.LittleEndian.PutUint32(descriptor[8:12], 52)
+	binary.LittleEndian.PutUint32(descriptor[12:16], 24)
+
+	// Header, 24 bytes
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint16(header[0:2], 2000)
+	binary.LittleEndian.PutUint32(header[4:8], 73728*4)
+	binary.LittleEndian.PutUint32(header[8:12], 73728)
+	binary.LittleEndian.PutUint32(header[12:16], totalFrames)
+	binary.LittleEndian.PutUint16(header[16:18], bitsPerSample)
+	binary.LittleEndian.PutUint16(header[18:20], channels)
+	binary.LittleEndian.PutUint32(header[20:24], sampleRate)
+
+	stream := append(descriptor, header...)
+	stream = append(stream, make([]byte, 128)...)
+	return append(stream, apev2Tag(items...)...)
+}
+
+// TestAPE verifies that all APEParser methods work properly
+func TestAPE(t *testing.T) {
+	ape, err := New(bytes.NewReader(apeStream(44100, 2, 16, 11,
+		apev2Item(0, "Artist", "Artist"),
+		apev2Item(0, "Title", "Title"),
+		apev2Item(0, "Track", "3/12"),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := ape.(*APEParser); !ok {
+		t.Fatalf("unexpected parser type: %T", ape)
+	}
+
+	if ape.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", ape.Artist())
+	}
+
+	if ape.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", ape.Title())
+	}
+
+	if ape.TrackNumber() != 3 {
+		t.Fatalf("mismatched tag TrackNumber: %v", ape.TrackNumber())
+	}
+
+	if ape.BitDepth() != 16 {
+		t.Fatalf("mismatched property BitDepth: %v", ape.BitDepth())
+	}
+
+	if ape.Channels() != 2 {
+		t.Fatalf("mismatched property Channels: %v", ape.Channels())
+	}
+
+	if ape.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", ape.SampleRate())
+	}
+
+	// 10 full frames of 294912 blocks, and a final frame of 73728 blocks
+	if ape.Duration() != 68545306122*time.Nanosecond {
+		t.Fatalf("mismatched property Duration: %v", ape.Duration())
+	}
+
+	if ape.Encoder() != "Monkey's Audio 3.99" {
+		t.Fatalf("mismatched property Encoder: %v", ape.Encoder())
+	}
+
+	if ape.Format() != "Monkey's Audio" {
+		t.Fatalf("mismatched property Format: %v", ape.Format())
+	}
+}
+
+// TestAPEZeroSampleRate verifies that a Monkey's Audio stream with a zero sample rate is rejected
+func TestAPEZeroSampleRate(t *testing.T) {
+	if _, err := New(bytes.NewReader(apeStream(0, 2, 16, 11))); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
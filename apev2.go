@@ -0,0 +1,199 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// apev2FooterSize is the size of an APEv2 tag footer, which is identical in size and
+	// layout to the optional APEv2 tag header
+	apev2FooterSize = 32
+
+	// apev2ItemTypeMask masks the APEv2 item flag bits which specify the type of an item's value
+	apev2ItemTypeMask = 0x06
+
+	// apev2FlagHasHeader is the APEv2 tag flag which indicates that the tag begins with a header
+	apev2FlagHasHeader = 0x80000000
+)
+
+var (
+	// apev2Preamble is the preamble used to identify an APEv2 tag header or footer
+	apev2Preamble = []byte("APETAGEX")
+	// apev2ID3v1Marker is the marker used to identify an ID3v1 tag, which may follow an APEv2 tag
+	apev2ID3v1Marker = []byte("TAG")
+)
+
+// apev2KeyToTag maps APEv2 item keys which differ from the built-in tag names to those tags.  Other
+// item keys are used as tag names directly.
+var apev2KeyToTag = map[string]string{
+	"ALBUM ARTIST": tagAlbumArtist,
+	"DISC":         tagDiscNumber,
+	"TRACK":        tagTrackNumber,
+	"YEAR":         tagDate,
+}
+
+// apev2Footer represents the information contained in an APEv2 tag footer
+type apev2Footer struct {
+	Version   uint32
+	TagSize   uint32
+	ItemCount uint32
+	Flags     uint32
+}
+
+// parseAPEv2 locates an APEv2 tag at the end of an input stream, which may be followed by an ID3v1 tag,
+// and decodes its text items into a tag map.  If the stream does not contain an APEv2 tag, parseAPEv2
+// returns a nil map.  The position of the input stream is not restored.
+func parseAPEv2(reader io.ReadSeeker) (map[string]string, error) {
+	// Check for an APEv2 footer at the end of the stream, and then directly before an ID3v1 tag
+	footerBuf := make([]byte, apev2FooterSize)
+	for _, offset := range []int64{0, 128} {
+		// Check for an ID3v1 tag before checking for an APEv2 footer before it
+		if offset > 0 {
+			if _, err := reader.Seek(-offset, 2); err != nil {
+				return nil, nil
+			}
+
+			if _, err := io.ReadFull(reader, footerBuf[:len(apev2ID3v1Marker)]); err != nil {
+				return nil, err
+			}
+
+			if !bytes.Equal(footerBuf[:len(apev2ID3v1Marker)], apev2ID3v1Marker) {
+				return nil, nil
+			}
+		}
+
+		// Streams too short to contain a footer contain no APEv2 tag
+		end, err := reader.Seek(-offset-apev2FooterSize, 2)
+		if err != nil {
+			return nil, nil
+		}
+
+		if _, err := io.ReadFull(reader, footerBuf); err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(footerBuf[:len(apev2Preamble)], apev2Preamble) {
+			return parseAPEv2Items(reader, footerBuf, end)
+		}
+	}
+
+	return nil, nil
+}
+
+// parseAPEv2Items parses an APEv2 footer, and the items which precede it, ending at the input offset
+func parseAPEv2Items(reader io.ReadSeeker, footerBuf []byte, end int64) (map[string]string, error) {
+	// Parse footer fields following the preamble
+	footer := new(apev2Footer)
+	if err := binary.Read(bytes.NewReader(footerBuf[len(apev2Preamble):]), binary.LittleEndian, footer); err != nil {
+		return nil, err
+	}
+
+	// Ensure APEv1 or APEv2 tag
+	if footer.Version != 1000 && footer.Version != 2000 {
+		return nil, TagError{
+			Err:     ErrUnsupportedVersion,
+			Format:  "APEv2",
+			Details: fmt.Sprintf("unsupported APE tag version: %d", footer.Version),
+		}
+	}
+
+	// Tag size includes the footer, but not the optional header, and must fit in the stream
+	if footer.TagSize < apev2FooterSize || int64(footer.TagSize) > end+apev2FooterSize {
+		return nil, TagError{
+			Err:     ErrInvalidStream,
+			Format:  "APEv2",
+			Details: fmt.Sprintf("invalid APE tag size: %d", footer.TagSize),
+		}
+	}
+
+	// Read all items into memory
+	if _, err := reader.Seek(end+apev2FooterSize-int64(footer.TagSize), 0); err != nil {
+		return nil, err
+	}
+
+	items := make([]byte, footer.TagSize-apev2FooterSize)
+	if _, err := io.ReadFull(reader, items); err != nil {
+		return nil, err
+	}
+
+	// Parse each item, which consists of:
+	//   - 4 bytes: value size, little endian
+	//   - 4 bytes: item flags, little endian
+	//   - N bytes: key, null terminated
+	//   - N bytes: value
+	tagMap := map[string]string{}
+	for i := uint32(0); i < footer.ItemCount; i++ {
+		if len(items) < 8 {
+			return nil, TagError{
+				Err:     ErrInvalidStream,
+				Format:  "APEv2",
+				Details: "APE tag item extends past end of tag",
+			}
+		}
+
+		size := binary.LittleEndian.Uint32(items[0:4])
+		flags := binary.LittleEndian.Uint32(items[4:8])
+		items = items[8:]
+
+		// Locate the end of the key, and ensure the value fits in the tag
+		index := bytes.IndexByte(items, 0)
+		if index == -1 || uint64(index)+1+uint64(size) > uint64(len(items)) {
+			return nil, TagError{
+				Err:     ErrInvalidStream,
+				Format:  "APEv2",
+				Details: "APE tag item extends past end of tag",
+			}
+		}
+
+		key := strings.ToUpper(string(items[:index]))
+		value := items[index+1 : index+1+int(size)]
+		items = items[index+1+int(size):]
+
+		// Only text items are stored as tags, skipping binary data and external references
+		if flags&apev2ItemTypeMask != 0 {
+			continue
+		}
+
+		// Map item key to tag name, when needed
+		if name, ok := apev2KeyToTag[key]; ok {
+			key = name
+		}
+		tagMap[key] = string(value)
+	}
+
+	return tagMap, nil
+}
+
+// apev2TagSize returns the total size of an APEv2 tag whose footer ends at the input offset of an input
+// stream, including its optional header, or 0 if no APEv2 tag ends at the offset.  The position of the
+// input stream is not restored.
+func apev2TagSize(reader io.ReadSeeker, end int64) (int64, error) {
+	if end < apev2FooterSize {
+		return 0, nil
+	}
+
+	if _, err := reader.Seek(end-apev2FooterSize, 0); err != nil {
+		return 0, err
+	}
+
+	footerBuf := make([]byte, apev2FooterSize)
+	if _, err := io.ReadFull(reader, footerBuf); err != nil {
+		return 0, err
+	}
+
+	if !bytes.Equal(footerBuf[:len(apev2Preamble)], apev2Preamble) {
+		return 0, nil
+	}
+
+	// Tag size includes the footer, but not the optional header
+	size := int64(binary.LittleEndian.Uint32(footerBuf[12:16]))
+	if binary.LittleEndian.Uint32(footerBuf[20:24])&apev2FlagHasHeader != 0 {
+		size += apev2FooterSize
+	}
+
+	return size, nil
+}
@@ -0,0 +1,97 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// apev2Item generates an APEv2 tag item with the specified flags, key, and value
+func apev2Item(flags uint32, key string, value string) []byte {
+	item := make([]byte, 8)
+	binary.LittleEndian.PutUint32(item[0:4], uint32(len(value)))
+	binary.LittleEndian.PutUint32(item[4:8], flags)
+
+	item = append(item, key...)
+	item = append(item, 0)
+	return append(item, value...)
+}
+
+// apev2Tag generates an APEv2 tag, with a footer but no header, containing the specified items
+func apev2Tag(items ...[]byte) []byte {
+	var tag []byte
+	for _, item := range items {
+		tag = append(tag, item...)
+	}
+
+	footer := make([]byte, apev2FooterSize)
+	copy(footer, apev2Preamble)
+	binary.LittleEndian.PutUint32(footer[8:12], 2000)
+	binary.LittleEndian.PutUint32(footer[12:16], uint32(len(tag)+apev2FooterSize))
+	binary.LittleEndian.PutUint32(footer[16:20], uint32(len(items)))
+
+	return append(tag, footer...)
+}
+
+// TestParseAPEv2 verifies that parseAPEv2 locates and decodes APEv2 tags
+func TestParseAPEv2(t *testing.T) {
+	tag := apev2Tag(
+		apev2Item(0, "Title", "Title"),
+		apev2Item(0, "Album Artist", "Album Artist"),
+		apev2Item(0, "Track", "1/10"),
+		apev2Item(0, "Year", "2014"),
+		apev2Item(2, "Cover Art (Front)", "\x00\x01\x02"),
+	)
+	tags := map[string]string{
+		tagTitle:       "Title",
+		tagAlbumArtist: "Album Artist",
+		tagTrackNumber: "1/10",
+		tagDate:        "2014",
+	}
+
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+
+	var tests = []struct {
+		stream []byte
+		tags   map[string]string
+	}{
+		// No APEv2 tag
+		{[]byte("audio"), nil},
+		{append([]byte("audio"), id3v1...), nil},
+		// APEv2 tag at end of stream
+		{append([]byte("audio"), tag...), tags},
+		// APEv2 tag followed by ID3v1 tag
+		{append(append([]byte("audio"), tag...), id3v1...), tags},
+	}
+
+	for i, test := range tests {
+		tags, err := parseAPEv2(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(tags, test.tags) {
+			t.Fatalf("[%02d] mismatched tags: %v != %v", i, tags, test.tags)
+		}
+	}
+}
+
+// TestParseAPEv2Invalid verifies that parseAPEv2 rejects malformed APEv2 tags
+func TestParseAPEv2Invalid(t *testing.T) {
+	// Claim more items than are present in the tag
+	tag := apev2Tag(apev2Item(0, "Title", "Title"))
+	binary.LittleEndian.PutUint32(tag[len(tag)-16:len(tag)-12], 2)
+
+	if _, err := parseAPEv2(bytes.NewReader(tag)); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+
+	// Claim a tag size larger than the stream
+	tag = apev2Tag(apev2Item(0, "Title", "Title"))
+	binary.LittleEndian.PutUint32(tag[len(tag)-20:len(tag)-16], 1024)
+
+	if _, err := parseAPEv2(bytes.NewReader(tag)); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
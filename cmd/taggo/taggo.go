@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/mdlayher/taggolib"
 )
@@ -26,29 +25,10 @@ func main() {
 		}
 	}
 
-	// Invoke a recursive file walk on all parameter directories
+	// Invoke a recursive file walk on all parameter directories, which skips files in unknown formats
 	for _, arg := range os.Args[1:] {
-		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
-			// Skip directories
-			if info.IsDir() {
-				return nil
-			}
-
-			// Open file
-			file, err := os.Open(path)
+		err := taggolib.Walk(arg, func(path string, audio taggolib.Parser, err error) error {
 			if err != nil {
-				return err
-			}
-			defer file.Close()
-
-			// Load file using taggolib
-			audio, err := taggolib.New(file)
-			if err != nil {
-				// Check for unknown format, which will be skipped
-				if taggolib.IsUnknownFormat(err) {
-					return nil
-				}
-
 				// Check for unsupported version, invalid stream, or EOF, which will be logged and skipped
 				if taggolib.IsUnsupportedVersion(err) || taggolib.IsInvalidStream(err) || err == io.EOF {
 					fmt.Println("taggo:", err, ":", path)
@@ -1,4 +1,5 @@
 /*
-Package taggolib provides read-only access to metadata contained in various audio formats.  MIT Licensed.
+Package taggolib provides access to metadata contained in various audio formats, and can modify the tags of
+FLAC, MP3, and Ogg Vorbis streams using the TagWriter interface.  MIT Licensed.
 */
 package taggolib
@@ -1,6 +1,7 @@
 package taggolib
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -14,8 +15,33 @@ import (
 const (
 	// flacStreamInfo denotes a STREAMINFO metadata block
 	flacStreamInfo = 0
+	// flacSeekTable denotes a SEEKTABLE metadata block
+	flacSeekTable = 3
 	// flacVorbisComment denotes a VORBISCOMMENT metadata block
 	flacVorbisComment = 4
+	// flacPicture denotes a PICTURE metadata block
+	flacPicture = 6
+)
+
+const (
+	// flacSeekPointSize is the size of a single seek point in a FLAC SEEKTABLE block
+	flacSeekPointSize = 18
+	// flacPlaceholderSeekPoint is the sample number used to denote a placeholder seek point
+	flacPlaceholderSeekPoint = 0xffffffffffffffff
+
+	// flacMinBlockSize is the smallest block size permitted in a FLAC stream, excluding
+	// the final block
+	flacMinBlockSize = 16
+
+	// flacFrameSync is the 14-bit sync code which begins every FLAC audio frame, followed
+	// by a reserved bit and the blocking strategy bit
+	flacFrameSync = 0xfff8
+	// flacFrameSyncMask masks the sync code and reserved bit of a FLAC audio frame header
+	flacFrameSyncMask = 0xfffe
+
+	// flacFrameScanSize is the number of bytes at the end of a FLAC stream which are scanned
+	// for the last audio frame header, when STREAMINFO does not contain a sample count
+	flacFrameScanSize = 64 * 1024
 )
 
 var (
@@ -23,72 +49,142 @@ var (
 	flacMagicNumber = []byte("fLaC")
 )
 
-// flacParser represents a FLAC audio metadata tag parser
-type flacParser struct {
-	encoder    string
-	endPos     int64
-	properties *flacStreamInfoBlock
-	reader     io.ReadSeeker
-	tags       map[string]string
+// FLACParser represents a FLAC audio metadata tag parser.  A *FLACParser is returned by New for
+// FLAC and Ogg FLAC streams, and may be type-asserted to access methods specific to the format.
+type FLACParser struct {
+	audioStart  int64
+	start       int64
+	bitrate     int
+	duration    time.Duration
+	endPos      int64
+	hasPicture  bool
+	lastBlock   bool
+	metadata    io.ReadSeeker
+	ogg         bool
+	options     Options
+	pictures    []embeddedPicture
+	properties  *FLACStreamInfo
+	reader      io.ReadSeeker
+	sampleCount uint64
+	seekPoints  []SeekPoint
+	tags        map[string]string
+	vendor      string
 
 	// Shared buffer stored as field to prevent unneeded allocations
 	buffer []byte
 }
 
+// init registers the FLAC format with New
+func init() {
+	registerFormat(FormatFLAC, flacMagicNumber, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newFLACParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
 // Album returns the Album tag for this stream
-func (f flacParser) Album() string {
+func (f FLACParser) Album() string {
 	return f.tags[tagAlbum]
 }
 
 // AlbumArtist returns the AlbumArtist tag for this stream
-func (f flacParser) AlbumArtist() string {
+func (f FLACParser) AlbumArtist() string {
 	return f.tags[tagAlbumArtist]
 }
 
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (f FLACParser) AlbumArtistSort() string {
+	return f.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (f FLACParser) AlbumSort() string {
+	return f.tags[tagAlbumSort]
+}
+
 // Artist returns the Artist tag for this stream
-func (f flacParser) Artist() string {
+func (f FLACParser) Artist() string {
 	return f.tags[tagArtist]
 }
 
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (f FLACParser) ArtistSort() string {
+	return f.tags[tagArtistSort]
+}
+
 // BitDepth returns the bits-per-sample of this stream
-func (f flacParser) BitDepth() int {
+func (f FLACParser) BitDepth() int {
 	return int(f.properties.BitsPerSample)
 }
 
-// Bitrate calculates the audio bitrate for this stream
-func (f flacParser) Bitrate() int {
-	// Check for zero duration or end position, to prevent a division-by-zero panic
-	seconds := f.Duration().Seconds()
-	if f.endPos == 0 || seconds == 0 {
+// Bitrate returns the audio bitrate for this stream
+func (f FLACParser) Bitrate() int {
+	return f.bitrate
+}
+
+// BPM returns the BPM (beats per minute) tag for this stream
+func (f FLACParser) BPM() int {
+	bpm, err := strconv.Atoi(f.tags[tagBPM])
+	if err != nil {
 		return 0
 	}
 
-	return int(((f.endPos * 8) / int64(f.Duration().Seconds())) / 1024)
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, such as "mono", "stereo", or "5.1"
+func (f FLACParser) ChannelLayout() string {
+	return channelLayout(f.Channels())
 }
 
 // Channels returns the number of channels for this stream
-func (f flacParser) Channels() int {
+func (f FLACParser) Channels() int {
 	return int(f.properties.ChannelCount)
 }
 
 // Checksum returns the checksum for this stream
-func (f flacParser) Checksum() string {
+func (f FLACParser) Checksum() string {
 	return f.properties.MD5Checksum
 }
 
 // Comment returns the Comment tag for this stream
-func (f flacParser) Comment() string {
+func (f FLACParser) Comment() string {
 	return f.tags[tagComment]
 }
 
+// Composer returns the Composer tag for this stream
+func (f FLACParser) Composer() string {
+	return f.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (f FLACParser) Conductor() string {
+	return f.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (f FLACParser) ContentType() string {
+	return f.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (f FLACParser) Copyright() string {
+	return f.tags[tagCopyright]
+}
+
 // Date returns the Date tag for this stream
-func (f flacParser) Date() string {
+func (f FLACParser) Date() string {
 	return f.tags[tagDate]
 }
 
 // DiscNumber returns the DiscNumber tag for this stream
-func (f flacParser) DiscNumber() int {
-	disc, err := strconv.Atoi(f.tags[tagDiscNumber])
+func (f FLACParser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(f.tags[tagDiscNumber], "/")[0])
 	if err != nil {
 		return 0
 	}
@@ -97,48 +193,340 @@ func (f flacParser) DiscNumber() int {
 }
 
 // Duration returns the time duration for this stream
-func (f flacParser) Duration() time.Duration {
-	return time.Duration(int64(f.properties.SampleCount)/int64(f.SampleRate())) * time.Second
+func (f FLACParser) Duration() time.Duration {
+	return f.duration
 }
 
-// Encoder returns the encoder for this stream
-func (f flacParser) Encoder() string {
-	return f.encoder
+// EncodedBy returns the EncodedBy tag for this stream
+func (f FLACParser) EncodedBy() string {
+	return f.tags[tagEncodedBy]
+}
+
+// Encoder returns the encoder for this stream, preferring the ENCODER tag over the vendor string
+func (f FLACParser) Encoder() string {
+	if encoder := f.tags[tagEncoder]; encoder != "" {
+		return encoder
+	}
+
+	return f.vendor
 }
 
 // Format returns the name of the FLAC format
-func (f flacParser) Format() string {
-	return "FLAC"
+func (f FLACParser) Format() string {
+	return f.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (f FLACParser) FormatID() Format {
+	if f.ogg {
+		return FormatOggFLAC
+	}
+
+	return FormatFLAC
 }
 
 // Genre returns the Genre tag for this stream
-func (f flacParser) Genre() string {
+func (f FLACParser) Genre() string {
 	return f.tags[tagGenre]
 }
 
-// Publisher returns the Publisher (record-label) tag for this stream
-func (f flacParser) Publisher() string {
-	return f.tags[tagPublisher]
+// Grouping returns the Grouping (content group) tag for this stream
+func (f FLACParser) Grouping() string {
+	return f.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+func (f FLACParser) HasPicture() bool {
+	return f.hasPicture
+}
+
+// HeaderFingerprint returns a stable identifier derived from the STREAMINFO block and size of this stream
+func (f FLACParser) HeaderFingerprint() []byte {
+	return headerFingerprint(f.endPos, *f.properties)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (f FLACParser) InitialKey() string {
+	return firstTag(f.tags, tagInitialKey, tagKey)
+}
+
+// Language returns the Language tag for this stream
+func (f FLACParser) Language() string {
+	return f.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream, falling back to the UnsyncedLyrics tag
+func (f FLACParser) Lyrics() string {
+	if lyrics := f.tags[tagLyrics]; lyrics != "" {
+		return lyrics
+	}
+
+	return f.tags[tagUnsyncedLyrics]
+}
+
+// MaxBlockSize returns the maximum block size in samples used in this stream
+func (f FLACParser) MaxBlockSize() int {
+	return int(f.properties.MaxBlockSize)
+}
+
+// MaxFrameSize returns the maximum frame size in bytes used in this stream, or 0 if unknown
+func (f FLACParser) MaxFrameSize() int {
+	return int(f.properties.MaxFrameSize)
+}
+
+// MinBlockSize returns the minimum block size in samples used in this stream
+func (f FLACParser) MinBlockSize() int {
+	return int(f.properties.MinBlockSize)
+}
+
+// MinFrameSize returns the minimum frame size in bytes used in this stream, or 0 if unknown
+func (f FLACParser) MinFrameSize() int {
+	return int(f.properties.MinFrameSize)
+}
+
+// Mood returns the Mood tag for this stream
+func (f FLACParser) Mood() string {
+	return f.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (f FLACParser) OriginalFilename() string {
+	return f.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (f FLACParser) Properties() AudioProperties {
+	return audioProperties(&f)
+}
+
+// Picture returns the first picture embedded in a PICTURE block of this stream, and whether or not
+// a picture is present
+func (f FLACParser) Picture() (Picture, bool) {
+	for _, p := range f.pictures {
+		if picture, err := f.readPicture(p); err == nil {
+			return picture, true
+		}
+	}
+
+	return Picture{}, false
+}
+
+// Pictures returns the pictures embedded in PICTURE blocks of this stream, in the order they are
+// stored.  If any picture types are specified, only pictures of those types are returned.  The image
+// data of each picture is read from the stream when Pictures is called, and pictures whose data cannot
+// be read are omitted.
+func (f FLACParser) Pictures(types ...PictureType) []Picture {
+	var pictures []Picture
+	for _, p := range f.pictures {
+		if !hasPictureType(p.Type, types) {
+			continue
+		}
+
+		if picture, err := f.readPicture(p); err == nil {
+			pictures = append(pictures, picture)
+		}
+	}
+
+	return pictures
+}
+
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (f FLACParser) Publisher() string {
+	return firstTag(f.tags, tagPublisher, tagLabel, tagOrganization)
+}
+
+// Raw returns a copy of the format-specific header parsed from this stream, as a FLACStreamInfo
+func (f FLACParser) Raw() interface{} {
+	return *f.properties
+}
+
+// RawTagBlock returns a copy of the raw bytes of the metadata region of this stream, which begins
+// with the "fLaC" marker and contains every metadata block preceding the audio frames.  For Ogg FLAC
+// streams, the Ogg pages which contain the metadata blocks are returned.
+func (f FLACParser) RawTagBlock() ([]byte, error) {
+	return readRange(f.reader, f.start, f.audioStart)
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (f FLACParser) Remixer() string {
+	return firstTag(f.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (f FLACParser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(f.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (f FLACParser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(f.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (f FLACParser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(f.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (f FLACParser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(f.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new FLAC stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (f *FLACParser) Reset(reader io.ReadSeeker) error {
+	*f = FLACParser{
+		buffer:  f.buffer,
+		ogg:     f.ogg,
+		options: f.options,
+		reader:  reader,
+	}
+
+	return f.parse()
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (f FLACParser) SampleCount() uint64 {
+	return f.sampleCount
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (f FLACParser) SampleFormat() SampleFormat {
+	// FLAC samples are always signed integers
+	return SampleFormatSignedInt
 }
 
 // SampleRate returns the sample rate in Hertz for this stream
-func (f flacParser) SampleRate() int {
+func (f FLACParser) SampleRate() int {
 	return int(f.properties.SampleRate)
 }
 
+// Save writes a copy of this FLAC stream to w, replacing its VORBISCOMMENT block with one containing
+// the tags set on this parser.  All other metadata blocks and the audio frames are copied unchanged.
+// If the stream has no VORBISCOMMENT block, one is added following the other metadata blocks.
+func (f *FLACParser) Save(w io.Writer) error {
+	// BUG(mdlayher): Ogg FLAC: tags cannot be saved, because Ogg pages are not rewritten
+	if f.ogg {
+		return TagError{
+			Err:     ErrUnsupportedVersion,
+			Format:  f.Format(),
+			Details: "saving tags is not supported for Ogg FLAC streams",
+		}
+	}
+
+	// Serialize tags as a VORBISCOMMENT block, which must fit in a 24-bit block length
+	comment := vorbisCommentBytes(f.vendor, f.tags)
+	if len(comment) >= 1<<24 {
+		return f.invalidStream("VORBISCOMMENT block too large to save")
+	}
+
+	if _, err := f.reader.Seek(f.start+int64(len(flacMagicNumber)), 0); err != nil {
+		return err
+	}
+	if _, err := w.Write(flacMagicNumber); err != nil {
+		return err
+	}
+
+	// Copy each metadata block, replacing the VORBISCOMMENT block
+	written := false
+	header := make([]byte, 4)
+	for last := false; !last; {
+		if _, err := io.ReadFull(f.reader, header); err != nil {
+			return err
+		}
+		last = header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		// Skip the original VORBISCOMMENT block, and write the new one in its place
+		if blockType == flacVorbisComment {
+			if _, err := f.reader.Seek(length, 1); err != nil {
+				return err
+			}
+
+			if err := writeFLACBlock(w, last, flacVorbisComment, bytes.NewReader(comment), int64(len(comment))); err != nil {
+				return err
+			}
+			written = true
+			continue
+		}
+
+		// If no VORBISCOMMENT block was present, add one following the last block
+		add := last && !written && len(f.tags) > 0
+		if err := writeFLACBlock(w, last && !add, blockType, f.reader, length); err != nil {
+			return err
+		}
+
+		if add {
+			if err := writeFLACBlock(w, true, flacVorbisComment, bytes.NewReader(comment), int64(len(comment))); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Copy the audio frames which follow the metadata blocks
+	if _, err := f.reader.Seek(f.audioStart, 0); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, f.reader)
+	return err
+}
+
+// SetTag sets the value of the named tag in the VORBISCOMMENT block of this stream, replacing any
+// existing value.  An empty value removes the tag.  The stream is not modified until Save is called.
+func (f *FLACParser) SetTag(name string, value string) {
+	setTag(&f.tags, name, value)
+}
+
+// SeekPoints returns the seek points from the SEEKTABLE block of this stream, excluding placeholders
+func (f FLACParser) SeekPoints() []SeekPoint {
+	return f.seekPoints
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (f FLACParser) String() string {
+	return summarize(&f)
+}
+
 // Tag attempts to return the raw, unprocessed tag with the specified name for this stream
-func (f flacParser) Tag(name string) string {
+func (f FLACParser) Tag(name string) string {
 	return f.tags[strings.ToUpper(name)]
 }
 
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream
+func (f FLACParser) TagMulti(name string) []string {
+	return tagValues(f.tags, name)
+}
+
 // Title returns the Title tag for this stream
-func (f flacParser) Title() string {
+func (f FLACParser) Title() string {
 	return f.tags[tagTitle]
 }
 
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (f FLACParser) TitleSort() string {
+	return f.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (f FLACParser) TotalDiscs() int {
+	return parseTotal(f.tags[tagDiscNumber], firstTag(f.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (f FLACParser) TotalTracks() int {
+	return parseTotal(f.tags[tagTrackNumber], firstTag(f.tags, tagTrackTotal, tagTotalTracks))
+}
+
 // TrackNumber returns the TrackNumber tag for this stream
-func (f flacParser) TrackNumber() int {
-	track, err := strconv.Atoi(f.tags[tagTrackNumber])
+func (f FLACParser) TrackNumber() int {
+	// Check for a /, such as 2/8
+	track, err := strconv.Atoi(strings.Split(f.tags[tagTrackNumber], "/")[0])
 	if err != nil {
 		return 0
 	}
@@ -146,33 +534,197 @@ func (f flacParser) TrackNumber() int {
 	return track
 }
 
+// Vendor returns the vendor string for this stream, which typically identifies the encoding software
+func (f FLACParser) Vendor() string {
+	return f.vendor
+}
+
+// Validate verifies that the STREAMINFO block of this stream is internally consistent, and that
+// audio frames begin directly after the metadata blocks
+// BUG(mdlayher): FLAC: Validate does not decode audio frames, so the MD5 checksum of the decoded audio is not verified
+func (f FLACParser) Validate() error {
+	p := f.properties
+
+	// Ensure stream properties are within the bounds permitted by the format
+	if p.SampleRate == 0 {
+		return f.invalidStream("STREAMINFO sample rate is zero")
+	}
+	if p.MinBlockSize < flacMinBlockSize || p.MinBlockSize > p.MaxBlockSize {
+		return f.invalidStream(fmt.Sprintf("invalid STREAMINFO block sizes: min %d, max %d", p.MinBlockSize, p.MaxBlockSize))
+	}
+
+	// Frame sizes of zero are unknown, and cannot be checked
+	if p.MinFrameSize > 0 && p.MaxFrameSize > 0 {
+		if p.MinFrameSize > p.MaxFrameSize {
+			return f.invalidStream(fmt.Sprintf("invalid STREAMINFO frame sizes: min %d, max %d", p.MinFrameSize, p.MaxFrameSize))
+		}
+
+		// The stream must be long enough to contain the fewest frames which could hold all
+		// of its samples.  Data may follow the audio frames, so only the lower bound is checked.
+		if p.SampleCount > 0 && f.endPos > 0 {
+			frames := (p.SampleCount + uint64(p.MaxBlockSize) - 1) / uint64(p.MaxBlockSize)
+			if uint64(f.endPos-f.audioStart) < frames*uint64(p.MinFrameSize) {
+				return f.invalidStream(fmt.Sprintf("stream too short for STREAMINFO sample count: %d", p.SampleCount))
+			}
+		}
+	}
+
+	// Ensure that the first audio frame begins with a frame sync code, which for Ogg FLAC streams
+	// begins the data of the first audio page
+	if _, err := f.reader.Seek(f.audioStart, 0); err != nil {
+		return err
+	}
+
+	var reader io.Reader = f.reader
+	if f.ogg {
+		_, data, err := readOGGPage(f.reader, make([]byte, oggMaxPageSize), f.Format())
+		if err != nil {
+			if err == io.EOF {
+				return f.invalidStream("no audio frames follow metadata blocks")
+			}
+
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	var sync uint16
+	if err := binary.Read(reader, binary.BigEndian, &sync); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return f.invalidStream("no audio frames follow metadata blocks")
+		}
+
+		return err
+	}
+
+	if sync&flacFrameSyncMask != flacFrameSync {
+		return f.invalidStream("could not find FLAC frame sync after metadata blocks")
+	}
+
+	return nil
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (f FLACParser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range f.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (f FLACParser) Work() string {
+	return f.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (f FLACParser) Year() int {
+	return parseYear(f.tags[tagDate])
+}
+
 // newFLACParser creates a parser for FLAC audio streams
-func newFLACParser(reader io.ReadSeeker) (*flacParser, error) {
+func newFLACParser(reader io.ReadSeeker, options Options) (*FLACParser, error) {
 	// Create FLAC parser
-	parser := &flacParser{
-		buffer: make([]byte, 2048),
-		reader: reader,
+	parser := &FLACParser{
+		buffer:  make([]byte, 2048),
+		options: options,
+		reader:  reader,
 	}
 
-	// Begin parsing properties
-	if err := parser.parseProperties(); err != nil {
+	// Parse the stream
+	if err := parser.parse(); err != nil {
 		return nil, err
 	}
 
-	// Seek through the file and attempt to parse tags
-	if err := parser.parseTags(); err != nil {
-		return nil, err
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a FLAC stream from the reader of this parser
+func (f *FLACParser) parse() error {
+	// Note the start of the stream, so it may be copied when saving tags
+	start, err := f.reader.Seek(0, 1)
+	if err != nil {
+		return err
 	}
+	f.start = start
 
-	// Seek to end of file to grab the final position, used to calculate bitrate
-	n, err := parser.reader.Seek(0, 2)
+	// Parse the metadata blocks, which are stored in Ogg packets for Ogg FLAC streams
+	if f.ogg {
+		if err := f.parseOGGMetadata(); err != nil {
+			return err
+		}
+	} else {
+		if err := f.parseMetadata(); err != nil {
+			return err
+		}
+	}
+
+	// Duration and bitrate are not needed if only tags are requested
+	if f.options.TagsOnly {
+		return nil
+	}
+
+	// Seek to end of file to grab the final position, used to calculate bitrate, unless only
+	// a prefix of the stream is available
+	if !f.options.streaming {
+		n, err := f.reader.Seek(0, 2)
+		if err != nil {
+			return err
+		}
+		f.endPos = n
+	}
+
+	// Calculate duration and bitrate once, so they need not be recomputed on each call
+	if err := f.calculateProperties(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseMetadata parses the magic number and metadata blocks of a FLAC stream, noting the position
+// where audio frames begin
+func (f *FLACParser) parseMetadata() error {
+	// Verify the magic number at the start of the stream
+	if err := readMagicNumber(f.reader, flacMagicNumber, f.Format()); err != nil {
+		return err
+	}
+
+	// Begin parsing properties
+	if err := f.parseProperties(); err != nil {
+		return err
+	}
+
+	// Seek through the file and attempt to parse tags, unless STREAMINFO was the last metadata block
+	if !f.lastBlock {
+		if err := f.parseTags(); err != nil {
+			return err
+		}
+	}
+
+	// Note the position where audio frames begin, directly following the metadata blocks
+	audioStart, err := f.reader.Seek(0, 1)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	parser.endPos = n
+	f.audioStart = audioStart
 
-	// Return parser
-	return parser, nil
+	return nil
+}
+
+// SeekPoint represents a single seek point from a FLAC SEEKTABLE block, which maps a sample
+// number to the offset of the audio frame containing it
+type SeekPoint struct {
+	// SampleNumber is the number of the first sample in the target frame
+	SampleNumber uint64
+
+	// Offset is the offset in bytes of the target frame, relative to the first audio frame
+	Offset uint64
+
+	// FrameSamples is the number of samples in the target frame
+	FrameSamples uint16
 }
 
 // flacMetadataHeader represents the header for a FLAC metadata block
@@ -182,17 +734,233 @@ type flacMetadataHeader struct {
 	BlockLength uint32
 }
 
-// flacStreamInfoBlock represents the metadata from a FLAC STREAMINFO block
-type flacStreamInfoBlock struct {
-	SampleRate    uint16
+// FLACStreamInfo represents the metadata from a FLAC STREAMINFO block.  A copy of the
+// FLACStreamInfo for a FLAC stream is returned by Raw.
+type FLACStreamInfo struct {
+	MinBlockSize  uint16
+	MaxBlockSize  uint16
+	MinFrameSize  uint32
+	MaxFrameSize  uint32
+	SampleRate    uint32
 	ChannelCount  uint8
 	BitsPerSample uint16
 	SampleCount   uint64
 	MD5Checksum   string
 }
 
+// calculateProperties calculates the duration and bitrate of a FLAC stream from its STREAMINFO
+// block and size
+func (f *FLACParser) calculateProperties() error {
+	// A sample count of zero indicates an unknown number of samples, so when the entire stream
+	// is available, estimate the sample count using the last audio frame.  Audio frames of Ogg FLAC
+	// streams are split across Ogg pages, so no estimate is made.
+	f.sampleCount = f.properties.SampleCount
+	if f.sampleCount == 0 && f.endPos > 0 && !f.ogg {
+		n, err := f.estimateSampleCount()
+		if err != nil {
+			return err
+		}
+		f.sampleCount = n
+	}
+
+	// Check for zero sample rate, to prevent a division-by-zero panic
+	if f.properties.SampleRate == 0 {
+		return nil
+	}
+
+	f.duration = samplesDuration(f.sampleCount, uint64(f.properties.SampleRate))
+
+	// Check for zero duration or end position, to prevent a division-by-zero panic
+	if f.endPos == 0 || f.duration == 0 {
+		return nil
+	}
+	f.bitrate = int(float64(f.endPos*8) / f.duration.Seconds() / 1000)
+
+	return nil
+}
+
+// estimateSampleCount estimates the number of samples in a FLAC stream by locating the header of
+// the last audio frame, and adding its block size to the number of its first sample.  If no frame
+// header can be found, estimateSampleCount returns 0.
+func (f *FLACParser) estimateSampleCount() (uint64, error) {
+	// Scan only the end of the stream, since the last frame is typically small
+	size := f.endPos - f.audioStart
+	if size > flacFrameScanSize {
+		size = flacFrameScanSize
+	}
+	if size <= 0 {
+		return 0, nil
+	}
+
+	if _, err := f.reader.Seek(f.endPos-size, 0); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(f.reader, buf); err != nil {
+		return 0, err
+	}
+
+	// Search backwards for a frame sync code which begins a valid frame header
+	for i := len(buf) - 2; i >= 0; i-- {
+		if buf[i] != 0xff || buf[i+1]&0xfe != 0xf8 {
+			continue
+		}
+
+		if sample, blockSize, ok := flacParseFrameHeader(buf[i:], uint64(f.properties.MaxBlockSize)); ok {
+			return sample + blockSize, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// flacParseFrameHeader parses a FLAC audio frame header at the start of the input buffer, returning the
+// number of the frame's first sample and its block size.  fixedBlockSize is used to calculate the first
+// sample of frames in fixed block size streams.  If the header is invalid or its CRC-8 does not match,
+// flacParseFrameHeader returns false.
+func flacParseFrameHeader(buf []byte, fixedBlockSize uint64) (uint64, uint64, bool) {
+	// Parse the following fields after the 15-bit sync code and reserved bit:
+	//   1 - Blocking strategy (0: fixed, 1: variable)
+	//   4 - Block size code
+	//   4 - Sample rate code
+	//   4 - Channel assignment
+	//   3 - Sample size code
+	//   1 - Reserved
+	if len(buf) < 5 {
+		return 0, 0, false
+	}
+	variable := buf[1]&0x01 == 1
+	blockSizeCode := buf[2] >> 4
+	sampleRateCode := buf[2] & 0x0f
+
+	// Reject reserved and invalid values
+	if blockSizeCode == 0 || sampleRateCode == 0x0f || buf[3]>>4 > 10 || (buf[3]>>1)&0x07 == 3 || buf[3]&0x01 != 0 {
+		return 0, 0, false
+	}
+
+	// Decode the UTF-8 coded frame number (fixed) or sample number (variable), where the number
+	// of leading one bits in the first byte specifies the total number of bytes
+	ones := 0
+	for ones < 8 && buf[4]&(0x80>>uint(ones)) != 0 {
+		ones++
+	}
+	if ones == 1 || ones == 8 {
+		return 0, 0, false
+	}
+
+	length := 1
+	if ones > 1 {
+		length = ones
+	}
+
+	pos := 4 + length
+	if len(buf) < pos+1 {
+		return 0, 0, false
+	}
+
+	number := uint64(buf[4] & (0xff >> uint(ones+1)))
+	for _, b := range buf[5:pos] {
+		if b&0xc0 != 0x80 {
+			return 0, 0, false
+		}
+		number = number<<6 | uint64(b&0x3f)
+	}
+
+	// Determine the block size, which may be stored at the end of the header
+	var blockSize uint64
+	switch {
+	case blockSizeCode == 1:
+		blockSize = 192
+	case blockSizeCode <= 5:
+		blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		if len(buf) < pos+1 {
+			return 0, 0, false
+		}
+		blockSize = uint64(buf[pos]) + 1
+		pos++
+	case blockSizeCode == 7:
+		if len(buf) < pos+2 {
+			return 0, 0, false
+		}
+		blockSize = uint64(binary.BigEndian.Uint16(buf[pos:pos+2])) + 1
+		pos += 2
+	default:
+		blockSize = 256 << (blockSizeCode - 8)
+	}
+
+	// Skip the sample rate, which may be stored at the end of the header
+	switch sampleRateCode {
+	case 12:
+		pos++
+	case 13, 14:
+		pos += 2
+	}
+
+	// Verify the CRC-8 which follows the header
+	if len(buf) < pos+1 || flacCRC8(buf[:pos]) != buf[pos] {
+		return 0, 0, false
+	}
+
+	if !variable {
+		number *= fixedBlockSize
+	}
+
+	return number, blockSize, true
+}
+
+// writeFLACBlock writes a FLAC metadata block header to w, followed by length bytes of block data
+// copied from r
+func writeFLACBlock(w io.Writer, last bool, blockType byte, r io.Reader, length int64) error {
+	header := []byte{blockType, byte(length >> 16), byte(length >> 8), byte(length)}
+	if last {
+		header[0] |= 0x80
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	_, err := io.CopyN(w, r, length)
+	return err
+}
+
+// flacCRC8 calculates the CRC-8 used to protect FLAC frame headers, with polynomial
+// x^8 + x^2 + x^1 + x^0
+func flacCRC8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// invalidStream generates an invalid stream error for a FLAC stream, with the specified details
+func (f FLACParser) invalidStream(details string) error {
+	return TagError{
+		Err:     ErrInvalidStream,
+		Format:  f.Format(),
+		Details: details,
+	}
+}
+
+// truncatedComments generates an invalid stream error for a VORBISCOMMENT block which ends before all
+// of its fields
+func (f FLACParser) truncatedComments() error {
+	return f.invalidStream("VORBISCOMMENT block field extends past end of block")
+}
+
 // parseMetadataHeader retrieves metadata header information from a FLAC stream
-func (f *flacParser) parseMetadataHeader() (*flacMetadataHeader, error) {
+func (f *FLACParser) parseMetadataHeader() (*flacMetadataHeader, error) {
 	// Create and use a bit reader to parse the following fields:
 	//    1 - Last metadata block before audio (boolean)
 	//    7 - Metadata block type (should be 0, for streaminfo)
@@ -210,48 +978,247 @@ func (f *flacParser) parseMetadataHeader() (*flacMetadataHeader, error) {
 	}, nil
 }
 
-// parseTags retrieves metadata tags from a FLAC VORBISCOMMENT block
-func (f *flacParser) parseTags() error {
-	// Continuously parse and seek through blocks until we discover the VORBISCOMMENT block
+// parseTags retrieves metadata tags from a FLAC VORBISCOMMENT block, and notes the presence of
+// other metadata blocks of interest
+func (f *FLACParser) parseTags() error {
+	// Continuously parse and seek through blocks until we reach the last metadata block
 	for {
+		// Stop if parsing has been canceled
+		if err := f.options.err(); err != nil {
+			return err
+		}
+
 		header, err := f.parseMetadataHeader()
 		if err != nil {
 			return err
 		}
 
-		// Check for VORBISCOMMENT block, break so we can begin parsing tags
-		if header.BlockType == flacVorbisComment {
-			break
+		// Store the start of the block's data, so we can seek past it when finished
+		start, err := f.reader.Seek(0, 1)
+		if err != nil {
+			return err
+		}
+
+		switch header.BlockType {
+		case flacVorbisComment:
+			// Check for VORBISCOMMENT block, and parse tags
+			if err := f.parseVorbisComment(header.BlockLength); err != nil {
+				return err
+			}
+		case flacPicture:
+			// Check for PICTURE block, which indicates cover art is present, and parse the picture
+			f.hasPicture = true
+			if err := f.parsePicture(header.BlockLength); err != nil {
+				return err
+			}
+		case flacSeekTable:
+			// Check for SEEKTABLE block, and parse seek points
+			if err := f.parseSeekTable(header.BlockLength); err != nil {
+				return err
+			}
+		}
+
+		// Seek forward in stream to the end of the block
+		if _, err := f.reader.Seek(start+int64(header.BlockLength), 0); err != nil {
+			return err
 		}
 
-		// If last block and no VORBISCOMMENT block found, no tags
+		// If last block, all metadata has been parsed
 		if header.LastBlock {
 			return nil
 		}
+	}
+}
+
+// parsePicture retrieves a picture from a FLAC PICTURE block of the specified length, noting the
+// location of its image data so it may be read when requested.  Malformed pictures are skipped.
+func (f *FLACParser) parsePicture(length uint32) error {
+	start, err := f.reader.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+
+	// Parse the picture, which consists of:
+	//   - 4 bytes: picture type, big endian
+	//   - 4 bytes: MIME type length, big endian
+	//   - N bytes: MIME type
+	//   - 4 bytes: description length, big endian
+	//   - N bytes: description, UTF-8
+	//   - 16 bytes: width, height, color depth, and number of colors (unused)
+	//   - 4 bytes: picture data length, big endian
+	//   - N bytes: picture data
+	// Each field is checked against the bytes remaining in the block before it is read, and the
+	// picture is skipped if a field extends past the end of the block.
+	remaining := int64(length)
+	var malformed bool
+	readField := func(n int64) ([]byte, error) {
+		if malformed || n > remaining {
+			malformed = true
+			return nil, nil
+		}
+		remaining -= n
+
+		field := make([]byte, n)
+		_, err := io.ReadFull(f.reader, field)
+		return field, err
+	}
+	readLength := func() (int64, error) {
+		field, err := readField(4)
+		if err != nil || malformed {
+			return 0, err
+		}
+
+		return int64(binary.BigEndian.Uint32(field)), nil
+	}
+
+	pictureType, err := readLength()
+	if err != nil {
+		return err
+	}
+
+	var text [2][]byte
+	for i := range text {
+		n, err := readLength()
+		if err != nil {
+			return err
+		}
+
+		if text[i], err = readField(n); err != nil {
+			return err
+		}
+	}
+
+	if _, err := readField(16); err != nil {
+		return err
+	}
 
-		// If nothing found and not last block, seek forward in stream
-		if _, err := f.reader.Seek(int64(header.BlockLength), 1); err != nil {
+	dataLength, err := readLength()
+	if err != nil {
+		return err
+	}
+
+	// Ensure the picture data fits in the block
+	if malformed || dataLength > remaining {
+		return nil
+	}
+
+	f.pictures = append(f.pictures, embeddedPicture{
+		Picture: Picture{
+			Type:        PictureType(pictureType),
+			MIMEType:    string(text[0]),
+			Description: string(text[1]),
+		},
+		offset: start + int64(length) - remaining,
+		length: dataLength,
+	})
+
+	return nil
+}
+
+// readPicture reads the image data of a picture embedded in a PICTURE block.  The pictures of an Ogg
+// FLAC stream are read from its reassembled metadata blocks.
+func (f FLACParser) readPicture(p embeddedPicture) (Picture, error) {
+	reader := f.reader
+	if f.metadata != nil {
+		reader = f.metadata
+	}
+
+	data, err := readStreamBytes(reader, p.offset, p.length)
+	if err != nil {
+		return Picture{}, err
+	}
+
+	picture := p.Picture
+	picture.Data = data
+	return picture, nil
+}
+
+// parseSeekTable retrieves seek points from a FLAC SEEKTABLE block of the specified length
+func (f *FLACParser) parseSeekTable(length uint32) error {
+	// Ensure the block contains a whole number of seek points
+	if length%flacSeekPointSize != 0 {
+		return f.invalidStream(fmt.Sprintf("invalid SEEKTABLE block length: %d", length))
+	}
+
+	// Parse each seek point, which consists of:
+	//   - 8 bytes: sample number of first sample in target frame, big endian
+	//   - 8 bytes: offset of target frame from first frame, big endian
+	//   - 2 bytes: number of samples in target frame, big endian
+	var seekPoints []SeekPoint
+	for i := uint32(0); i < length/flacSeekPointSize; i++ {
+		if _, err := io.ReadFull(f.reader, f.buffer[:flacSeekPointSize]); err != nil {
 			return err
 		}
+
+		sample := binary.BigEndian.Uint64(f.buffer[0:8])
+		if sample == flacPlaceholderSeekPoint {
+			continue
+		}
+
+		seekPoints = append(seekPoints, SeekPoint{
+			SampleNumber: sample,
+			Offset:       binary.BigEndian.Uint64(f.buffer[8:16]),
+			FrameSamples: binary.BigEndian.Uint16(f.buffer[16:18]),
+		})
+	}
+
+	f.seekPoints = seekPoints
+	return nil
+}
+
+// parseVorbisComment retrieves metadata tags from a FLAC VORBISCOMMENT block of the specified length
+func (f *FLACParser) parseVorbisComment(blockLength uint32) error {
+	// Track the bytes remaining in the block, so that lengths which extend past its end are rejected
+	// before they are used to grow the shared buffer
+	remaining := int64(blockLength)
+	readUint32 := func() (uint32, error) {
+		var n uint32
+		if remaining < 4 {
+			return 0, f.truncatedComments()
+		}
+		if err := binary.Read(f.reader, binary.LittleEndian, &n); err != nil {
+			return 0, err
+		}
+		remaining -= 4
+
+		return n, nil
 	}
 
-	// Parse length fields
-	var length uint32
+	// readLength reads the length of a string, and ensures the string fits in the block
+	readLength := func() (uint32, error) {
+		length, err := readUint32()
+		if err != nil {
+			return 0, err
+		}
+
+		if int64(length) > remaining {
+			return 0, f.truncatedComments()
+		}
+		remaining -= int64(length)
+
+		return length, nil
+	}
 
 	// Read vendor string length
-	if err := binary.Read(f.reader, binary.LittleEndian, &length); err != nil {
+	length, err := readLength()
+	if err != nil {
 		return err
 	}
 
+	// Grow shared buffer if vendor string is too long for it
+	if int(length) > len(f.buffer) {
+		f.buffer = make([]byte, length)
+	}
+
 	// Read vendor string
-	if _, err := f.reader.Read(f.buffer[:length]); err != nil {
+	if _, err := io.ReadFull(f.reader, f.buffer[:length]); err != nil {
 		return err
 	}
-	f.encoder = string(f.buffer[:length])
+	f.vendor = string(f.buffer[:length])
 
 	// Read comment length (new allocation so we can use it as loop counter)
-	var commentLength uint32
-	if err := binary.Read(f.reader, binary.LittleEndian, &commentLength); err != nil {
+	commentLength, err := readUint32()
+	if err != nil {
 		return err
 	}
 
@@ -259,28 +1226,47 @@ func (f *flacParser) parseTags() error {
 	tagMap := map[string]string{}
 	for i := 0; i < int(commentLength); i++ {
 		// Read tag string length
-		if err := binary.Read(f.reader, binary.LittleEndian, &length); err != nil {
+		length, err := readLength()
+		if err != nil {
 			return err
 		}
 
+		// Grow shared buffer if tag string is too long for it
+		if int(length) > len(f.buffer) {
+			f.buffer = make([]byte, length)
+		}
+
 		// Read tag string
-		n, err := f.reader.Read(f.buffer[:length])
+		n, err := io.ReadFull(f.reader, f.buffer[:length])
 		if err != nil {
 			return err
 		}
 
 		// Split tag name and data, store in map
-		pair := strings.Split(string(f.buffer[:n]), "=")
-		tagMap[strings.ToUpper(pair[0])] = pair[1]
+		name, tag, ok := parseVorbisComment(string(f.buffer[:n]), f.options)
+		if !ok {
+			// Malformed comments are only an error with strict options
+			if f.options.strict() {
+				return TagError{
+					Err:     ErrInvalidStream,
+					Format:  f.Format(),
+					Details: "malformed Vorbis comment in VORBISCOMMENT block",
+				}
+			}
+
+			continue
+		}
+		tagMap[name] = tag
 	}
 
 	// Store tags
+	f.options.normalizeTags(tagMap)
 	f.tags = tagMap
 	return nil
 }
 
 // parseProperties retrieves stream properties from a FLAC STREAMINFO block
-func (f *flacParser) parseProperties() error {
+func (f *FLACParser) parseProperties() error {
 	// Read the metadata header for STREAMINFO block
 	header, err := f.parseMetadataHeader()
 	if err != nil {
@@ -290,32 +1276,33 @@ func (f *flacParser) parseProperties() error {
 	// Ensure that the metadata block type is STREAMINFO
 	if header.BlockType != flacStreamInfo {
 		return TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  f.Format(),
 			Details: "first metadata block is not type STREAMINFO",
 		}
 	}
 
-	// Ensure that STREAMINFO is not the last block
-	if header.LastBlock {
+	// Ensure that STREAMINFO is not the last block, unless lenient options are set, since
+	// a stream containing only STREAMINFO simply contains no tags
+	if header.LastBlock && !f.options.lenient() {
 		return TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  f.Format(),
 			Details: "STREAMINFO block is marked as last metadata block in stream",
 		}
 	}
-
-	// Seek forward past frame information, to sample rate
-	if _, err := f.reader.Seek(10, 1); err != nil {
-		return err
-	}
+	f.lastBlock = header.LastBlock
 
 	// Create and use a bit reader to parse the following fields:
+	//   16 - Minimum block size (in samples)
+	//   16 - Maximum block size (in samples)
+	//   24 - Minimum frame size (in bytes)
+	//   24 - Maximum frame size (in bytes)
 	//   20 - Sample rate
 	//    3 - Channel count (+1)
 	//    5 - Bits per sample (+1)
 	//   36 - Sample count
-	fields, err := bit.NewReader(f.reader).ReadFields(20, 3, 5, 36)
+	fields, err := bit.NewReader(f.reader).ReadFields(16, 16, 24, 24, 20, 3, 5, 36)
 	if err != nil {
 		return err
 	}
@@ -326,11 +1313,15 @@ func (f *flacParser) parseProperties() error {
 	}
 
 	// Store properties
-	f.properties = &flacStreamInfoBlock{
-		SampleRate:    uint16(fields[0]),
-		ChannelCount:  uint8(fields[1]) + 1,
-		BitsPerSample: uint16(fields[2]) + 1,
-		SampleCount:   uint64(fields[3]),
+	f.properties = &FLACStreamInfo{
+		MinBlockSize:  uint16(fields[0]),
+		MaxBlockSize:  uint16(fields[1]),
+		MinFrameSize:  uint32(fields[2]),
+		MaxFrameSize:  uint32(fields[3]),
+		SampleRate:    uint32(fields[4]),
+		ChannelCount:  uint8(fields[5]) + 1,
+		BitsPerSample: uint16(fields[6]) + 1,
+		SampleCount:   uint64(fields[7]),
 		MD5Checksum:   fmt.Sprintf("%x", f.buffer[:16]),
 	}
 
@@ -2,20 +2,22 @@ package taggolib
 
 import (
 	"bytes"
+	"encoding/binary"
 	"reflect"
 	"testing"
+	"time"
 )
 
-// TestFLAC verifies that all flacParser methods work properly
+// TestFLAC verifies that all FLACParser methods work properly
 func TestFLAC(t *testing.T) {
-	// Generate a flacParser
+	// Generate a FLACParser
 	flac, err := New(bytes.NewReader(flacFile))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
 	// Verify that we actually got a FLAC flac
-	if reflect.TypeOf(flac) != reflect.TypeOf(&flacParser{}) {
+	if reflect.TypeOf(flac) != reflect.TypeOf(&FLACParser{}) {
 		t.Fatalf("unexpected flac type: %v", reflect.TypeOf(flac))
 	}
 
@@ -42,10 +44,15 @@ func TestFLAC(t *testing.T) {
 	}
 
 	// Bitrate
-	if flac.Bitrate() != 202 {
+	if flac.Bitrate() != 206 {
 		t.Fatalf("mismatched property Bitrate: %v", flac.Bitrate())
 	}
 
+	// BPM
+	if flac.BPM() != 0 {
+		t.Fatalf("mismatched tag BPM: %v", flac.BPM())
+	}
+
 	// Channels
 	if flac.Channels() != 2 {
 		t.Fatalf("mismatched property Channels: %v", flac.Channels())
@@ -56,6 +63,11 @@ func TestFLAC(t *testing.T) {
 		t.Fatalf("mismatched tag Comment: %v", flac.Comment())
 	}
 
+	// Composer
+	if flac.Composer() != "" {
+		t.Fatalf("mismatched tag Composer: %v", flac.Composer())
+	}
+
 	// Date
 	if flac.Date() != "2014-01-01" {
 		t.Fatalf("mismatched tag Date: %v", flac.Date())
@@ -71,6 +83,11 @@ func TestFLAC(t *testing.T) {
 		t.Fatalf("mismatched property Duration: %v", flac.Duration().Seconds())
 	}
 
+	// EncodedBy
+	if flac.EncodedBy() != "" {
+		t.Fatalf("mismatched tag EncodedBy: %v", flac.EncodedBy())
+	}
+
 	// Encoder
 	if flac.Encoder() != "reference libFLAC 1.1.4 20070213" {
 		t.Fatalf("mismatched property Encoder: %v", flac.Encoder())
@@ -86,6 +103,16 @@ func TestFLAC(t *testing.T) {
 		t.Fatalf("mismatched tag Genre: %v", flac.Genre())
 	}
 
+	// HasPicture
+	if flac.HasPicture() != false {
+		t.Fatalf("mismatched property HasPicture: %v", flac.HasPicture())
+	}
+
+	// Lyrics
+	if flac.Lyrics() != "" {
+		t.Fatalf("mismatched tag Lyrics: %v", flac.Lyrics())
+	}
+
 	// SampleRate
 	if flac.SampleRate() != 44100 {
 		t.Fatalf("mismatched property SampleRate: %v", flac.SampleRate())
@@ -106,6 +133,21 @@ func TestFLAC(t *testing.T) {
 		t.Fatalf("mismatched tag TrackNumber: %v", flac.TrackNumber())
 	}
 
+	// TotalDiscs
+	if flac.TotalDiscs() != 0 {
+		t.Fatalf("mismatched tag TotalDiscs: %v", flac.TotalDiscs())
+	}
+
+	// TotalTracks
+	if flac.TotalTracks() != 0 {
+		t.Fatalf("mismatched tag TotalTracks: %v", flac.TotalTracks())
+	}
+
+	// Vendor
+	if flac.(*FLACParser).Vendor() != "reference libFLAC 1.1.4 20070213" {
+		t.Fatalf("mismatched property Vendor: %v", flac.(*FLACParser).Vendor())
+	}
+
 	// Check a few raw tags
 
 	if flac.Tag("ARTIST") != "Artist" {
@@ -125,3 +167,485 @@ func TestFLAC(t *testing.T) {
 		t.Fatalf("unexpected raw tag NOTEXISTS: %v", flac.Tag("NOTEXISTS"))
 	}
 }
+
+// TestFLACEncoderTag verifies that the ENCODER tag is preferred over the vendor string
+func TestFLACEncoderTag(t *testing.T) {
+	flac := &FLACParser{
+		tags:   map[string]string{tagEncoder: "transcoder 1.0"},
+		vendor: "reference libFLAC 1.2.1 20070917",
+	}
+
+	if flac.Encoder() != "transcoder 1.0" {
+		t.Fatalf("mismatched property Encoder: %v", flac.Encoder())
+	}
+
+	if flac.Vendor() != "reference libFLAC 1.2.1 20070917" {
+		t.Fatalf("mismatched property Vendor: %v", flac.Vendor())
+	}
+}
+
+// TestFLACTrackNumberTotal verifies that a track number stored along with the total number of
+// tracks, such as 3/12, is split into both values
+func TestFLACTrackNumberTotal(t *testing.T) {
+	flac, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flac.(TagWriter).SetTag("TRACKNUMBER", "3/12")
+
+	if flac.TrackNumber() != 3 {
+		t.Fatalf("mismatched tag TrackNumber: %v", flac.TrackNumber())
+	}
+
+	if flac.TotalTracks() != 12 {
+		t.Fatalf("mismatched tag TotalTracks: %v", flac.TotalTracks())
+	}
+}
+
+// TestFLACHasPicture verifies that a FLAC PICTURE metadata block is detected
+func TestFLACHasPicture(t *testing.T) {
+	// Insert a PICTURE block directly after the STREAMINFO block of the test file
+	picture := append([]byte{flacPicture, 0, 0, 4}, []byte{0, 0, 0, 3}...)
+	stream := append(append(append([]byte{}, flacFile[:42]...), picture...), flacFile[42:]...)
+
+	flac, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !flac.HasPicture() {
+		t.Fatalf("mismatched property HasPicture: %v", flac.HasPicture())
+	}
+
+	// Tags following the PICTURE block should still be parsed
+	if flac.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", flac.Artist())
+	}
+}
+
+// TestFLACPictures verifies that pictures are parsed from FLAC PICTURE metadata blocks, and may be
+// filtered by picture type
+func TestFLACPictures(t *testing.T) {
+	// flacPictureBlock generates a PICTURE block with the specified picture type and data
+	flacPictureBlock := func(pictureType PictureType, data []byte) []byte {
+		var block []byte
+		for _, field := range [][]byte{{0, 0, 0, byte(pictureType)}, {0, 0, 0, 9}, []byte("image/png"), {0, 0, 0, 5}, []byte("Cover")} {
+			block = append(block, field...)
+		}
+		block = append(block, make([]byte, 16)...)
+		block = append(block, 0, 0, 0, byte(len(data)))
+		block = append(block, data...)
+
+		return append([]byte{flacPicture, 0, 0, byte(len(block))}, block...)
+	}
+
+	// Insert two PICTURE blocks directly after the STREAMINFO block of the test file
+	front := Picture{PictureTypeFrontCover, "image/png", "Cover", []byte{0x89, 'P', 'N', 'G'}}
+	back := Picture{PictureTypeBackCover, "image/png", "Cover", []byte{0x89, 'P', 'N', 'G', 0}}
+
+	stream := append([]byte{}, flacFile[:42]...)
+	stream = append(stream, flacPictureBlock(back.Type, back.Data)...)
+	stream = append(stream, flacPictureBlock(front.Type, front.Data)...)
+	stream = append(stream, flacFile[42:]...)
+
+	flac, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parser := flac.(*FLACParser)
+
+	// Table of tests
+	var tests = []struct {
+		types    []PictureType
+		pictures []Picture
+	}{
+		{nil, []Picture{back, front}},
+		{[]PictureType{PictureTypeFrontCover}, []Picture{front}},
+		{[]PictureType{PictureTypeFrontCover, PictureTypeBackCover}, []Picture{back, front}},
+		{[]PictureType{PictureTypeArtist}, nil},
+	}
+
+	// Iterate all tests
+	for i, test := range tests {
+		if pictures := parser.Pictures(test.types...); !reflect.DeepEqual(pictures, test.pictures) {
+			t.Fatalf("[%02d] mismatched Pictures: %+v != %+v", i, pictures, test.pictures)
+		}
+	}
+
+	if picture, ok := parser.Picture(); !ok || !reflect.DeepEqual(picture, back) {
+		t.Fatalf("mismatched Picture: %+v", picture)
+	}
+
+	// A picture whose data length extends past the end of its block is skipped
+	block := flacPictureBlock(front.Type, front.Data)
+	block[len(block)-len(front.Data)-1]++
+	stream = append(append(append([]byte{}, flacFile[:42]...), block...), flacFile[42:]...)
+
+	flac, err = New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pictures := flac.(*FLACParser).Pictures(); !flac.HasPicture() || pictures != nil {
+		t.Fatalf("unexpected Pictures: %+v", pictures)
+	}
+}
+
+// TestFLACVorbisCommentLengths verifies that vendor and tag string lengths which extend past the end
+// of the VORBISCOMMENT block are rejected, rather than used to allocate a buffer
+func TestFLACVorbisCommentLengths(t *testing.T) {
+	// Locate the data of the VORBISCOMMENT block in the test file
+	block := 4
+	for flacFile[block]&0x7f != flacVorbisComment {
+		block += 4 + (int(flacFile[block+1])<<16 | int(flacFile[block+2])<<8 | int(flacFile[block+3]))
+	}
+	block += 4
+	vendor := int(binary.LittleEndian.Uint32(flacFile[block : block+4]))
+
+	// Offsets of the vendor length, and the length of the first tag string
+	for i, offset := range []int{block, block + 4 + vendor + 4} {
+		stream := append([]byte{}, flacFile...)
+		binary.LittleEndian.PutUint32(stream[offset:offset+4], 0x7fffffff)
+
+		if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+	}
+}
+
+// TestFLACStreamInfoLastBlock verifies that a STREAMINFO block marked as last is only accepted
+// with lenient options
+func TestFLACStreamInfoLastBlock(t *testing.T) {
+	// Copy the test file, and mark STREAMINFO as the last metadata block
+	stream := make([]byte, len(flacFile))
+	copy(stream, flacFile)
+	stream[4] |= 0x80
+
+	if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+
+	flac, err := NewWithOptions(bytes.NewReader(stream), Options{Strictness: Lenient})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if flac.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", flac.SampleRate())
+	}
+
+	if flac.Artist() != "" {
+		t.Fatalf("mismatched tag Artist: %v", flac.Artist())
+	}
+}
+
+// TestFLACBlockFrameSizes verifies that block and frame sizes are parsed from STREAMINFO
+func TestFLACBlockFrameSizes(t *testing.T) {
+	parser, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flac := parser.(*FLACParser)
+
+	if flac.MinBlockSize() != 4096 {
+		t.Fatalf("mismatched property MinBlockSize: %v", flac.MinBlockSize())
+	}
+
+	if flac.MaxBlockSize() != 4096 {
+		t.Fatalf("mismatched property MaxBlockSize: %v", flac.MaxBlockSize())
+	}
+
+	if flac.MinFrameSize() != 1534 {
+		t.Fatalf("mismatched property MinFrameSize: %v", flac.MinFrameSize())
+	}
+
+	if flac.MaxFrameSize() != 2642 {
+		t.Fatalf("mismatched property MaxFrameSize: %v", flac.MaxFrameSize())
+	}
+
+	// Sample rate must still be parsed correctly following the sizes
+	if flac.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", flac.SampleRate())
+	}
+}
+
+// TestFLACSeekPoints verifies that seek points are parsed from a SEEKTABLE block, skipping
+// placeholder points
+func TestFLACSeekPoints(t *testing.T) {
+	// Replace the SEEKTABLE block in the test file with one containing two seek points
+	// and a placeholder
+	seekTable := []byte{flacSeekTable, 0, 0, 54}
+	seekTable = append(seekTable, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x10, 0)
+	seekTable = append(seekTable, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0x10, 0, 0x10, 0)
+	seekTable = append(seekTable, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	stream := append(append(append([]byte{}, flacFile[:42]...), seekTable...), flacFile[64:]...)
+
+	parser, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	points := []SeekPoint{
+		{SampleNumber: 0, Offset: 0, FrameSamples: 4096},
+		{SampleNumber: 65536, Offset: 4096, FrameSamples: 4096},
+	}
+	if seekPoints := parser.(*FLACParser).SeekPoints(); !reflect.DeepEqual(seekPoints, points) {
+		t.Fatalf("mismatched SeekPoints: %v != %v", seekPoints, points)
+	}
+
+	// Tags following the SEEKTABLE block should still be parsed
+	if parser.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", parser.Artist())
+	}
+
+	// A SEEKTABLE block which does not contain a whole number of seek points is invalid
+	stream[45] = 53
+	if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestFLACHighSampleRate verifies that sample rates which do not fit in 16 bits are parsed from all
+// 20 bits of the STREAMINFO sample rate field
+func TestFLACHighSampleRate(t *testing.T) {
+	// Set the sample rate in a copy of the test file to 96kHz, preserving the channel count bits
+	// which share its final byte
+	sampleRate := 96000
+	stream := make([]byte, len(flacFile))
+	copy(stream, flacFile)
+	stream[18], stream[19] = byte(sampleRate>>12), byte(sampleRate>>4)
+	stream[20] = byte(sampleRate<<4) | stream[20]&0x0f
+
+	flac, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if flac.SampleRate() != sampleRate {
+		t.Fatalf("mismatched property SampleRate: %v", flac.SampleRate())
+	}
+
+	if info := flac.Raw().(FLACStreamInfo); info.SampleRate != uint32(sampleRate) {
+		t.Fatalf("mismatched STREAMINFO SampleRate: %v", info.SampleRate)
+	}
+
+	if duration := samplesDuration(flac.SampleCount(), uint64(sampleRate)); flac.Duration() != duration {
+		t.Fatalf("mismatched property Duration: %v != %v", flac.Duration(), duration)
+	}
+}
+
+// TestFLACShortBitrate verifies that the bitrate of a stream shorter than one second is calculated
+// from its exact duration
+func TestFLACShortBitrate(t *testing.T) {
+	// Set the sample rate in a copy of the test file to 441kHz, so its samples last half a second
+	sampleRate := 441000
+	stream := make([]byte, len(flacFile))
+	copy(stream, flacFile)
+	stream[18], stream[19] = byte(sampleRate>>12), byte(sampleRate>>4)
+	stream[20] = byte(sampleRate<<4) | stream[20]&0x0f
+
+	flac, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if flac.Duration() >= time.Second {
+		t.Fatalf("mismatched property Duration: %v", flac.Duration())
+	}
+
+	if b := int(float64(len(stream)*8) / flac.Duration().Seconds() / 1000); flac.Bitrate() != b {
+		t.Fatalf("mismatched property Bitrate: %v != %v", flac.Bitrate(), b)
+	}
+}
+
+// TestFLACZeroDuration verifies that streams with zero sample rate or unknown sample count report
+// zero duration and bitrate, rather than causing a division-by-zero panic
+func TestFLACZeroDuration(t *testing.T) {
+	// Clear fields in a copy of the test file, preserving the bits of adjacent fields
+	// which share their bytes
+	var tests = []struct {
+		modify    func(stream []byte) []byte
+		streaming bool
+	}{
+		// Sample rate
+		{func(stream []byte) []byte {
+			stream[18], stream[19], stream[20] = 0, 0, stream[20]&0x0f
+			return stream
+		}, false},
+		// Sample count, with no audio frames from which to estimate it
+		{func(stream []byte) []byte {
+			stream[21] &= 0xf0
+			copy(stream[22:26], []byte{0, 0, 0, 0})
+			return stream[:8304]
+		}, false},
+		// Sample count, with only a prefix of the stream available
+		{func(stream []byte) []byte {
+			stream[21] &= 0xf0
+			copy(stream[22:26], []byte{0, 0, 0, 0})
+			return stream
+		}, true},
+	}
+
+	for i, test := range tests {
+		stream := make([]byte, len(flacFile))
+		copy(stream, flacFile)
+		stream = test.modify(stream)
+
+		var flac Parser
+		var err error
+		if test.streaming {
+			flac, err = NewReader(bytes.NewReader(stream))
+		} else {
+			flac, err = New(bytes.NewReader(stream))
+		}
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if flac.Duration() != 0 {
+			t.Fatalf("[%02d] mismatched property Duration: %v", i, flac.Duration())
+		}
+
+		if flac.Bitrate() != 0 {
+			t.Fatalf("[%02d] mismatched property Bitrate: %v", i, flac.Bitrate())
+		}
+	}
+}
+
+// TestFLACEstimateSampleCount verifies that the duration of a stream with an unknown sample count
+// is estimated using the last audio frame
+func TestFLACEstimateSampleCount(t *testing.T) {
+	stream := make([]byte, len(flacFile))
+	copy(stream, flacFile)
+	stream[21] &= 0xf0
+	copy(stream[22:26], []byte{0, 0, 0, 0})
+
+	flac, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if int(flac.Duration().Seconds()) != 5 {
+		t.Fatalf("mismatched property Duration: %v", flac.Duration())
+	}
+
+	if flac.Bitrate() != 206 {
+		t.Fatalf("mismatched property Bitrate: %v", flac.Bitrate())
+	}
+}
+
+// TestFLACValidate verifies that Validate detects inconsistent STREAMINFO blocks and missing
+// audio frames
+func TestFLACValidate(t *testing.T) {
+	var tests = []struct {
+		modify func(stream []byte) []byte
+		valid  bool
+	}{
+		// Unmodified test file
+		{func(stream []byte) []byte { return stream }, true},
+		// Minimum block size larger than maximum block size
+		{func(stream []byte) []byte { stream[8] = 0x20; return stream }, false},
+		// Minimum frame size larger than maximum frame size
+		{func(stream []byte) []byte { stream[12] = 0x10; return stream }, false},
+		// Audio frames truncated
+		{func(stream []byte) []byte { return stream[:len(stream)/2] }, false},
+		// Audio frames missing frame sync
+		{func(stream []byte) []byte { stream[8304] = 0; return stream }, false},
+	}
+
+	for i, test := range tests {
+		stream := make([]byte, len(flacFile))
+		copy(stream, flacFile)
+
+		flac, err := New(bytes.NewReader(test.modify(stream)))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		err = flac.(*FLACParser).Validate()
+		if test.valid && err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if !test.valid && !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+	}
+}
+
+// TestFLACSave verifies that tags set on a FLAC parser are saved to a copy of the stream, while other
+// metadata blocks and the audio frames are preserved
+func TestFLACSave(t *testing.T) {
+	flac, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writer, ok := flac.(TagWriter)
+	if !ok {
+		t.Fatalf("FLAC parser does not implement TagWriter")
+	}
+
+	writer.SetTag("artist", "New Artist")
+	writer.SetTag("ALBUM", "")
+	writer.SetTag("Mood", "Calm")
+
+	buf := bytes.NewBuffer(nil)
+	if err := writer.Save(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved, err := New(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if saved.Artist() != "New Artist" {
+		t.Fatalf("mismatched tag Artist: %v", saved.Artist())
+	}
+	if saved.Album() != "" {
+		t.Fatalf("unexpected tag Album: %v", saved.Album())
+	}
+	if saved.Tag("MOOD") != "Calm" {
+		t.Fatalf("mismatched tag MOOD: %v", saved.Tag("MOOD"))
+	}
+	if saved.Title() != flac.Title() {
+		t.Fatalf("mismatched tag Title: %v != %v", saved.Title(), flac.Title())
+	}
+	if saved.Encoder() != flac.Encoder() {
+		t.Fatalf("mismatched Encoder: %v != %v", saved.Encoder(), flac.Encoder())
+	}
+
+	// The seek table and audio frames must be unchanged
+	if !reflect.DeepEqual(saved.(*FLACParser).SeekPoints(), flac.(*FLACParser).SeekPoints()) {
+		t.Fatalf("mismatched SeekPoints")
+	}
+
+	audio := flacFile[flac.(*FLACParser).audioStart:]
+	if !bytes.Equal(buf.Bytes()[saved.(*FLACParser).audioStart:], audio) {
+		t.Fatalf("audio frames were modified")
+	}
+	// A VORBISCOMMENT block is added to streams which have none, following the SEEKTABLE block
+	stream := append([]byte{}, flacFile[:64]...)
+	stream[42] |= 0x80
+	stream = append(stream, audio...)
+
+	flac, err = New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flac.(TagWriter).SetTag("TITLE", "Title")
+	buf.Reset()
+	if err := flac.(TagWriter).Save(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved, err = New(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if saved.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", saved.Title())
+	}
+}
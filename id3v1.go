@@ -0,0 +1,232 @@
+package taggolib
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// id3v1Size is the size of an ID3v1 tag, which is located at the end of a stream
+	id3v1Size = 128
+
+	// id3v1GenreUndefined is the ID3v1 genre value which indicates that no genre is set
+	id3v1GenreUndefined = 255
+)
+
+var (
+	// id3v1Marker is the marker used to identify an ID3v1 tag
+	id3v1Marker = []byte("TAG")
+)
+
+// id3v1Genres contains the names of genres referenced by number from ID3v1 tags and ID3v2 TCON
+// frames, including the Winamp extensions to the original list
+var id3v1Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop", // 0-7
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap", // 8-15
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks", // 16-23
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance", // 24-31
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise", // 32-39
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock", // 40-47
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream", // 48-55
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle", // 56-63
+	"Native American", "Cabaret", "New Wave", "Psychadelic", "Rave", "Showtunes", "Trailer", "Lo-Fi", // 64-71
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock", // 72-79
+	"Folk", "Folk-Rock", "National Folk", "Swing", "Fast Fusion", "Bebob", "Latin", "Revival", // 80-87
+	"Celtic", "Bluegrass", "Avantgarde", "Gothic Rock", "Progressive Rock", "Psychedelic Rock", "Symphonic Rock", "Slow Rock", // 88-95
+	"Big Band", "Chorus", "Easy Listening", "Acoustic", "Humour", "Speech", "Chanson", "Opera", // 96-103
+	"Chamber Music", "Sonata", "Symphony", "Booty Bass", "Primus", "Porn Groove", "Satire", "Slow Jam", // 104-111
+	"Club", "Tango", "Samba", "Folklore", "Ballad", "Power Ballad", "Rhythmic Soul", "Freestyle", // 112-119
+	"Duet", "Punk Rock", "Drum Solo", "A capella", "Euro-House", "Dance Hall", "Goa", "Drum & Bass", // 120-127
+	"Club-House", "Hardcore", "Terror", "Indie", "BritPop", "Negerpunk", "Polsk Punk", "Beat", // 128-135
+	"Christian Gangsta Rap", "Heavy Metal", "Black Metal", "Crossover", "Contemporary Christian", "Christian Rock", "Merengue", "Salsa", // 136-143
+	"Thrash Metal", "Anime", "JPop", "Synthpop", "Abstract", "Art Rock", "Baroque", "Bhangra", // 144-151
+	"Big Beat", "Breakbeat", "Chillout", "Downtempo", "Dub", "EBM", "Eclectic", "Electro", // 152-159
+	"Electroclash", "Emo", "Experimental", "Garage", "Global", "IDM", "Illbient", "Industro-Goth", // 160-167
+	"Jam Band", "Krautrock", "Leftfield", "Lounge", "Math Rock", "New Romantic", "Nu-Breakz", "Post-Punk", // 168-175
+	"Post-Rock", "Psytrance", "Shoegaze", "Space Rock", "Trop Rock", "World Music", "Neoclassical", "Audiobook", // 176-183
+	"Audio Theatre", "Neue Deutsche Welle", "Podcast", "Indie Rock", "G-Funk", "Dubstep", "Garage Rock", "Psybient", // 184-191
+}
+
+// parseID3v1 locates an ID3v1 tag at the end of an input stream, and decodes its fields into a tag map.
+// If the stream does not contain an ID3v1 tag, parseID3v1 returns a nil map.  The position of the input
+// stream is not restored.
+func parseID3v1(reader io.ReadSeeker) (map[string]string, error) {
+	// Streams too short to contain a tag contain no ID3v1 tag
+	if _, err := reader.Seek(-id3v1Size, 2); err != nil {
+		return nil, nil
+	}
+
+	tag := make([]byte, id3v1Size)
+	if _, err := io.ReadFull(reader, tag); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(tag[:len(id3v1Marker)], id3v1Marker) {
+		return nil, nil
+	}
+
+	// ID3v1 tags contain fixed-length fields:
+	//    3 - "TAG" marker
+	//   30 - Title
+	//   30 - Artist
+	//   30 - Album
+	//    4 - Year
+	//   30 - Comment (ID3v1.1: 28 byte comment, zero byte, and track number)
+	//    1 - Genre
+	tagMap := map[string]string{}
+	fields := []struct {
+		name string
+		data []byte
+	}{
+		{tagTitle, tag[3:33]},
+		{tagArtist, tag[33:63]},
+		{tagAlbum, tag[63:93]},
+		{tagDate, tag[93:97]},
+		{tagComment, tag[97:127]},
+	}
+
+	// ID3v1.1 stores a track number in the final byte of the comment, when the byte before it is zero
+	if tag[125] == 0 && tag[126] != 0 {
+		fields[4].data = tag[97:125]
+		tagMap[tagTrackNumber] = strconv.Itoa(int(tag[126]))
+	}
+
+	for _, f := range fields {
+		if value := id3v1DecodeText(f.data); value != "" {
+			tagMap[f.name] = value
+		}
+	}
+
+	// Genre is stored as an index, using the same "(N)" form as ID3v2 numeric genres
+	if genre := tag[127]; genre != id3v1GenreUndefined {
+		tagMap[tagGenre] = "(" + strconv.Itoa(int(genre)) + ")"
+	}
+
+	return tagMap, nil
+}
+
+// updateID3v1 updates the fields of an ID3v1 tag in place for each modified tag which it can hold,
+// using the values in the input tag map.  Text is truncated to fit each field, and characters which
+// cannot be encoded as ISO-8859-1 are replaced.
+func updateID3v1(tag []byte, tags map[string]string, modified map[string]bool) {
+	fields := []struct {
+		name string
+		data []byte
+	}{
+		{tagTitle, tag[3:33]},
+		{tagArtist, tag[33:63]},
+		{tagAlbum, tag[63:93]},
+		{tagDate, tag[93:97]},
+		{tagComment, tag[97:125]},
+	}
+
+	// Preserve the ID3v1.0 comment field width, unless a track number is stored
+	if tag[125] != 0 {
+		fields[4].data = tag[97:127]
+	}
+
+	for _, f := range fields {
+		if modified[f.name] {
+			id3v1EncodeText(f.data, tags[f.name])
+		}
+	}
+
+	// Store a track number using ID3v1.1, when the comment field permits it
+	if modified[tagTrackNumber] && tag[125] == 0 {
+		track, _ := strconv.Atoi(strings.SplitN(tags[tagTrackNumber], "/", 2)[0])
+		if track < 0 || track > 255 {
+			track = 0
+		}
+		tag[126] = byte(track)
+	}
+}
+
+// id3v1EncodeText encodes text into an ID3v1 text field as ISO-8859-1, padded with zero bytes
+func id3v1EncodeText(data []byte, text string) {
+	for i := range data {
+		data[i] = 0
+	}
+
+	i := 0
+	for _, r := range text {
+		if i == len(data) {
+			break
+		}
+
+		if r > 0xff {
+			r = '?'
+		}
+		data[i] = byte(r)
+		i++
+	}
+}
+
+// id3v1DecodeText decodes an ID3v1 text field, which is ISO-8859-1 text padded with zero bytes or spaces
+func id3v1DecodeText(data []byte) string {
+	if index := bytes.IndexByte(data, 0); index != -1 {
+		data = data[:index]
+	}
+
+	// ISO-8859-1 bytes map directly to Unicode code points
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+
+	return strings.TrimRight(string(runes), " ")
+}
+
+// genreName translates a numeric genre reference, such as "17" or "(17)", into the name of the genre
+// it references.  ID3v2.3 genres may follow references with refinement text, such as "(4)Eurodisco",
+// which is returned in their place, and "((" escapes a literal parenthesis.  Free-text genres and
+// references to unknown genres are returned unchanged.
+func genreName(genre string) string {
+	// ID3v2.4 references genres using plain numbers
+	if n, err := strconv.Atoi(genre); err == nil {
+		if n >= 0 && n < len(id3v1Genres) {
+			return id3v1Genres[n]
+		}
+
+		return genre
+	}
+
+	// ID3v1 and ID3v2.3 reference genres in parentheses, using the first recognized reference
+	var name string
+	text := genre
+	for strings.HasPrefix(text, "(") && !strings.HasPrefix(text, "((") {
+		end := strings.IndexByte(text, ')')
+		if end == -1 {
+			return genre
+		}
+
+		ref := text[1:end]
+		text = text[end+1:]
+		if name != "" {
+			continue
+		}
+
+		switch ref {
+		case "RX":
+			name = "Remix"
+		case "CR":
+			name = "Cover"
+		default:
+			if n, err := strconv.Atoi(ref); err == nil && n >= 0 && n < len(id3v1Genres) {
+				name = id3v1Genres[n]
+			}
+		}
+	}
+
+	// Refinement text takes precedence over references
+	if text != "" {
+		return strings.TrimPrefix(text, "(")
+	}
+
+	if name == "" {
+		return genre
+	}
+
+	return name
+}
@@ -0,0 +1,129 @@
+package taggolib
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// id3v1Tag generates an ID3v1 tag with the specified fields, and a track number if it is not zero
+func id3v1Tag(title string, artist string, album string, year string, comment string, track byte, genre byte) []byte {
+	tag := make([]byte, id3v1Size)
+	copy(tag, id3v1Marker)
+	copy(tag[3:33], title)
+	copy(tag[33:63], artist)
+	copy(tag[63:93], album)
+	copy(tag[93:97], year)
+	copy(tag[97:127], comment)
+	if track != 0 {
+		tag[125] = 0
+		tag[126] = track
+	}
+	tag[127] = genre
+
+	return tag
+}
+
+// TestParseID3v1 verifies that parseID3v1 locates and decodes ID3v1 tags
+func TestParseID3v1(t *testing.T) {
+	var tests = []struct {
+		stream []byte
+		tags   map[string]string
+	}{
+		// No ID3v1 tag
+		{[]byte("audio"), nil},
+		{make([]byte, 256), nil},
+		// ID3v1 tag
+		{append([]byte("audio"), id3v1Tag("Title", "Artist", "Album", "2014", "Comment", 0, 17)...), map[string]string{
+			tagTitle:   "Title",
+			tagArtist:  "Artist",
+			tagAlbum:   "Album",
+			tagDate:    "2014",
+			tagComment: "Comment",
+			tagGenre:   "(17)",
+		}},
+		// ID3v1.1 tag with track number, space padding, ISO-8859-1 text, and undefined genre
+		{id3v1Tag("Title   ", "Art\xefst", "", "", "", 7, id3v1GenreUndefined), map[string]string{
+			tagTitle:       "Title",
+			tagArtist:      "Artïst",
+			tagTrackNumber: "7",
+		}},
+	}
+
+	for i, test := range tests {
+		tags, err := parseID3v1(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(tags, test.tags) {
+			t.Fatalf("[%02d] mismatched tags: %v != %v", i, tags, test.tags)
+		}
+	}
+}
+
+// TestUpdateID3v1 verifies that updateID3v1 updates only modified fields, truncating and encoding text
+func TestUpdateID3v1(t *testing.T) {
+	tag := id3v1Tag("Title", "Artist", "Album", "2014", "Comment", 0, 17)
+	updateID3v1(tag, map[string]string{
+		tagTitle:       "A title which is much too long for an ID3v1 tag",
+		tagAlbum:       "",
+		tagArtist:      "Unmodified",
+		tagTrackNumber: "3/12",
+		tagComment:     "é世",
+	}, map[string]bool{
+		tagTitle:       true,
+		tagAlbum:       true,
+		tagTrackNumber: true,
+		tagComment:     true,
+	})
+
+	tags, err := parseID3v1(bytes.NewReader(tag))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		tagTitle:       "A title which is much too long",
+		tagArtist:      "Artist",
+		tagDate:        "2014",
+		tagComment:     "é?",
+		tagTrackNumber: "3",
+		tagGenre:       "(17)",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Fatalf("unexpected tags:\n- want: %v\n-  got: %v", want, tags)
+	}
+}
+
+// TestGenreName verifies that numeric genre references are translated into genre names, while
+// free-text genres are left unchanged
+func TestGenreName(t *testing.T) {
+	var tests = []struct {
+		genre string
+		name  string
+	}{
+		{"", ""},
+		{"Rock", "Rock"},
+		{"(17)", "Rock"},
+		{"17", "Rock"},
+		{"0", "Blues"},
+		{"(191)", "Psybient"},
+		{"(51)(39)", "Techno-Industrial"},
+		{"(4)Eurodisco", "Eurodisco"},
+		{"(RX)", "Remix"},
+		{"(CR)", "Cover"},
+		{"((Parenthesized)", "(Parenthesized)"},
+		{"(17)((Rock)", "(Rock)"},
+		{"(255)", "(255)"},
+		{"999", "999"},
+		{"(17", "(17"},
+		{"1970s", "1970s"},
+	}
+
+	for i, test := range tests {
+		if name := genreName(test.genre); name != test.name {
+			t.Fatalf("[%02d] mismatched genre name for %q: %q != %q", i, test.genre, name, test.name)
+		}
+	}
+}
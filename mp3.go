@@ -5,75 +5,172 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf16"
 
 	"github.com/eaburns/bit"
 )
 
 const (
 	// Tags specific to ID3v2 MP3
-	mp3TagEncoder = "ENCODER"
-	mp3TagLength  = "LENGTH"
+	mp3TagLength = "LENGTH"
 
 	// Samples per frame for MPEG1 Layer III
 	mp3SamplesPerFrame = 1152
+
+	// Number of bytes beyond the ID3v2 tag size which are scanned for the first MP3 frame sync,
+	// before the stream is considered invalid
+	mp3FrameSyncMargin = 64 * 1024
+
+	// ID3v2.4 frame format flag which indicates that unsynchronization was applied to a frame
+	mp3FrameUnsynchronization = 0x0002
+
+	// mp3ID3v2FooterSize is the size of the optional ID3v2.4 footer, which follows the frames
+	// and padding of an ID3v2 tag
+	mp3ID3v2FooterSize = 10
+
+	// mp3FrameReadSize is the minimum number of bytes read from the start of the first MP3 frame,
+	// which is enough to contain the frame header, a Xing header, and a LAME tag
+	mp3FrameReadSize = 512
+
+	// Xing header flags which indicate the presence of optional fields
+	mp3XingFrames  = 0x01
+	mp3XingBytes   = 0x02
+	mp3XingTOC     = 0x04
+	mp3XingQuality = 0x08
+
+	// mp3SavePadding is the number of bytes of padding added to an ID3v2 tag which is saved, when
+	// its frames no longer fit in the space occupied by the original tag
+	mp3SavePadding = 1024
+
+	// mp3LAMETagSize is the size of the portion of a LAME tag which is parsed, up to and including
+	// the encoder delay and padding fields
+	mp3LAMETagSize = 24
+
+	// LAME tag VBR methods, which indicate the rate management mode used by the encoder
+	mp3LAMECBR      = 1
+	mp3LAMEABR      = 2
+	mp3LAMEVBROld   = 3
+	mp3LAMEVBRMTRH  = 4
+	mp3LAMEVBRMT    = 5
+	mp3LAMEVBRFull  = 6
+	mp3LAMECBR2Pass = 8
+	mp3LAMEABR2Pass = 9
 )
 
 var (
 	// mp3MagicNumber is the magic number used to identify a MP3 audio stream
 	mp3MagicNumber = []byte("ID3")
+	// mp3FooterMagicNumber is the identifier of an ID3v2.4 footer, which marks a tag appended to the
+	// end of a MP3 audio stream
+	mp3FooterMagicNumber = []byte("3DI")
+	// mp3FrameSync is the 11 bit frame sync used to identify a MP3 audio stream without an ID3v2 tag,
+	// and mp3FrameSyncMask masks the bits of a frame header which make up the frame sync
+	mp3FrameSync     = []byte{0xff, 0xe0}
+	mp3FrameSyncMask = []byte{0xff, 0xe0}
 	// mp3APICFrame is the name of the APIC, or attached picture ID3 frame
 	mp3APICFrame = []byte("APIC")
+	// mp3PICFrame is the name of the PIC, or ID3v2.2 attached picture ID3 frame
+	mp3PICFrame = []byte("PIC")
 	// mp3XingMarker is the bytes which identify a Xing VBR header
 	mp3XingMarker = []byte("Xing")
 	// mp3InfoMarker is the bytes which identify a Info VBR header
 	mp3InfoMarker = []byte("Info")
 )
 
-// mp3Parser represents a MP3 audio metadata tag parser
-type mp3Parser struct {
+// MP3Parser represents an MP3 audio metadata tag parser.  A *MP3Parser is returned by New for
+// MP3 streams, and may be type-asserted to access methods specific to the format.
+type MP3Parser struct {
+	audioEnd   int64
+	audioStart int64
+	endPos     int64
+	hasPicture bool
 	id3Header  *mp3ID3v2Header
-	mp3Header  *mp3Header
+	leading    bool
+	modified   map[string]bool
+	mp3Header  *MP3Header
+	multi      map[string][]string
+	options    Options
+	pictures   []mp3Picture
 	reader     io.ReadSeeker
+	start      int64
+	tagEnd     int64
 	tags       map[string]string
 	xingHeader *mp3XingHeader
+
+	// Shared buffer stored as field to prevent unneeded allocations
+	buffer []byte
+}
+
+// init registers the MP3 format with New, for streams beginning with either an ID3v2 tag or
+// a MP3 frame sync
+func init() {
+	newParser := func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newMP3Parser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	}
+
+	registerFormat(FormatMP3, mp3MagicNumber, 0, newParser)
+	registerFormatMask(FormatMP3, mp3FrameSync, mp3FrameSyncMask, 0, newParser)
 }
 
-// taggolib issue #3 - ID3v2.4 requires use of synch-safe frameLength values
-// taken from github.com/ascherkus/go-id3/blob/master/src/id3/util.go
-func unSynch(data [4]byte) int32 {
-	size := int32(0)
-	for i, b := range data {
-		shift := uint32(len(data)-i-1) * 7
-		size |= int32(b&0x7f) << shift
+// readSynchsafe reads a 32-bit synch-safe integer from an input reader.  Synch-safe integers store
+// 7 bits in each byte, with the most significant bit always zero, so only 28 bits are significant.
+// taggolib issue #3 - the ID3v2 tag size and ID3v2.4 frame sizes are synch-safe integers
+func readSynchsafe(reader io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(reader, b[:]); err != nil {
+		return 0, err
 	}
-	return size
+
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f), nil
 }
 
 // Album returns the Album tag for this stream
-func (m mp3Parser) Album() string {
+func (m MP3Parser) Album() string {
 	return m.tags[tagAlbum]
 }
 
 // AlbumArtist returns the AlbumArtist tag for this stream
-func (m mp3Parser) AlbumArtist() string {
+func (m MP3Parser) AlbumArtist() string {
 	return m.tags[tagAlbumArtist]
 }
 
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (m MP3Parser) AlbumArtistSort() string {
+	return m.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (m MP3Parser) AlbumSort() string {
+	return m.tags[tagAlbumSort]
+}
+
 // Artist returns the Artist tag for this stream
-func (m mp3Parser) Artist() string {
+func (m MP3Parser) Artist() string {
 	return m.tags[tagArtist]
 }
 
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (m MP3Parser) ArtistSort() string {
+	return m.tags[tagArtistSort]
+}
+
 // BitDepth returns the bits-per-sample of this stream
-func (m mp3Parser) BitDepth() int {
+func (m MP3Parser) BitDepth() int {
 	return 16
 }
 
 // Bitrate calculates the audio bitrate for this stream
-func (m mp3Parser) Bitrate() int {
+func (m MP3Parser) Bitrate() int {
 	// Check for a Xing header, meaning that the bitrate was calculated there
 	if m.xingHeader != nil && m.xingHeader.Bitrate > 0 {
 		return m.xingHeader.Bitrate
@@ -83,24 +180,61 @@ func (m mp3Parser) Bitrate() int {
 	return mp3BitrateMap[m.mp3Header.Bitrate]
 }
 
+// BPM returns the BPM (beats per minute) tag for this stream
+func (m MP3Parser) BPM() int {
+	bpm, err := strconv.Atoi(m.tags[tagBPM])
+	if err != nil {
+		return 0
+	}
+
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, as indicated by its channel mode:
+// "stereo", "joint stereo", "dual channel", or "mono"
+func (m MP3Parser) ChannelLayout() string {
+	return m.StereoMode().String()
+}
+
 // Channels returns the number of channels for this stream
-func (m mp3Parser) Channels() int {
+func (m MP3Parser) Channels() int {
 	return mp3ChannelModeMap[m.mp3Header.ChannelMode]
 }
 
 // Comment returns the Comment tag for this stream
-func (m mp3Parser) Comment() string {
+func (m MP3Parser) Comment() string {
 	return m.tags[tagComment]
 }
 
+// Composer returns the Composer tag for this stream
+func (m MP3Parser) Composer() string {
+	return m.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (m MP3Parser) Conductor() string {
+	return m.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (m MP3Parser) ContentType() string {
+	return m.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (m MP3Parser) Copyright() string {
+	return m.tags[tagCopyright]
+}
+
 // Date returns the Date tag for this stream
-func (m mp3Parser) Date() string {
+func (m MP3Parser) Date() string {
 	return m.tags[tagDate]
 }
 
 // DiscNumber returns the DiscNumber tag for this stream
-func (m mp3Parser) DiscNumber() int {
-	disc, err := strconv.Atoi(m.tags[tagDiscNumber])
+func (m MP3Parser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(m.tags[tagDiscNumber], "/")[0])
 	if err != nil {
 		return 0
 	}
@@ -109,263 +243,1583 @@ func (m mp3Parser) DiscNumber() int {
 }
 
 // Duration returns the time duration for this stream
-func (m mp3Parser) Duration() time.Duration {
-	// Check for a Xing header, meaning that the duration was calculated there
-	if m.xingHeader != nil && m.xingHeader.Duration > 0 {
-		return time.Duration(m.xingHeader.Duration) * time.Second
+func (m MP3Parser) Duration() time.Duration {
+	// Check for a Xing header, meaning that the duration can be calculated from its frame count
+	if m.xingHeader != nil && m.xingHeader.FrameCount > 0 && m.SampleRate() > 0 {
+		return m.xingHeader.Duration
 	}
 
-	// Parse length tag as integer
-	length, err := strconv.Atoi(m.tags[mp3TagLength])
-	if err != nil {
-		return time.Duration(0 * time.Second)
+	// Parse length tag as integer, in milliseconds
+	if length, err := strconv.Atoi(m.tags[mp3TagLength]); err == nil {
+		return time.Duration(length) * time.Millisecond
+	}
+
+	// Otherwise, assume a constant bitrate stream, and calculate the duration from the size of its
+	// audio frames and the bitrate from the MP3 header, which is unknown for free format streams
+	bitrate := int64(mp3BitrateMap[m.mp3Header.Bitrate])
+	if bitrate == 0 || m.audioEnd <= m.audioStart {
+		return 0
+	}
+
+	return time.Duration((m.audioEnd - m.audioStart) * 8 * int64(time.Millisecond) / bitrate)
+}
+
+// EncodedBy returns the EncodedBy tag for this stream
+func (m MP3Parser) EncodedBy() string {
+	return m.tags[tagEncodedBy]
+}
+
+// EncoderDelay returns the number of samples of delay added by the encoder at the start of this
+// stream, as stored in a LAME tag, or 0 if no LAME tag is present
+func (m MP3Parser) EncoderDelay() int {
+	if m.xingHeader == nil {
+		return 0
+	}
+
+	return int(m.xingHeader.LAME.EncoderDelay)
+}
+
+// EncoderPadding returns the number of samples of padding added by the encoder at the end of this
+// stream, as stored in a LAME tag, or 0 if no LAME tag is present
+func (m MP3Parser) EncoderPadding() int {
+	if m.xingHeader == nil {
+		return 0
 	}
 
-	return time.Duration(length/1000) * time.Second
+	return int(m.xingHeader.LAME.EncoderPadding)
 }
 
 // Encoder returns the encoder for this stream
-func (m mp3Parser) Encoder() string {
-	return m.tags[mp3TagEncoder]
+func (m MP3Parser) Encoder() string {
+	return m.tags[tagEncoder]
+}
+
+// EncoderSettings returns the encoder settings stored in the LAME tag of this stream, and whether or
+// not a LAME tag is present
+func (m MP3Parser) EncoderSettings() (MP3EncoderSettings, bool) {
+	if m.xingHeader == nil || m.xingHeader.LAME.Version == "" {
+		return MP3EncoderSettings{}, false
+	}
+	lame := m.xingHeader.LAME
+
+	settings := MP3EncoderSettings{
+		Mode:       BitrateModeUnknown,
+		VBRQuality: -1,
+		Bitrate:    int(lame.Bitrate),
+		Lowpass:    int(lame.Lowpass) * 100,
+	}
+
+	switch lame.VBRMethod {
+	case mp3LAMECBR, mp3LAMECBR2Pass:
+		settings.Mode = BitrateModeCBR
+	case mp3LAMEABR, mp3LAMEABR2Pass:
+		settings.Mode = BitrateModeABR
+	case mp3LAMEVBROld, mp3LAMEVBRMTRH, mp3LAMEVBRMT, mp3LAMEVBRFull:
+		settings.Mode = BitrateModeVBR
+
+		// LAME stores a quality of 100 - (10 * VBR quality) - algorithm quality in the Xing header
+		if q := m.xingHeader.Quality; q > 0 && q <= 100 {
+			settings.VBRQuality = int(100-q) / 10
+		}
+	}
+
+	return settings, true
 }
 
 // Format returns the name of the MP3 format
-func (m mp3Parser) Format() string {
-	return "MP3"
+func (m MP3Parser) Format() string {
+	return m.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (m MP3Parser) FormatID() Format {
+	return FormatMP3
+}
+
+// Genre returns the Genre tag for this stream, translating numeric references to ID3v1 genres,
+// such as "(17)", into the names of those genres
+func (m MP3Parser) Genre() string {
+	return genreName(m.tags[tagGenre])
+}
+
+// Grouping returns the Grouping (content group) tag for this stream
+func (m MP3Parser) Grouping() string {
+	return m.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+func (m MP3Parser) HasPicture() bool {
+	return m.hasPicture
+}
+
+// HeaderFingerprint returns a stable identifier derived from the ID3v2 and MP3 headers and size of this stream
+func (m MP3Parser) HeaderFingerprint() []byte {
+	// Include the Xing header, if one is present
+	if m.xingHeader != nil {
+		return headerFingerprint(m.endPos, *m.id3Header, *m.mp3Header, *m.xingHeader)
+	}
+
+	return headerFingerprint(m.endPos, *m.id3Header, *m.mp3Header)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (m MP3Parser) InitialKey() string {
+	return firstTag(m.tags, tagInitialKey, tagKey)
+}
+
+// IsVBR returns whether or not this stream is encoded with a variable bitrate, as indicated by
+// the presence of a Xing header.  CBR streams, with an Info header or no header, return false.
+func (m MP3Parser) IsVBR() bool {
+	return m.xingHeader != nil && m.xingHeader.VBR
+}
+
+// Language returns the Language tag for this stream
+func (m MP3Parser) Language() string {
+	return m.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream
+func (m MP3Parser) Lyrics() string {
+	return m.tags[tagLyrics]
+}
+
+// Mood returns the Mood tag for this stream
+func (m MP3Parser) Mood() string {
+	return m.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (m MP3Parser) OriginalFilename() string {
+	return m.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (m MP3Parser) Properties() AudioProperties {
+	return audioProperties(&m)
+}
+
+// Picture returns the first picture embedded in an APIC or ID3v2.2 PIC frame of this stream, and
+// whether or not a picture is present
+func (m MP3Parser) Picture() (Picture, bool) {
+	for _, p := range m.pictures {
+		if picture, err := m.readPicture(p); err == nil {
+			return picture, true
+		}
+	}
+
+	return Picture{}, false
+}
+
+// Pictures returns the pictures embedded in APIC or ID3v2.2 PIC frames of this stream, in the order
+// they are stored.  If any picture types are specified, only pictures of those types are returned.
+// The image data of each picture is read from the stream when Pictures is called, and pictures whose
+// data cannot be read are omitted.
+func (m MP3Parser) Pictures(types ...PictureType) []Picture {
+	var pictures []Picture
+	for _, p := range m.pictures {
+		if !hasPictureType(p.Type, types) {
+			continue
+		}
+
+		if picture, err := m.readPicture(p); err == nil {
+			pictures = append(pictures, picture)
+		}
+	}
+
+	return pictures
 }
 
-// Genre returns the Genre tag for this stream
-func (m mp3Parser) Genre() string {
-	return m.tags[tagGenre]
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (m MP3Parser) Publisher() string {
+	return firstTag(m.tags, tagPublisher, tagLabel, tagOrganization)
 }
 
-// Publisher returns the Publisher (record-label) tag for this stream
-func (m mp3Parser) Publisher() string {
-	return m.tags[tagPublisher]
+// Raw returns a copy of the format-specific header parsed from this stream, as a MP3Header
+func (m MP3Parser) Raw() interface{} {
+	return *m.mp3Header
+}
+
+// RawTagBlock returns a copy of the raw bytes of the ID3v2 tag at the start of this stream, including
+// its header, padding, and footer.  If the stream does not begin with an ID3v2 tag, nil is returned.
+func (m MP3Parser) RawTagBlock() ([]byte, error) {
+	if !m.leading {
+		return nil, nil
+	}
+
+	return readRange(m.reader, m.start, m.audioStart)
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (m MP3Parser) Remixer() string {
+	return firstTag(m.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (m MP3Parser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(m.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (m MP3Parser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(m.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (m MP3Parser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(m.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (m MP3Parser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(m.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new MP3 stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (m *MP3Parser) Reset(reader io.ReadSeeker) error {
+	*m = MP3Parser{
+		buffer:  m.buffer,
+		options: m.options,
+		reader:  reader,
+	}
+
+	return m.parse()
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (m MP3Parser) SampleCount() uint64 {
+	// Check for a Xing header, which contains the exact number of frames
+	if m.xingHeader != nil && m.xingHeader.FrameCount > 0 {
+		return uint64(m.xingHeader.FrameCount) * mp3SamplesPerFrame
+	}
+
+	// Estimate the sample count using the duration
+	return uint64(m.Duration().Seconds() * float64(m.SampleRate()))
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (m MP3Parser) SampleFormat() SampleFormat {
+	// MP3 is a lossy format, so its decoded samples have no inherent format
+	return SampleFormatUnknown
 }
 
 // SampleRate returns the sample rate in Hertz for this stream
-func (m mp3Parser) SampleRate() int {
+func (m MP3Parser) SampleRate() int {
 	return mp3SampleRateMap[m.mp3Header.SampleRate]
 }
 
+// Save writes a copy of this MP3 stream to w, replacing the frames of its ID3v2 tag for each tag set
+// on this parser.  Other frames are copied unchanged, and if the new frames fit in the space occupied
+// by the original tag, its padding is adjusted so the tag remains the same size.  If the stream has
+// no ID3v2 tag, an ID3v2.4 tag containing all of its tags is added.  The audio frames are copied
+// unchanged, except for an ID3v1 tag at the end of the stream, which is updated with each tag set on
+// this parser that it can hold.
+// BUG(mdlayher): MP3: Save does not update APEv2 tags, which may continue to hold tags removed from the ID3v2 tag
+// BUG(mdlayher): MP3: Save does not support ID3v2.2 tags, or ID3v2.4 tags which are unsynchronized
+func (m *MP3Parser) Save(w io.Writer) error {
+	version := uint8(4)
+	var frames bytes.Buffer
+	if m.leading {
+		version = m.id3Header.MajorVersion
+		if version < 3 || (version == 4 && m.id3Header.Unsynchronization) {
+			return TagError{
+				Err:     ErrUnsupportedVersion,
+				Format:  m.Format(),
+				Details: fmt.Sprintf("saving ID3v2.%d tag is not supported", version),
+			}
+		}
+
+		// Copy the frames of the original tag, except those which were modified
+		if err := m.copyID3v2Frames(&frames); err != nil {
+			return err
+		}
+	}
+
+	// Add frames for each modified tag, or for all tags when the stream had no ID3v2 tag
+	names := make([]string, 0, len(m.tags))
+	for name := range m.tags {
+		if !m.leading || m.modified[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		frames.Write(mp3ID3v2TagFrame(version, name, m.tags[name]))
+	}
+
+	// Keep the size of the original tag when the frames fit, so that the padding absorbs the change.
+	// Padding is not permitted when a footer is present.
+	footer := m.leading && version == 4 && m.id3Header.Footer
+	size := frames.Len()
+	if !footer {
+		if m.leading && size <= int(m.id3Header.Size) {
+			size = int(m.id3Header.Size)
+		} else {
+			size += mp3SavePadding
+		}
+	}
+
+	if size >= 1<<28 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  m.Format(),
+			Details: "ID3v2 tag too large to save",
+		}
+	}
+
+	// Write the header, frames, padding, and footer
+	header := append([]byte{'I', 'D', '3', version, 0, 0}, mp3Synchsafe(uint32(size))...)
+	if footer {
+		header[5] = 0x10
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(frames.Bytes()); err != nil {
+		return err
+	}
+	if _, err := w.Write(make([]byte, size-frames.Len())); err != nil {
+		return err
+	}
+	if footer {
+		if _, err := w.Write(append(append([]byte{}, mp3FooterMagicNumber...), header[3:]...)); err != nil {
+			return err
+		}
+	}
+
+	// Check for an ID3v1 tag at the end of the stream, which is updated so that removed tags are
+	// not read from it instead
+	var id3v1 []byte
+	if m.endPos-m.audioStart >= id3v1Size {
+		if _, err := m.reader.Seek(m.endPos-id3v1Size, 0); err != nil {
+			return err
+		}
+
+		id3v1 = make([]byte, id3v1Size)
+		if _, err := io.ReadFull(m.reader, id3v1); err != nil {
+			return err
+		}
+
+		if !bytes.Equal(id3v1[:len(id3v1Marker)], id3v1Marker) {
+			id3v1 = nil
+		}
+	}
+
+	// Copy the audio frames which follow the tag
+	if _, err := m.reader.Seek(m.audioStart, 0); err != nil {
+		return err
+	}
+
+	if id3v1 == nil {
+		_, err := io.Copy(w, m.reader)
+		return err
+	}
+
+	if _, err := io.CopyN(w, m.reader, m.endPos-id3v1Size-m.audioStart); err != nil {
+		return err
+	}
+
+	updateID3v1(id3v1, m.tags, m.modified)
+	_, err := w.Write(id3v1)
+	return err
+}
+
+// SetTag sets the value of the named tag in the ID3v2 tag of this stream, replacing any existing value.
+// An empty value removes the tag.  Tags are saved using the frame they are read from, or using a TXXX
+// frame if no frame exists for a tag.  The stream is not modified until Save is called.
+func (m *MP3Parser) SetTag(name string, value string) {
+	setTag(&m.tags, name, value)
+	delete(m.multi, strings.ToUpper(name))
+
+	if m.modified == nil {
+		m.modified = map[string]bool{}
+	}
+	m.modified[strings.ToUpper(name)] = true
+}
+
+// StereoMode returns the channel mode of this stream, which distinguishes joint stereo and dual
+// channel streams from stereo streams
+func (m MP3Parser) StereoMode() StereoMode {
+	return StereoMode(m.mp3Header.ChannelMode)
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (m MP3Parser) String() string {
+	return summarize(&m)
+}
+
 // Tag attempts to return the raw, unprocessed tag with the specified name for this stream
-func (m mp3Parser) Tag(name string) string {
-	return m.tags[name]
+func (m MP3Parser) Tag(name string) string {
+	return m.tags[strings.ToUpper(name)]
+}
+
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream.
+// ID3v2.4 text frames may contain multiple values, which are returned in order.
+func (m MP3Parser) TagMulti(name string) []string {
+	if values, ok := m.multi[strings.ToUpper(name)]; ok {
+		return append([]string(nil), values...)
+	}
+
+	return tagValues(m.tags, name)
 }
 
 // Title returns the Title tag for this stream
-func (m mp3Parser) Title() string {
+func (m MP3Parser) Title() string {
 	return m.tags[tagTitle]
 }
 
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (m MP3Parser) TitleSort() string {
+	return m.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (m MP3Parser) TotalDiscs() int {
+	return parseTotal(m.tags[tagDiscNumber], firstTag(m.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (m MP3Parser) TotalTracks() int {
+	return parseTotal(m.tags[tagTrackNumber], firstTag(m.tags, tagTrackTotal, tagTotalTracks))
+}
+
 // TrackNumber returns the TrackNumber tag for this stream
-func (m mp3Parser) TrackNumber() int {
+func (m MP3Parser) TrackNumber() int {
 	// Check for a /, such as 2/8
 	track, err := strconv.Atoi(strings.Split(m.tags[tagTrackNumber], "/")[0])
 	if err != nil {
 		return 0
 	}
 
-	return track
+	return track
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (m MP3Parser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range m.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (m MP3Parser) Work() string {
+	return m.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (m MP3Parser) Year() int {
+	return parseYear(m.tags[tagDate])
+}
+
+// newMP3Parser creates a parser for MP3 audio streams
+func newMP3Parser(reader io.ReadSeeker, options Options) (*MP3Parser, error) {
+	// Create MP3 parser
+	parser := &MP3Parser{
+		buffer:  make([]byte, 4096),
+		options: options,
+		reader:  reader,
+	}
+
+	// Parse the stream
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a MP3 stream from the reader of this parser
+func (m *MP3Parser) parse() error {
+	// Determine the size of the stream before parsing, unless only a prefix is available
+	if !m.options.streaming {
+		n, err := streamSize(m.reader)
+		if err != nil {
+			return err
+		}
+		m.endPos = n
+	}
+
+	// Check for an ID3v2 tag at the start of the stream
+	start, err := m.reader.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+
+	magicBuf := make([]byte, len(mp3MagicNumber))
+	if _, err := io.ReadFull(m.reader, magicBuf); err != nil {
+		return err
+	}
+
+	m.start = start
+	leading := bytes.Equal(magicBuf, mp3MagicNumber)
+	m.leading = leading
+	if leading {
+		// Return to the start of the ID3v2 tag, and parse it
+		if _, err := m.reader.Seek(start, 0); err != nil {
+			return err
+		}
+
+		tag, err := parseID3v2(m.reader, m.options)
+		if err != nil {
+			return err
+		}
+
+		// Audio frames begin directly after the tag
+		m.setID3v2Tag(tag)
+		m.audioStart = tag.end
+	} else {
+		// Without an ID3v2 tag, the stream must begin with a MP3 frame sync
+		if magicBuf[0] != mp3FrameSync[0] || magicBuf[1]&mp3FrameSyncMask[1] != mp3FrameSync[1] {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  m.Format(),
+				Details: "unrecognized magic number",
+			}
+		}
+
+		// Use an empty ID3v2 header, and return to the frame sync to parse the MP3 header
+		m.id3Header = new(mp3ID3v2Header)
+		m.tags = map[string]string{}
+		m.audioStart = start
+		if _, err := m.reader.Seek(start, 0); err != nil {
+			return err
+		}
+	}
+
+	// Parse MP3 header
+	if err := m.parseMP3Header(); err != nil {
+		return err
+	}
+
+	// Unless only a prefix of the stream is available, merge tags from an APEv2 tag and an
+	// ID3v1 tag at the end of the stream, preferring tags from the ID3v2 tag, and then the
+	// APEv2 tag
+	if !m.options.streaming {
+		// Without a leading ID3v2 tag, use tags from an ID3v2.4 tag appended to the stream
+		if !leading {
+			if err := m.parseAppendedID3v2(); err != nil && !m.options.lenient() {
+				return err
+			}
+		}
+
+		apeTags, err := parseAPEv2(m.reader)
+		if err != nil && !m.options.lenient() {
+			return err
+		}
+
+		id3v1Tags, err := parseID3v1(m.reader)
+		if err != nil {
+			return err
+		}
+
+		for _, tags := range []map[string]string{apeTags, id3v1Tags} {
+			for name, tag := range tags {
+				if _, ok := m.tags[name]; !ok {
+					m.tags[name] = tag
+				}
+			}
+		}
+	}
+
+	// Locate the end of the audio frames, so the duration of constant bitrate streams may be
+	// calculated, unless only a prefix of the stream is available or only tags are requested
+	if !m.options.streaming && !m.options.TagsOnly {
+		if err := m.findAudioEnd(); err != nil && !m.options.lenient() {
+			return err
+		}
+	}
+
+	m.options.normalizeTags(m.tags)
+	for _, values := range m.multi {
+		for i, value := range values {
+			values[i] = m.options.normalizeTag(value)
+		}
+	}
+
+	return nil
+}
+
+// findAudioEnd locates the end of the audio frames of a MP3 stream, which precede any ID3v1, appended
+// ID3v2, and APEv2 tags at the end of the stream
+func (m *MP3Parser) findAudioEnd() error {
+	end := m.endPos
+
+	// An ID3v1 tag is always last
+	if end-m.audioStart >= id3v1Size {
+		if _, err := m.reader.Seek(end-id3v1Size, 0); err != nil {
+			return err
+		}
+
+		if _, err := io.ReadFull(m.reader, m.buffer[:len(id3v1Marker)]); err != nil {
+			return err
+		}
+
+		if bytes.Equal(m.buffer[:len(id3v1Marker)], id3v1Marker) {
+			end -= id3v1Size
+		}
+	}
+
+	// An appended ID3v2 tag ends with a footer, which contains the size of the tag, excluding its
+	// header and footer
+	if end-m.audioStart >= mp3ID3v2FooterSize {
+		if _, err := m.reader.Seek(end-mp3ID3v2FooterSize, 0); err != nil {
+			return err
+		}
+
+		footerBuf := m.buffer[:mp3ID3v2FooterSize]
+		if _, err := io.ReadFull(m.reader, footerBuf); err != nil {
+			return err
+		}
+
+		if bytes.Equal(footerBuf[:len(mp3FooterMagicNumber)], mp3FooterMagicNumber) {
+			size, err := readSynchsafe(bytes.NewReader(footerBuf[6:10]))
+			if err != nil {
+				return err
+			}
+			end -= int64(size) + 2*mp3ID3v2FooterSize
+		}
+	}
+
+	// An APEv2 tag may also precede an ID3v1 tag
+	size, err := apev2TagSize(m.reader, end)
+	if err != nil {
+		return err
+	}
+	end -= size
+
+	// Clamp malformed tag sizes to the start of the audio frames
+	if end < m.audioStart {
+		end = m.audioStart
+	}
+	m.audioEnd = end
+
+	return nil
+}
+
+// parseAppendedID3v2 locates an ID3v2.4 tag appended to the end of a MP3 stream using its footer,
+// which may be followed by an ID3v1 tag, and parses its frames.  If no appended tag is present, the
+// parser is not modified.
+func (m *MP3Parser) parseAppendedID3v2() error {
+	footerBuf := make([]byte, mp3ID3v2FooterSize)
+	for _, offset := range []int64{0, 128} {
+		// Check for an ID3v1 tag before checking for a footer before it
+		if offset > 0 {
+			if _, err := m.reader.Seek(-offset, 2); err != nil {
+				return nil
+			}
+
+			if _, err := io.ReadFull(m.reader, footerBuf[:len(apev2ID3v1Marker)]); err != nil {
+				return err
+			}
+
+			if !bytes.Equal(footerBuf[:len(apev2ID3v1Marker)], apev2ID3v1Marker) {
+				return nil
+			}
+		}
+
+		// Streams too short to contain a footer contain no appended tag
+		end, err := m.reader.Seek(-offset-mp3ID3v2FooterSize, 2)
+		if err != nil {
+			return nil
+		}
+
+		if _, err := io.ReadFull(m.reader, footerBuf); err != nil {
+			return err
+		}
+
+		if !bytes.Equal(footerBuf[:len(mp3FooterMagicNumber)], mp3FooterMagicNumber) {
+			continue
+		}
+
+		// The footer duplicates the ID3v2 header, so the size of the tag locates its header
+		size, err := readSynchsafe(bytes.NewReader(footerBuf[6:10]))
+		if err != nil {
+			return err
+		}
+
+		start := end - int64(size) - 10
+		if start < 0 {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  m.Format(),
+				Details: fmt.Sprintf("invalid appended ID3v2 tag size: %d", size),
+			}
+		}
+
+		if _, err := m.reader.Seek(start, 0); err != nil {
+			return err
+		}
+
+		// Parse the appended tag, preserving the position where audio frames begin
+		tag, err := parseID3v2(m.reader, m.options)
+		if err != nil {
+			return err
+		}
+
+		m.setID3v2Tag(tag)
+		return nil
+	}
+
+	return nil
+}
+
+// copyID3v2Frames copies the frames of the ID3v2 tag at the start of a MP3 stream to w, except for
+// frames which hold tags which were modified using SetTag.  Unsynchronization is reversed, and the
+// extended header is not copied.
+func (m *MP3Parser) copyID3v2Frames(w io.Writer) error {
+	if _, err := m.reader.Seek(m.start+10, 0); err != nil {
+		return err
+	}
+
+	tag := make([]byte, m.id3Header.Size)
+	if _, err := io.ReadFull(m.reader, tag); err != nil {
+		return err
+	}
+
+	if m.id3Header.Unsynchronization {
+		tag = mp3ReverseUnsynchronization(tag)
+	}
+
+	// Skip the extended header, in the same way as when parsing frames
+	if m.id3Header.Extended && len(tag) >= 4 {
+		size := int(binary.BigEndian.Uint32(tag[:4]))
+		if size > len(tag) {
+			size = len(tag)
+		}
+		tag = tag[size:]
+	}
+
+	for len(tag) >= 10 && tag[0] != 0 {
+		id := string(tag[:4])
+		var size uint32
+		if m.id3Header.MajorVersion == 4 {
+			size, _ = readSynchsafe(bytes.NewReader(tag[4:8]))
+		} else {
+			size = binary.BigEndian.Uint32(tag[4:8])
+		}
+
+		// Stop copying frames if this frame extends past the end of the tag
+		if uint64(size) > uint64(len(tag)-10) {
+			break
+		}
+
+		frame := tag[:10+size]
+		tag = tag[10+size:]
+
+		if m.modified[mp3ID3v2FrameTag(id, frame[10:])] {
+			continue
+		}
+
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// id3v2Tag represents the contents of an ID3v2 tag parsed by parseID3v2
+type id3v2Tag struct {
+	header     *mp3ID3v2Header
+	tags       map[string]string
+	multi      map[string][]string
+	pictures   []mp3Picture
+	hasPicture bool
+
+	// end is the offset of the first byte following the tag, including its optional footer
+	end int64
+}
+
+// parseID3v2 parses an ID3v2 tag which begins at the current position of an input stream.  It is used
+// to parse the ID3v2 tags of MP3 streams, and allows ID3v2 tags embedded in other containers, such as
+// the "ID3 " chunk of a WAV or AIFF file, to be parsed using the same code, so errors are reported
+// using the MP3 format.  The position of the input stream is not restored.
+func parseID3v2(reader io.ReadSeeker, options Options) (*id3v2Tag, error) {
+	m := &MP3Parser{
+		buffer:  make([]byte, 4096),
+		options: options,
+		reader:  reader,
+	}
+
+	if err := readMagicNumber(reader, mp3MagicNumber, m.Format()); err != nil {
+		return nil, err
+	}
+
+	if err := m.parseID3v2Header(); err != nil {
+		return nil, err
+	}
+
+	if err := m.parseID3v2Frames(); err != nil {
+		return nil, err
+	}
+
+	return &id3v2Tag{
+		header:     m.id3Header,
+		tags:       m.tags,
+		multi:      m.multi,
+		pictures:   m.pictures,
+		hasPicture: m.hasPicture,
+		end:        m.audioStart,
+	}, nil
+}
+
+// setID3v2Tag stores the header, tags, and pictures of a parsed ID3v2 tag in the parser
+func (m *MP3Parser) setID3v2Tag(tag *id3v2Tag) {
+	m.id3Header = tag.header
+	m.tags = tag.tags
+	m.multi = tag.multi
+	m.pictures = tag.pictures
+	m.hasPicture = tag.hasPicture
+}
+
+// parseID3v2Header parses the ID3v2 header at the start of an MP3 stream
+func (m *MP3Parser) parseID3v2Header() error {
+	// Create and use a bit reader to parse the following fields
+	//   8 - ID3v2 major version
+	//   8 - ID3v2 minor version
+	//   1 - Unsynchronization (boolean) (ID3v2.3+)
+	//   1 - Extended (boolean) (ID3v2.3+)
+	//   1 - Experimental (boolean) (ID3v2.3+)
+	//   1 - Footer (boolean) (ID3v2.4+)
+	//   4 - (empty)
+	fields, err := bit.NewReader(m.reader).ReadFields(8, 8, 1, 1, 1, 1, 4)
+	if err != nil {
+		return err
+	}
+
+	// Read the synch-safe tag size
+	size, err := readSynchsafe(m.reader)
+	if err != nil {
+		return err
+	}
+
+	// Generate ID3v2 header
+	m.id3Header = &mp3ID3v2Header{
+		MajorVersion:      uint8(fields[0]),
+		MinorVersion:      uint8(fields[1]),
+		Unsynchronization: fields[2] == 1,
+		Extended:          fields[3] == 1,
+		Experimental:      fields[4] == 1,
+		Footer:            fields[5] == 1,
+		Size:              size,
+	}
+
+	// Determine the end of the ID3v2 frames and padding.  The size field covers everything
+	// following the ID3v2 header, including the extended header, but not the footer.
+	pos, err := m.reader.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+	m.tagEnd = pos + int64(m.id3Header.Size)
+
+	// Ensure ID3v2 version is supported
+	if m.id3Header.MajorVersion < 2 || m.id3Header.MajorVersion > 4 {
+		return TagError{
+			Err:     ErrUnsupportedVersion,
+			Format:  m.Format(),
+			Details: fmt.Sprintf("unsupported ID3 version: ID3v2.%d.%d", m.id3Header.MajorVersion, m.id3Header.MinorVersion),
+		}
+	}
+
+	// Ensure reserved flag bits are not set, if requested
+	if m.options.checkReservedFlags() && fields[6] != 0 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  m.Format(),
+			Details: "ID3 header reserved flag bits are set",
+		}
+	}
+
+	// Ensure Footer boolean is not defined prior to ID3v2.4, unless lenient options are set
+	if m.id3Header.MajorVersion < 4 && m.id3Header.Footer && !m.options.lenient() {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  m.Format(),
+			Details: "ID3 footer bit set prior to version ID3v2.4",
+		}
+	}
+
+	// Audio frames begin after the ID3v2.4 footer, when one is present
+	m.audioStart = m.tagEnd
+	if m.id3Header.MajorVersion == 4 && m.id3Header.Footer {
+		m.audioStart += mp3ID3v2FooterSize
+	}
+
+	// Check for extended header
+	if m.id3Header.Extended {
+		// Read size of extended header
+		var headerSize uint32
+		if err := binary.Read(m.reader, binary.BigEndian, &headerSize); err != nil {
+			return err
+		}
+
+		// Seek past extended header (minus size of uint32 read), since the information
+		// is irrelevant for tag parsing
+		if _, err := m.reader.Seek(int64(headerSize)-4, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseID3v2Frames parses ID3v2 frames from an MP3 stream
+func (m *MP3Parser) parseID3v2Frames() error {
+	// Store discovered tags in map, as well as ReplayGain tags discovered in RVA2 frames, and all
+	// values of ID3v2.4 text frames which contain multiple values
+	tagMap := map[string]string{}
+	rva2Tags := map[string]string{}
+	multiMap := map[string][]string{}
+
+	// Store ID3v2.2 and ID3v2.3 date and time frames, which are combined with the year when
+	// all frames have been parsed
+	var dateFrame, timeFrame string
+
+	// Note whether the stored comment has a content descriptor, so it may be replaced by a
+	// comment without one
+	var describedComment bool
+
+	// Allocate a buffer to store frame titles, and note the size of frame headers
+	//   - ID3v2.2:  3 byte title, 6 byte header
+	//   - ID3v2.3+: 4 byte title, 10 byte header
+	var frameBuf []byte
+	var frameHeaderSize int64
+	if m.id3Header.MajorVersion == 2 {
+		frameBuf = make([]byte, 3)
+		frameHeaderSize = 6
+	} else {
+		frameBuf = make([]byte, 4)
+		frameHeaderSize = 10
+	}
+
+	// Create buffers for frame information
+	var frameLength uint32
+	var frameFlags uint16
+	tagBuf := make([]byte, 2048)
+	var bufLen = uint32(len(tagBuf))
+
+	// Prior to ID3v2.4, unsynchronization is applied to the entire tag, so when it is used,
+	// read the tag into memory and reverse it before parsing frames
+	reader := m.reader
+	tagEnd := m.tagEnd
+	var unsyncTagOffset, unsyncTagLength int64
+	if m.id3Header.Unsynchronization && m.id3Header.MajorVersion < 4 {
+		pos, err := m.reader.Seek(0, 1)
+		if err != nil {
+			return err
+		}
+		unsyncTagOffset, unsyncTagLength = pos, m.tagEnd-pos
+
+		tag, err := readStreamBytes(m.reader, unsyncTagOffset, unsyncTagLength)
+		if err != nil {
+			return err
+		}
+		tag = mp3ReverseUnsynchronization(tag)
+
+		// Parse frames from the reversed tag, restoring the stream reader when finished
+		m.reader = bytes.NewReader(tag)
+		defer func() {
+			m.reader = reader
+		}()
+		tagEnd = int64(len(tag))
+	}
+
+	// Continuously loop and parse frames
+	for {
+		// Stop if parsing has been canceled
+		if err := m.options.err(); err != nil {
+			return err
+		}
+
+		// Stop parsing frames when no room remains in the tag for another frame header
+		pos, err := m.reader.Seek(0, 1)
+		if err != nil {
+			return err
+		}
+		if pos+frameHeaderSize > tagEnd {
+			break
+		}
+
+		// Parse a frame title
+		if _, err := m.reader.Read(frameBuf); err != nil {
+			return err
+		}
+
+		// Stop parsing frames when frame title is nil, because we have reached padding
+		if frameBuf[0] == byte(0) {
+			break
+		}
+
+		// Parse the length of the frame data
+		//   - ID3v2.2: 24-bit integer, big endian
+		//   - ID3v2.3: 32-bit integer, big endian
+		//   - ID3v2.4: 32-bit synch-safe integer
+		if m.id3Header.MajorVersion == 2 {
+			// Read 3 bytes to parse length
+			if _, err := m.reader.Read(tagBuf[:3]); err != nil {
+				return err
+			}
+
+			// Store frame length
+			// Thanks: https://github.com/ascherkus/go-id3/blob/master/src/id3/id3v22.go#L24
+			frameLength = uint32(tagBuf[0])<<16 | uint32(tagBuf[1])<<8 | uint32(tagBuf[2])
+		} else {
+			// Read 4 bytes to parse length
+			if m.id3Header.MajorVersion == 4 {
+				length, err := readSynchsafe(m.reader)
+				if err != nil {
+					return err
+				}
+				frameLength = length
+			} else {
+				if err := binary.Read(m.reader, binary.BigEndian, &frameLength); err != nil {
+					return err
+				}
+			}
+
+			// ID3v2.3+: Read frame flags
+			if err := binary.Read(m.reader, binary.BigEndian, &frameFlags); err != nil {
+				return err
+			}
+		}
+
+		// Stop parsing frames if this frame extends past the end of the tag
+		pos, err = m.reader.Seek(0, 1)
+		if err != nil {
+			return err
+		}
+		if pos+int64(frameLength) > tagEnd {
+			break
+		}
+
+		// Lyrics frames are commonly too long for the buffer, so allocate space for them
+		// when needed
+		data := tagBuf
+		isLyrics := string(frameBuf) == "ULT" || string(frameBuf) == "USLT"
+		if isLyrics && frameLength > bufLen {
+			data = make([]byte, frameLength)
+		}
+
+		// Note the presence of attached pictures, and parse each picture
+		//   - ID3v2.2:  PIC
+		//   - ID3v2.3+: APIC
+		var isPicture bool
+		if m.id3Header.MajorVersion == 2 {
+			isPicture = bytes.Equal(frameBuf, mp3PICFrame)
+		} else {
+			isPicture = bytes.Equal(frameBuf, mp3APICFrame)
+		}
+
+		if isPicture {
+			m.hasPicture = true
+
+			// Read only enough of the frame to parse the fields which describe the picture, and
+			// note the location of the frame so its image data may be read when requested
+			header := tagBuf[:bufLen]
+			if frameLength < bufLen {
+				header = tagBuf[:frameLength]
+			}
+			if _, err := io.ReadFull(m.reader, header); err != nil {
+				return err
+			}
+
+			unsynchronized := m.id3Header.MajorVersion == 4 && (m.id3Header.Unsynchronization || frameFlags&mp3FrameUnsynchronization != 0)
+			if unsynchronized {
+				header = mp3ReverseUnsynchronization(header)
+			}
+
+			if p, ok := mp3ParseID3v2Picture(string(frameBuf), header); ok {
+				p.Data = nil
+				m.pictures = append(m.pictures, mp3Picture{
+					embeddedPicture: embeddedPicture{
+						Picture: p,
+						offset:  pos,
+						length:  int64(frameLength),
+					},
+					id:              string(frameBuf),
+					unsynchronized:  unsynchronized,
+					unsyncTagOffset: unsyncTagOffset,
+					unsyncTagLength: unsyncTagLength,
+				})
+			}
+
+			// Seek past the remainder of the frame
+			if _, err := m.reader.Seek(pos+int64(frameLength), 0); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// If frame does not contain text OR frame is too long for buffer, seek past it
+		if !mp3IsID3v2TextFrame(string(frameBuf)) || frameLength > uint32(len(data)) {
+			// Seek past frame data and continue loop
+			if _, err := m.reader.Seek(int64(frameLength), 1); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Parse the frame data tag
+		n, err := m.reader.Read(data[:frameLength])
+		if err != nil {
+			return err
+		}
+
+		// ID3v2.4 applies unsynchronization to individual frames, either when specified in the
+		// ID3v2 header, or in the frame's own flags
+		if m.id3Header.MajorVersion == 4 && (m.id3Header.Unsynchronization || frameFlags&mp3FrameUnsynchronization != 0) {
+			n = len(mp3ReverseUnsynchronization(data[:n]))
+		}
+
+		// Skip empty frames, which contain no encoding byte or data
+		if n == 0 {
+			continue
+		}
+
+		// Check for frames which require special handling
+		switch string(frameBuf) {
+		// User-defined text frames contain a description, used as the tag name, followed by the tag data
+		case "TXX", "TXXX":
+			description, value := mp3SplitID3v2Text(data[0], data[1:n])
+			tagMap[strings.ToUpper(mp3DecodeID3v2Text(data[0], description))] = mp3DecodeID3v2Text(data[0], value)
+			continue
+		// Unsynchronized lyrics frames contain a 3 byte language code and a content descriptor, followed
+		// by the lyrics text
+		case "ULT", "USLT":
+			if n < 4 {
+				continue
+			}
+
+			_, text := mp3SplitID3v2Text(data[0], data[4:n])
+			tagMap[tagLyrics] = mp3DecodeID3v2Text(data[0], text)
+			continue
+		// Comment frames contain a 3 byte language code and a content descriptor, followed by the
+		// comment text.  Comments without a descriptor are preferred, because descriptors are used
+		// by some encoders to store other data, such as iTunes' iTunNORM.
+		case "COM", "COMM":
+			if n < 4 {
+				continue
+			}
+
+			description, text := mp3SplitID3v2Text(data[0], data[4:n])
+			if _, ok := tagMap[tagComment]; ok && (len(description) > 0 || !describedComment) {
+				continue
+			}
+
+			tagMap[tagComment] = mp3DecodeID3v2Text(data[0], text)
+			describedComment = len(description) > 0
+			continue
+		// Relative volume adjustment frames may contain ReplayGain information
+		case "RVA2":
+			if gain, peak, album, ok := mp3ParseRVA2(data[:n]); ok {
+				if album {
+					rva2Tags[tagReplayGainAlbumGain] = gain
+					rva2Tags[tagReplayGainAlbumPeak] = peak
+				} else {
+					rva2Tags[tagReplayGainTrackGain] = gain
+					rva2Tags[tagReplayGainTrackPeak] = peak
+				}
+			}
+			continue
+		}
+
+		// Decode text using the encoding stored in the first byte of the frame.  ID3v2.4 text frames
+		// may contain multiple null-separated values, and the first value is used as the tag.
+		tag := mp3DecodeID3v2Text(data[0], data[1:n])
+		var values []string
+		if m.id3Header.MajorVersion == 4 {
+			values = mp3DecodeID3v2TextValues(data[0], data[1:n])
+			if len(values) > 0 {
+				tag = values[0]
+			}
+		}
+
+		// Date (DDMM) and time (HHMM) frames hold the remainder of the year frame's timestamp
+		switch string(frameBuf) {
+		case "TDA", "TDAT":
+			dateFrame = tag
+			continue
+		case "TIM", "TIME":
+			timeFrame = tag
+			continue
+		}
+
+		// Map frame title to tag title, store frame data, skipping frames which have no mapping
+		name, ok := mp3FrameToTag(string(frameBuf))
+		if !ok {
+			continue
+		}
+		tagMap[name] = tag
+
+		if len(values) > 1 {
+			multiMap[name] = values
+		} else {
+			delete(multiMap, name)
+		}
+	}
+
+	// Seek directly to the end of the tag in the stream, skipping any padding and footer,
+	// where audio frames begin
+	if _, err := reader.Seek(m.audioStart, 0); err != nil {
+		return err
+	}
+
+	// Combine year, date, and time frames into a single timestamp, as stored in ID3v2.4 TDRC frames
+	if date, ok := tagMap[tagDate]; ok {
+		tagMap[tagDate] = mp3CombineID3v2Date(date, dateFrame, timeFrame)
+	}
+
+	// Use ReplayGain information from RVA2 frames only when no equivalent TXXX frames were present
+	for name, value := range rva2Tags {
+		if _, ok := tagMap[name]; !ok {
+			tagMap[name] = value
+		}
+	}
+
+	// Store tags in parser
+	m.tags = tagMap
+	m.multi = multiMap
+	return nil
+}
+
+// mp3CombineID3v2Date combines the contents of ID3v2.3 TYER (YYYY), TDAT (DDMM), and TIME (HHMM)
+// frames into an ISO 8601 timestamp, as used by ID3v2.4 TDRC frames.  The year is returned unmodified
+// when it is not a bare year, or when the date is missing or malformed.
+func mp3CombineID3v2Date(year string, date string, clock string) string {
+	if !mp3IsDigits(year, 4) || !mp3IsDigits(date, 4) {
+		return year
+	}
+
+	timestamp := fmt.Sprintf("%s-%s-%s", year, date[2:4], date[0:2])
+	if !mp3IsDigits(clock, 4) {
+		return timestamp
+	}
+
+	return fmt.Sprintf("%sT%s:%s", timestamp, clock[0:2], clock[2:4])
+}
+
+// mp3IsDigits reports whether the input string consists of exactly n ASCII digits
+func mp3IsDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
 }
 
-// newMP3Parser creates a parser for MP3 audio streams
-func newMP3Parser(reader io.ReadSeeker) (*mp3Parser, error) {
-	// Create MP3 parser
-	parser := &mp3Parser{
-		reader: reader,
+// mp3ReverseUnsynchronization reverses the ID3v2 unsynchronization scheme, removing the zero byte
+// which was inserted after every 0xFF byte to prevent false MP3 frame syncs.  The input data is
+// modified in place, and the reversed data is returned.
+func mp3ReverseUnsynchronization(data []byte) []byte {
+	n := 0
+	for i := 0; i < len(data); i++ {
+		data[n] = data[i]
+		n++
+
+		if data[i] == 0xff && i+1 < len(data) && data[i+1] == 0x00 {
+			i++
+		}
 	}
 
-	// Parse ID3v2 header
-	if err := parser.parseID3v2Header(); err != nil {
-		return nil, err
-	}
+	return data[:n]
+}
 
-	// Parse ID3v2 frames
-	if err := parser.parseID3v2Frames(); err != nil {
-		return nil, err
+// mp3SplitID3v2Text splits ID3v2 frame data at its first null terminator, which is two bytes wide
+// for the UTF-16 encodings, returning the data before and after the terminator
+func mp3SplitID3v2Text(encoding byte, data []byte) ([]byte, []byte) {
+	// UTF-16 encodings use a two byte terminator, aligned to a code unit
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+
+		return data, nil
 	}
 
-	// Parse MP3 header
-	if err := parser.parseMP3Header(); err != nil {
-		return nil, err
+	// All other encodings use a single byte terminator
+	index := bytes.IndexByte(data, 0)
+	if index == -1 {
+		return data, nil
 	}
 
-	// Return parser
-	return parser, nil
+	return data[:index], data[index+1:]
 }
 
-// parseID3v2Header parses the ID3v2 header at the start of an MP3 stream
-func (m *mp3Parser) parseID3v2Header() error {
-	// Create and use a bit reader to parse the following fields
-	//   8 - ID3v2 major version
-	//   8 - ID3v2 minor version
-	//   1 - Unsynchronization (boolean) (ID3v2.3+)
-	//   1 - Extended (boolean) (ID3v2.3+)
-	//   1 - Experimental (boolean) (ID3v2.3+)
-	//   1 - Footer (boolean) (ID3v2.4+)
-	//   4 - (empty)
-	//  32 - Size
-	fields, err := bit.NewReader(m.reader).ReadFields(8, 8, 1, 1, 1, 1, 4, 32)
-	if err != nil {
-		return err
-	}
+// mp3PICImageFormats maps the image formats of ID3v2.2 PIC frames to MIME types
+var mp3PICImageFormats = map[string]string{
+	"BMP": "image/bmp",
+	"GIF": "image/gif",
+	"JPG": "image/jpeg",
+	"PNG": "image/png",
+}
 
-	// Generate ID3v2 header
-	m.id3Header = &mp3ID3v2Header{
-		MajorVersion:      uint8(fields[0]),
-		MinorVersion:      uint8(fields[1]),
-		Unsynchronization: fields[2] == 1,
-		Extended:          fields[3] == 1,
-		Experimental:      fields[4] == 1,
-		Footer:            fields[5] == 1,
-		Size:              uint32(fields[7]),
+// mp3ParseID3v2Picture parses the data of an APIC or ID3v2.2 PIC frame into a Picture, returning false
+// if the frame is too short to contain a picture.  The frames contain the following fields:
+//   - 1 byte: text encoding
+//   - APIC: MIME type, null terminated; PIC: 3 byte image format
+//   - 1 byte: picture type
+//   - N bytes: description, null terminated using the text encoding
+//   - N bytes: image data
+func mp3ParseID3v2Picture(id string, data []byte) (Picture, bool) {
+	if len(data) < 1 {
+		return Picture{}, false
 	}
+	encoding := data[0]
+	data = data[1:]
 
-	// Ensure ID3v2 version is supported
-	if m.id3Header.MajorVersion < 2 || m.id3Header.MajorVersion > 4 {
-		return TagError{
-			Err:     errUnsupportedVersion,
-			Format:  m.Format(),
-			Details: fmt.Sprintf("unsupported ID3 version: ID3v2.%d.%d", m.id3Header.MajorVersion, m.id3Header.MinorVersion),
+	var mimeType string
+	if id == string(mp3PICFrame) {
+		if len(data) < 3 {
+			return Picture{}, false
 		}
-	}
 
-	// Ensure Footer boolean is not defined prior to ID3v2.4
-	if m.id3Header.MajorVersion < 4 && m.id3Header.Footer {
-		return TagError{
-			Err:     errInvalidStream,
-			Format:  m.Format(),
-			Details: "ID3 footer bit set prior to version ID3v2.4",
+		format := strings.ToUpper(string(data[:3]))
+		if mimeType = mp3PICImageFormats[format]; mimeType == "" {
+			mimeType = "image/" + strings.ToLower(strings.TrimRight(format, "\x00 "))
 		}
+		data = data[3:]
+	} else {
+		var mime []byte
+		mime, data = mp3SplitID3v2Text(0, data)
+		mimeType = mp3DecodeID3v2Text(0, mime)
 	}
 
-	// Check for extended header
-	if m.id3Header.Extended {
-		// Read size of extended header
-		var headerSize uint32
-		if err := binary.Read(m.reader, binary.BigEndian, &headerSize); err != nil {
-			return err
+	if len(data) < 1 {
+		return Picture{}, false
+	}
+
+	description, image := mp3SplitID3v2Text(encoding, data[1:])
+	return Picture{
+		Type:        PictureType(data[0]),
+		MIMEType:    mimeType,
+		Description: mp3DecodeID3v2Text(encoding, description),
+		Data:        image,
+	}, true
+}
+
+// mp3Picture locates an APIC or ID3v2.2 PIC frame, which is parsed again to retrieve its image data
+// when the picture is requested
+type mp3Picture struct {
+	embeddedPicture
+
+	// id is the frame ID, and unsynchronized indicates that unsynchronization must be reversed
+	// on the frame data
+	id             string
+	unsynchronized bool
+
+	// If unsynchronization was applied to an entire ID3v2.2 or ID3v2.3 tag, unsyncTagOffset and
+	// unsyncTagLength locate the tag, and the frame is located relative to the start of the tag once
+	// unsynchronization is reversed
+	unsyncTagOffset int64
+	unsyncTagLength int64
+}
+
+// readPicture reads and parses the frame containing a picture to retrieve its image data
+func (m MP3Parser) readPicture(p mp3Picture) (Picture, error) {
+	var frame []byte
+	if p.unsyncTagLength > 0 {
+		tag, err := readStreamBytes(m.reader, p.unsyncTagOffset, p.unsyncTagLength)
+		if err != nil {
+			return Picture{}, err
 		}
+		tag = mp3ReverseUnsynchronization(tag)
 
-		// Seek past extended header (minus size of uint32 read), since the information
-		// is irrelevant for tag parsing
-		if _, err := m.reader.Seek(int64(headerSize)-4, 1); err != nil {
-			return err
+		if p.offset > int64(len(tag)) || p.length > int64(len(tag))-p.offset {
+			return Picture{}, io.ErrUnexpectedEOF
 		}
+		frame = tag[p.offset : p.offset+p.length]
+	} else {
+		data, err := readStreamBytes(m.reader, p.offset, p.length)
+		if err != nil {
+			return Picture{}, err
+		}
+		frame = data
 	}
 
-	return nil
+	if p.unsynchronized {
+		frame = mp3ReverseUnsynchronization(frame)
+	}
+
+	picture, ok := mp3ParseID3v2Picture(p.id, frame)
+	if !ok {
+		return Picture{}, TagError{
+			Err:     ErrInvalidStream,
+			Format:  m.Format(),
+			Details: "malformed picture frame",
+		}
+	}
+
+	return picture, nil
 }
 
-// parseID3v2Frames parses ID3v2 frames from an MP3 stream
-func (m *mp3Parser) parseID3v2Frames() error {
-	// Store discovered tags in map
-	tagMap := map[string]string{}
+// mp3DecodeID3v2Text decodes ID3v2 text data using the specified text encoding byte:
+//
+//	0 - ISO-8859-1
+//	1 - UTF-16, with byte order mark
+//	2 - UTF-16, big endian
+//	3 - UTF-8
+func mp3DecodeID3v2Text(encoding byte, data []byte) string {
+	switch encoding {
+	case 0:
+		// Each ISO-8859-1 byte maps directly to a Unicode code point
+		data = bytes.TrimRight(data, "\x00")
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
 
-	// Allocate a buffer to store frame titles
-	//   - ID3v2.2:  3 bytes
-	//   - ID3v2.3+: 4 bytes
-	var frameBuf []byte
-	if m.id3Header.MajorVersion == 2 {
-		frameBuf = make([]byte, 3)
-	} else {
-		frameBuf = make([]byte, 4)
+		return string(runes)
+	case 1:
+		// Check for a byte order mark, assuming little endian if none is present
+		var order binary.ByteOrder = binary.LittleEndian
+		if len(data) >= 2 {
+			if data[0] == 0xfe && data[1] == 0xff {
+				order = binary.BigEndian
+				data = data[2:]
+			} else if data[0] == 0xff && data[1] == 0xfe {
+				data = data[2:]
+			}
+		}
+
+		return mp3DecodeUTF16(order, data)
+	case 2:
+		// ID3v2.4 only: always big endian, with no byte order mark
+		return mp3DecodeUTF16(binary.BigEndian, data)
 	}
 
-	// Create buffers for frame information
-	var frameLength uint32
-	tagBuf := make([]byte, 2048)
-	var bufLen = uint32(len(tagBuf))
+	// UTF-8, trimming trailing nil
+	return string(bytes.TrimRight(data, "\x00"))
+}
 
-	// Byte slices which should be trimmed and discarded from prefix or suffix
-	trimPrefix := []byte{255, 254}
-	trimSuffix := []byte{0}
+// mp3DecodeID3v2TextValues decodes the null-separated values of ID3v2.4 text data using the specified
+// text encoding byte, skipping empty values
+func mp3DecodeID3v2TextValues(encoding byte, data []byte) []string {
+	// UTF-16 values are separated by a two byte null terminator, aligned to a code unit
+	width := 1
+	if encoding == 1 || encoding == 2 {
+		width = 2
+	}
 
-	// Continuously loop and parse frames
-	for {
-		// Parse a frame title
-		if _, err := m.reader.Read(frameBuf); err != nil {
-			return err
+	var values []string
+	for start, i := 0, 0; i <= len(data); i += width {
+		if i+width <= len(data) && !bytes.Equal(data[i:i+width], make([]byte, width)) {
+			continue
 		}
 
-		// Stop parsing frames when frame title is nil, because we have reached padding
-		if frameBuf[0] == byte(0) {
-			break
+		if value := mp3DecodeID3v2Text(encoding, data[start:i]); value != "" {
+			values = append(values, value)
 		}
+		start = i + width
+	}
 
-		// If byte 255 discovered, we have reached the start of the MP3 header
-		if frameBuf[0] == byte(255) {
-			// Read in more bytes to enable fetching the Xing header
-			if _, err := m.reader.Read(tagBuf); err != nil {
-				return err
-			}
+	return values
+}
 
-			// Pre-seed the current data as a bytes reader, to parse MP3 header, while also
-			// appending more bytes to find the Xing header
-			m.reader = bytes.NewReader(append(frameBuf, tagBuf...))
+// mp3DecodeUTF16 decodes UTF-16 text using the specified byte order, stopping at a null terminator
+func mp3DecodeUTF16(order binary.ByteOrder, data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		unit := order.Uint16(data[i:])
+		if unit == 0 {
 			break
 		}
 
-		// Parse the length of the frame data
-		//   - ID3v2.2:  24-bit integer, big endian
-		//   - ID3v2.3+: 32-bit integer, big endian
-		if m.id3Header.MajorVersion == 2 {
-			// Read 3 bytes to parse length
-			if _, err := m.reader.Read(tagBuf[:3]); err != nil {
-				return err
-			}
+		units = append(units, unit)
+	}
 
-			// Store frame length
-			// Thanks: https://github.com/ascherkus/go-id3/blob/master/src/id3/id3v22.go#L24
-			frameLength = uint32(tagBuf[0])<<16 | uint32(tagBuf[1])<<8 | uint32(tagBuf[2])
-		} else {
-			// Read 4 bytes as uint32 to parse length
-			if err := binary.Read(m.reader, binary.BigEndian, &frameLength); err != nil {
-				return err
-			}
+	return string(utf16.Decode(units))
+}
 
-			// ID3v2.4 frame lengths are synch-safe integers unless otherwise specified in ID3v2 header
-			if m.id3Header.MajorVersion == 4 && !m.id3Header.Unsynchronization {
-				b := [4]byte{}
-				binary.BigEndian.PutUint32(b[:], uint32(frameLength))
-				frameLength = uint32(unSynch(b))
-			}
+// mp3ParseRVA2 parses the master volume adjustment and peak from an ID3v2 RVA2 frame, returning
+// them in ReplayGain string form, and whether or not the adjustment applies to an entire album
+func mp3ParseRVA2(data []byte) (string, string, bool, bool) {
+	// Identification string is first, and determines if this is a track or album adjustment
+	identification, data := mp3SplitID3v2Text(0, data)
+	album := strings.EqualFold(string(identification), "album")
+
+	// Iterate channel adjustments, searching for the master volume
+	//   8 - Channel type (1: master volume)
+	//  16 - Volume adjustment, signed, in units of 1/512 dB
+	//   8 - Number of bits representing peak volume
+	//   N - Peak volume
+	for len(data) >= 4 {
+		channel := data[0]
+		adjustment := float64(int16(binary.BigEndian.Uint16(data[1:3]))) / 512
+		peakBits := int(data[3])
+		peakBytes := (peakBits + 7) / 8
+		data = data[4:]
+
+		if len(data) < peakBytes {
+			return "", "", false, false
+		}
 
-			// ID3v2.3+: Skip over frame flags
-			if _, err := m.reader.Seek(2, 1); err != nil {
-				return err
-			}
+		// Skip any channels other than master volume
+		if channel != 1 {
+			data = data[peakBytes:]
+			continue
 		}
 
-		// If frame is attached picture OR frame is too long for buffer, seek past it
-		if bytes.Equal(frameBuf, mp3APICFrame) || frameLength > bufLen {
-			// Seek past picture data and continue loop
-			if _, err := m.reader.Seek(int64(frameLength), 1); err != nil {
-				return err
+		// Calculate peak as a fraction of full scale
+		var peak float64
+		if peakBits > 0 {
+			var raw uint64
+			for _, b := range data[:peakBytes] {
+				raw = raw<<8 | uint64(b)
 			}
 
-			continue
+			peak = float64(raw) / float64(uint64(1)<<uint(peakBits-1))
 		}
 
-		// Parse the frame data tag
-		n, err := m.reader.Read(tagBuf[:frameLength])
-		if err != nil {
-			return err
-		}
+		return fmt.Sprintf("%.2f dB", adjustment), fmt.Sprintf("%.6f", peak), album, true
+	}
+
+	return "", "", false, false
+}
+
+// mp3ID3v2ParsedFrames contains the IDs of frames which are parsed in addition to text information frames,
+// either because they contain text, or because they require special handling
+var mp3ID3v2ParsedFrames = map[string]bool{
+	// ID3v2.2
+	"COM": true,
+	"ULT": true,
 
-		// Trim leading bytes such as UTF-8 BOM, garbage bytes, trim trailing nil
-		// BUG(mdlayher): MP3: handle ID3 tag encodings that aren't UTF-8, stored in tagBuf[0]
-		tag := string(bytes.TrimPrefix(bytes.TrimSuffix(tagBuf[1:n], trimSuffix), trimPrefix))
+	// ID3v2.3+
+	"COMM": true,
+	"GRP1": true,
+	"RVA2": true,
+	"USLT": true,
+}
 
-		// Map frame title to tag title, store frame data
-		tagMap[mp3ID3v2FrameToTag[string(frameBuf)]] = tag
+// mp3IsID3v2TextFrame returns whether or not the ID3v2 frame with the input ID is parsed for its text.
+// Text information frames, whose IDs begin with 'T', are always parsed, but all other frames are parsed
+// only if listed in mp3ID3v2ParsedFrames.  Binary frames, such as MCDI, GEOB, and PRIV, are skipped.
+func mp3IsID3v2TextFrame(id string) bool {
+	if id[0] == 'T' || mp3ID3v2ParsedFrames[id] {
+		return true
 	}
 
-	// Store tags in parser
-	m.tags = tagMap
-	return nil
+	_, ok := mp3FrameToTag(id)
+	return ok
 }
 
 // mp3ID3v2FrameToTag maps a MP3 ID3v2 frame title to its actual tag name
@@ -375,27 +1829,280 @@ var mp3ID3v2FrameToTag = map[string]string{
 	"TRK": tagTrackNumber,
 	"TP1": tagArtist,
 	"TP2": tagAlbumArtist,
+	"TP3": tagConductor,
+	"TP4": tagRemixer,
+	"TT1": tagGrouping,
 	"TT2": tagTitle,
 	"TYE": tagDate,
 	"TPA": tagDiscNumber,
 	"TCO": tagGenre,
+	"TOF": tagOriginalFilename,
+	"TCM": tagComposer,
+	"TCR": tagCopyright,
+	"TEN": tagEncodedBy,
+	"TBP": tagBPM,
+	"TS2": tagAlbumArtistSort,
+	"TSA": tagAlbumSort,
+	"TSP": tagArtistSort,
+	"TST": tagTitleSort,
+	"TLA": tagLanguage,
+	"COM": tagComment,
+	"TKE": tagInitialKey,
 
 	// ID3v2.3+
 	"COMM": tagComment,
+	"GRP1": tagGrouping,
 	"TALB": tagAlbum,
+	"TBPM": tagBPM,
+	"TCOM": tagComposer,
 	"TCON": tagGenre,
+	"TCOP": tagCopyright,
 	"TDRC": tagDate,
+	"TENC": tagEncodedBy,
+	"TIT1": tagGrouping,
 	"TIT2": tagTitle,
+	"TLAN": tagLanguage,
+	"TMOO": tagMood,
+	"TKEY": tagInitialKey,
 	"TLEN": mp3TagLength,
+	"TOFN": tagOriginalFilename,
 	"TPE1": tagArtist,
 	"TPE2": tagAlbumArtist,
+	"TPE3": tagConductor,
+	"TPE4": tagRemixer,
 	"TPOS": tagDiscNumber,
 	"TPUB": tagPublisher,
 	"TRCK": tagTrackNumber,
-	"TSSE": mp3TagEncoder,
+	"TSO2": tagAlbumArtistSort,
+	"TSOA": tagAlbumSort,
+	"TSOP": tagArtistSort,
+	"TSOT": tagTitleSort,
+	"TSSE": tagEncoder,
 	"TYER": tagDate,
 }
 
+var (
+	// mp3RegisteredFrames maps ID3v2 frames registered using RegisterID3Frame to tag names, protected
+	// by mp3RegisteredFramesMu
+	mp3RegisteredFrames   = map[string]string{}
+	mp3RegisteredFramesMu sync.RWMutex
+)
+
+// RegisterID3Frame registers a mapping from an ID3v2 text frame to a tag name, so that the contents of
+// frames which are not mapped by taggolib, such as TOWN or TSRC, are available using the Tag method of
+// MP3 parsers.  The frame must contain an encoding byte followed by text, as in ID3v2 text information
+// frames.  Frames which are already mapped by taggolib cannot be remapped.  RegisterID3Frame is safe
+// for concurrent use, and affects streams parsed after it returns.
+func RegisterID3Frame(frameID string, tagName string) {
+	mp3RegisteredFramesMu.Lock()
+	mp3RegisteredFrames[frameID] = strings.ToUpper(tagName)
+	mp3RegisteredFramesMu.Unlock()
+}
+
+// mp3FrameToTag returns the tag name mapped to an ID3v2 frame, using the mappings in mp3ID3v2FrameToTag,
+// followed by those registered using RegisterID3Frame
+func mp3FrameToTag(id string) (string, bool) {
+	if name, ok := mp3ID3v2FrameToTag[id]; ok {
+		return name, true
+	}
+
+	mp3RegisteredFramesMu.RLock()
+	name, ok := mp3RegisteredFrames[id]
+	mp3RegisteredFramesMu.RUnlock()
+
+	return name, ok
+}
+
+// mp3TagToID3v2Frame maps tags to the ID3v2.3+ frames used to save them, reversing mp3ID3v2FrameToTag.
+// Tags which are read from multiple frames are saved using the ID3v2.4 frame, or the most common frame.
+var mp3TagToID3v2Frame = func() map[string]string {
+	frames := map[string]string{}
+	for frame, tag := range mp3ID3v2FrameToTag {
+		if len(frame) == 4 && tag != mp3TagLength {
+			frames[tag] = frame
+		}
+	}
+
+	frames[tagDate] = "TDRC"
+	frames[tagGrouping] = "TIT1"
+	return frames
+}()
+
+// mp3ID3v2FrameTag returns the name of the tag held by an ID3v2.3+ frame with the input ID and data,
+// or an empty string if the frame holds no tag
+func mp3ID3v2FrameTag(id string, data []byte) string {
+	switch id {
+	case "TXXX":
+		if len(data) == 0 {
+			return ""
+		}
+
+		description, _ := mp3SplitID3v2Text(data[0], data[1:])
+		return strings.ToUpper(mp3DecodeID3v2Text(data[0], description))
+	case "USLT":
+		return tagLyrics
+	case "TDAT", "TIME":
+		return tagDate
+	}
+
+	name, _ := mp3FrameToTag(id)
+	return name
+}
+
+// mp3TagFrame returns the ID3v2.3+ frame used to save a tag, using the mappings in mp3TagToID3v2Frame,
+// followed by those registered using RegisterID3Frame.  If no frame is mapped to the tag, mp3TagFrame
+// returns false.
+func mp3TagFrame(name string) (string, bool) {
+	if id, ok := mp3TagToID3v2Frame[name]; ok {
+		return id, true
+	}
+
+	// Registered frames are sorted so that the same frame is chosen when multiple frames are
+	// registered to a tag, and frames which are mapped by taggolib are skipped
+	mp3RegisteredFramesMu.RLock()
+	defer mp3RegisteredFramesMu.RUnlock()
+
+	var frames []string
+	for id, tag := range mp3RegisteredFrames {
+		if _, ok := mp3ID3v2FrameToTag[id]; !ok && tag == name && len(id) == 4 {
+			frames = append(frames, id)
+		}
+	}
+	if len(frames) == 0 {
+		return "", false
+	}
+
+	sort.Strings(frames)
+	return frames[0], true
+}
+
+// mp3SplitID3v2Date splits an ISO 8601 timestamp, as used by ID3v2.4 TDRC frames, into the contents of
+// ID3v2.3 TYER (YYYY), TDAT (DDMM), and TIME (HHMM) frames, reversing mp3CombineID3v2Date.  The date
+// and time are empty when they are not present in the timestamp, and the timestamp is returned as the
+// year when it does not begin with a year.
+func mp3SplitID3v2Date(timestamp string) (string, string, string) {
+	if len(timestamp) < 4 || !mp3IsDigits(timestamp[0:4], 4) {
+		return timestamp, "", ""
+	}
+	year := timestamp[0:4]
+
+	// YYYY-MM-DD
+	if len(timestamp) < 10 || timestamp[4] != '-' || timestamp[7] != '-' ||
+		!mp3IsDigits(timestamp[5:7], 2) || !mp3IsDigits(timestamp[8:10], 2) {
+		return year, "", ""
+	}
+	date := timestamp[8:10] + timestamp[5:7]
+
+	// YYYY-MM-DDTHH:MM
+	if len(timestamp) < 16 || timestamp[10] != 'T' || timestamp[13] != ':' ||
+		!mp3IsDigits(timestamp[11:13], 2) || !mp3IsDigits(timestamp[14:16], 2) {
+		return year, date, ""
+	}
+
+	return year, date, timestamp[11:13] + timestamp[14:16]
+}
+
+// mp3ID3v2TagFrame generates the ID3v2.3+ frames which hold the input tag, using the frame mapped to
+// the tag, or a TXXX frame when no frame is mapped.  ID3v2.3 dates are split into TYER, TDAT, and
+// TIME frames.
+func mp3ID3v2TagFrame(version uint8, name string, value string) []byte {
+	if version == 3 && name == tagDate {
+		year, date, clock := mp3SplitID3v2Date(value)
+		frames := mp3ID3v2TextFrame(version, "TYER", name, year)
+		if date != "" {
+			frames = append(frames, mp3ID3v2TextFrame(version, "TDAT", name, date)...)
+		}
+		if clock != "" {
+			frames = append(frames, mp3ID3v2TextFrame(version, "TIME", name, clock)...)
+		}
+
+		return frames
+	}
+
+	id, _ := mp3TagFrame(name)
+	return mp3ID3v2TextFrame(version, id, name, value)
+}
+
+// mp3ID3v2TextFrame generates an ID3v2.3+ frame with the input ID which holds the input tag, or a TXXX
+// frame when the ID is empty
+func mp3ID3v2TextFrame(version uint8, id string, name string, value string) []byte {
+	ok := id != ""
+
+	// Text is encoded as UTF-8 in ID3v2.4, and as ISO-8859-1 or UTF-16 in ID3v2.3
+	encoding := byte(3)
+	if version == 3 {
+		encoding = 0
+		for _, r := range name + value {
+			if r > 0xff {
+				encoding = 1
+				break
+			}
+		}
+	}
+
+	data := []byte{encoding}
+	switch {
+	case id == "COMM" || name == tagLyrics:
+		// Comment and lyrics frames contain a language code and an empty content descriptor
+		if !ok {
+			id = "USLT"
+		}
+		data = append(data, "eng"...)
+		data = append(data, mp3EncodeID3v2Text(encoding, "", true)...)
+	case !ok:
+		// User-defined text frames contain a description, which holds the tag name
+		id = "TXXX"
+		data = append(data, mp3EncodeID3v2Text(encoding, name, true)...)
+	}
+	data = append(data, mp3EncodeID3v2Text(encoding, value, false)...)
+
+	// Frame header contains the frame ID, size, and empty flags
+	var size []byte
+	if version == 4 {
+		size = mp3Synchsafe(uint32(len(data)))
+	} else {
+		size = make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(data)))
+	}
+
+	frame := append([]byte(id), size...)
+	frame = append(frame, 0, 0)
+	return append(frame, data...)
+}
+
+// mp3EncodeID3v2Text encodes text using the specified ID3v2 text encoding byte, which must be
+// 0 (ISO-8859-1), 1 (UTF-16, with byte order mark), or 3 (UTF-8), and optionally adds a terminator
+func mp3EncodeID3v2Text(encoding byte, text string, terminate bool) []byte {
+	var data []byte
+	switch encoding {
+	case 0:
+		for _, r := range text {
+			data = append(data, byte(r))
+		}
+	case 1:
+		data = []byte{0xff, 0xfe}
+		for _, u := range utf16.Encode([]rune(text)) {
+			data = append(data, byte(u), byte(u>>8))
+		}
+	default:
+		data = []byte(text)
+	}
+
+	if terminate {
+		data = append(data, 0)
+		if encoding == 1 {
+			data = append(data, 0)
+		}
+	}
+
+	return data
+}
+
+// mp3Synchsafe encodes an integer of up to 28 bits as a 4 byte synch-safe integer
+func mp3Synchsafe(n uint32) []byte {
+	return []byte{byte(n>>21) & 0x7f, byte(n>>14) & 0x7f, byte(n>>7) & 0x7f, byte(n) & 0x7f}
+}
+
 // mp3ID3v2Header represents the MP3 ID3v2 header section
 type mp3ID3v2Header struct {
 	MajorVersion      uint8
@@ -415,34 +2122,51 @@ type mp3ID3v2ExtendedHeader struct {
 }
 
 // parseMP3Header parses the MP3 header after the ID3 headers in a MP3 stream
-func (m *mp3Parser) parseMP3Header() error {
-	// Read buffers continuously until we reach end of padding section, and find the
-	// MP3 header, which starts with byte 255
-	headerBuf := make([]byte, 4096)
-	for {
-		if _, err := m.reader.Read(headerBuf); err != nil {
+func (m *MP3Parser) parseMP3Header() error {
+	// Read into the shared buffer continuously until we reach end of padding section, and
+	// find the MP3 header, which starts with byte 255.  The scan is bounded by the size of
+	// the ID3v2 tag plus a margin, so malformed streams cannot cause an unbounded scan.
+	var headerBuf []byte
+	limit := int64(m.id3Header.Size) + mp3FrameSyncMargin
+	for scanned := int64(0); headerBuf == nil; {
+		// Stop if parsing has been canceled
+		if err := m.options.err(); err != nil {
 			return err
 		}
 
-		// If first byte is 255, value was pre-seeded by tag parser
-		if headerBuf[0] == byte(255) {
-			break
+		n, err := m.reader.Read(m.buffer)
+		if err != nil && err != io.EOF {
+			return err
 		}
 
-		// Search for byte 255
-		index := bytes.Index(headerBuf, []byte{255})
-		if index != -1 {
-			// We have encountered the header, re-slice forward to its index, and read 64 more
-			// bytes to ensure that the Xing header is retrieved
-			tempBuf := make([]byte, 64)
-			if _, err := m.reader.Read(tempBuf); err != nil {
-				return err
+		// Stop if no frame sync is found within the bounds of the scan, or before the
+		// end of the stream
+		if n == 0 || scanned >= limit {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  m.Format(),
+				Details: "could not find MP3 frame sync",
 			}
+		}
+		scanned += int64(n)
 
-			// Append buffers to add Xing header
-			headerBuf = append(headerBuf[index:], tempBuf...)
-			break
+		// Search for byte 255, continuing to read if it is not found
+		index := bytes.IndexByte(m.buffer[:n], 255)
+		if index == -1 {
+			continue
+		}
+
+		// We have encountered the header, move it to the start of the buffer, and read
+		// more bytes if needed to ensure that the Xing header and LAME tag are retrieved
+		n = copy(m.buffer, m.buffer[index:n])
+		if n < mp3FrameReadSize {
+			tn, err := io.ReadFull(m.reader, m.buffer[n:mp3FrameReadSize])
+			if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+				return err
+			}
+			n += tn
 		}
+		headerBuf = m.buffer[:n]
 	}
 
 	// Create and use a bit reader to parse the following fields
@@ -465,7 +2189,7 @@ func (m *mp3Parser) parseMP3Header() error {
 	}
 
 	// Create output MP3 header
-	m.mp3Header = &mp3Header{
+	m.mp3Header = &MP3Header{
 		MPEGVersionID: uint8(fields[1]),
 		MPEGLayerID:   uint8(fields[2]),
 		Protected:     fields[3] == 0,
@@ -486,7 +2210,7 @@ func (m *mp3Parser) parseMP3Header() error {
 	//   - Layer ID 1 -> MPEG Layer 3
 	if m.mp3Header.MPEGVersionID != 3 {
 		return TagError{
-			Err:     errUnsupportedVersion,
+			Err:     ErrUnsupportedVersion,
 			Format:  m.Format(),
 			Details: fmt.Sprintf("unsupported MPEG version ID: %d", m.mp3Header.MPEGVersionID),
 		}
@@ -494,36 +2218,84 @@ func (m *mp3Parser) parseMP3Header() error {
 
 	if m.mp3Header.MPEGLayerID != 1 {
 		return TagError{
-			Err:     errUnsupportedVersion,
+			Err:     ErrUnsupportedVersion,
 			Format:  m.Format(),
 			Details: fmt.Sprintf("unsupported MPEG layer ID: %d", m.mp3Header.MPEGLayerID),
 		}
 	}
 
-	// Search for "Xing" header, to help calculate duration
+	// The Xing header is not needed if only tags are requested
+	if m.options.TagsOnly {
+		return nil
+	}
+
+	// Search for "Xing" header, to help calculate duration.  A Xing header indicates a VBR
+	// stream, while an Info header indicates a CBR stream.
+	vbr := true
 	index := bytes.Index(headerBuf, mp3XingMarker)
 	if index == -1 {
+		vbr = false
+
 		// Search for "Info" header, which may also be present
 		index = bytes.Index(headerBuf, mp3InfoMarker)
 		if index == -1 {
-			// No Xing or Info header, must calculate duration via LENGTH tag
-			// BUG(mdlayher): MP3: Duration of CBR files must be calculated by finding last MP3 frame header
+			// No Xing or Info header, so Duration falls back to the LENGTH tag, and then to a
+			// calculation using the size of the audio frames and the constant bitrate
 			return nil
 		}
 	}
 
-	// Re-slice forward and begin reading data we want from the Xing header, skipping
-	// over the flags to directly read data
-	headerBuf = headerBuf[index+len(mp3XingMarker)+4:]
+	// Re-slice forward and begin reading data we want from the Xing header, starting with
+	// the flags which indicate which fields are present
+	headerBuf = headerBuf[index+len(mp3XingMarker):]
+	if len(headerBuf) < 4 {
+		return nil
+	}
+	flags := binary.BigEndian.Uint32(headerBuf[0:4])
 	m.xingHeader = &mp3XingHeader{
-		FrameCount: binary.BigEndian.Uint32(headerBuf[0:4]),
-		StreamSize: binary.BigEndian.Uint32(headerBuf[4:8]),
+		VBR: vbr,
+	}
+
+	// Read each optional field which is present, according to the flags, and skip past them to the
+	// LAME tag, which directly follows the Xing header
+	offset := 4
+	for _, field := range []struct {
+		flag uint32
+		size int
+	}{
+		{mp3XingFrames, 4},
+		{mp3XingBytes, 4},
+		{mp3XingTOC, 100},
+		{mp3XingQuality, 4},
+	} {
+		if flags&field.flag == 0 {
+			continue
+		}
+
+		// A truncated Xing header cannot be followed by a LAME tag
+		if len(headerBuf) < offset+field.size {
+			offset = len(headerBuf)
+			break
+		}
+
+		switch field.flag {
+		case mp3XingFrames:
+			m.xingHeader.FrameCount = binary.BigEndian.Uint32(headerBuf[offset : offset+field.size])
+		case mp3XingBytes:
+			m.xingHeader.StreamSize = binary.BigEndian.Uint32(headerBuf[offset : offset+field.size])
+		case mp3XingQuality:
+			m.xingHeader.Quality = binary.BigEndian.Uint32(headerBuf[offset : offset+field.size])
+		}
+		offset += field.size
 	}
+	m.parseLAMETag(headerBuf[offset:])
 
 	// Calculate file duration and VBR bitrate using Xing/Info header data
 	// Thanks: https://github.com/taglib/taglib/blob/master/taglib/mpeg/mpegproperties.cpp#L212
-	m.xingHeader.Duration = int((float64(mp3SamplesPerFrame) / float64(m.SampleRate())) * float64(m.xingHeader.FrameCount))
-	m.xingHeader.Bitrate = int(float64(m.xingHeader.StreamSize*8) / float64(m.xingHeader.Duration) / 1000)
+	m.xingHeader.Duration = samplesDuration(uint64(m.xingHeader.FrameCount)*mp3SamplesPerFrame, uint64(m.SampleRate()))
+	if m.xingHeader.Duration > 0 {
+		m.xingHeader.Bitrate = int(float64(m.xingHeader.StreamSize) * 8 / m.xingHeader.Duration.Seconds() / 1000)
+	}
 
 	// If bitrate calculated is above 320, correct it to 320, per specification
 	if m.xingHeader.Bitrate > 320 {
@@ -533,17 +2305,110 @@ func (m *mp3Parser) parseMP3Header() error {
 	return nil
 }
 
+// parseLAMETag parses the encoder version, settings, delay, and padding from a LAME tag at the start
+// of the input buffer, if one is present
+func (m *MP3Parser) parseLAMETag(buf []byte) {
+	// Parse the following fields, skipping fields which are not used:
+	//   - 9 bytes: encoder version string
+	//   - 4 bits: tag revision (unused)
+	//   - 4 bits: VBR method
+	//   - 1 byte: lowpass filter frequency (in hundreds of Hertz)
+	//   - 9 bytes: (unused)
+	//   - 1 byte: ABR target, CBR, or VBR minimum bitrate (in kbps)
+	//   - 12 bits: encoder delay (in samples)
+	//   - 12 bits: encoder padding (in samples)
+	// A LAME tag is not present if the version string is empty.
+	if len(buf) < mp3LAMETagSize || buf[0] == 0 {
+		return
+	}
+
+	m.xingHeader.LAME = mp3LameInfo{
+		Version:        strings.TrimRight(string(buf[0:9]), "\x00 "),
+		VBRMethod:      buf[9] & 0x0f,
+		Lowpass:        buf[10],
+		Bitrate:        buf[20],
+		EncoderDelay:   uint16(buf[21])<<4 | uint16(buf[22])>>4,
+		EncoderPadding: uint16(buf[22]&0x0f)<<8 | uint16(buf[23]),
+	}
+}
+
 // mp3XingHeader represents additional information contained within a Xing header, used to
-// help parse MP3 duration
+// help parse MP3 duration, and information from an optional LAME tag which follows it
 type mp3XingHeader struct {
 	FrameCount uint32
 	StreamSize uint32
-	Duration   int
+	Duration   time.Duration
 	Bitrate    int
+	VBR        bool
+	Quality    uint32
+
+	LAME mp3LameInfo
+}
+
+// mp3LameInfo represents information contained within an optional LAME tag, which describes the
+// settings used to encode a MP3 audio stream
+type mp3LameInfo struct {
+	Version        string
+	VBRMethod      uint8
+	Lowpass        uint8
+	Bitrate        uint8
+	EncoderDelay   uint16
+	EncoderPadding uint16
+}
+
+// StereoMode identifies the channel mode of a MP3 audio stream, which determines how its channels
+// are encoded.  StereoMode values are identical to the channel mode field of a MPEG audio frame header.
+type StereoMode uint8
+
+const (
+	// StereoModeStereo indicates that both channels are encoded independently
+	StereoModeStereo StereoMode = iota
+
+	// StereoModeJointStereo indicates that the channels are encoded together, using mid/side or
+	// intensity stereo
+	StereoModeJointStereo
+
+	// StereoModeDualChannel indicates two independent mono channels, such as two languages
+	StereoModeDualChannel
+
+	// StereoModeMono indicates a single channel
+	StereoModeMono
+)
+
+// stereoModeNames maps each StereoMode to its name
+var stereoModeNames = map[StereoMode]string{
+	StereoModeStereo:      "stereo",
+	StereoModeJointStereo: "joint stereo",
+	StereoModeDualChannel: "dual channel",
+	StereoModeMono:        "mono",
+}
+
+// String returns the name of a StereoMode
+func (m StereoMode) String() string {
+	return stereoModeNames[m]
+}
+
+// MP3EncoderSettings represents the encoder settings stored in the LAME tag of a MP3 audio stream,
+// as returned by EncoderSettings
+type MP3EncoderSettings struct {
+	// Mode is the rate management mode used by the encoder
+	Mode BitrateMode
+
+	// VBRQuality is the LAME VBR quality setting, from 0 (V0) to 9 (V9), or -1 if the stream is not
+	// VBR or its quality is unknown
+	VBRQuality int
+
+	// Bitrate is the target bitrate of an ABR stream, the bitrate of a CBR stream, or the minimum
+	// bitrate of a VBR stream, in kbps.  A value of 255 indicates 255 kbps or higher.
+	Bitrate int
+
+	// Lowpass is the frequency of the lowpass filter applied by the encoder in Hertz, or 0 if unknown
+	Lowpass int
 }
 
-// mp3Header represents a MP3 audio stream header, and contains information about the stream
-type mp3Header struct {
+// MP3Header represents a MP3 audio stream header, and contains information about the stream.
+// A copy of the MP3Header for a MP3 stream is returned by Raw.
+type MP3Header struct {
 	MPEGVersionID uint8
 	MPEGLayerID   uint8
 	Protected     bool
@@ -588,6 +2453,6 @@ var mp3SampleRateMap = map[uint16]int{
 var mp3ChannelModeMap = map[uint8]int{
 	0: 2,
 	1: 2,
-	3: 2,
-	4: 1,
+	2: 2,
+	3: 1,
 }
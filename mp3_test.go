@@ -2,26 +2,56 @@ package taggolib
 
 import (
 	"bytes"
+	"encoding/binary"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
-// TestMP3 verifies that all mp3Parser methods work properly
+// mp3ID3v23Frame generates an ID3v2.3 frame with the specified ID and data
+func mp3ID3v23Frame(id string, data []byte) []byte {
+	frame := make([]byte, 10)
+	copy(frame, id)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(data)))
+
+	return append(frame, data...)
+}
+
+// mp3ID3v23Stream generates a MP3 stream containing an ID3v2.3 tag with the specified frames,
+// followed by the audio frames from a MP3 test file
+func mp3ID3v23Stream(frames ...[]byte) []byte {
+	var tag []byte
+	for _, f := range frames {
+		tag = append(tag, f...)
+	}
+
+	// Generate ID3v2.3 header with synch-safe size
+	size := len(tag)
+	stream := []byte{'I', 'D', '3', 3, 0, 0, byte(size>>21) & 0x7f, byte(size>>14) & 0x7f, byte(size>>7) & 0x7f, byte(size) & 0x7f}
+	stream = append(stream, tag...)
+
+	// Append audio frames from the MP3 test file
+	return append(stream, mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255):]...)
+}
+
+// TestMP3 verifies that all MP3Parser methods work properly
 func TestMP3(t *testing.T) {
 	// Slices of values which differ between MP3 variants
-	bitrates := []int{32, 320, 88}
+	bitrates := []int{32, 320, 87}
 	encoders := []string{"Lavf53.21.1", "MP3FS", "Lavf53.21.1"}
+	pictures := []bool{false, true, false}
 
 	// Check all available variants of MP3
 	for i, mp3File := range [][]byte{mp3ID3v23File, mp3ID3v24File, mp3VBRFile} {
-		// Generate a mp3Parser
+		// Generate a MP3Parser
 		mp3, err := New(bytes.NewReader(mp3File))
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
 		// Verify that we actually got a MP3 mp3
-		if reflect.TypeOf(mp3) != reflect.TypeOf(&mp3Parser{}) {
+		if reflect.TypeOf(mp3) != reflect.TypeOf(&MP3Parser{}) {
 			t.Fatalf("unexpected mp3 type: %v", reflect.TypeOf(mp3))
 		}
 
@@ -52,6 +82,11 @@ func TestMP3(t *testing.T) {
 			t.Fatalf("mismatched property Bitrate: %v", mp3.Bitrate())
 		}
 
+		// BPM
+		if mp3.BPM() != 0 {
+			t.Fatalf("mismatched tag BPM: %v", mp3.BPM())
+		}
+
 		// Channels
 		if mp3.Channels() != 2 {
 			t.Fatalf("mismatched property Channels: %v", mp3.Channels())
@@ -62,6 +97,11 @@ func TestMP3(t *testing.T) {
 			t.Fatalf("mismatched tag Comment: %v", mp3.Comment())
 		}
 
+		// Composer
+		if mp3.Composer() != "" {
+			t.Fatalf("mismatched tag Composer: %v", mp3.Composer())
+		}
+
 		// Date
 		if mp3.Date() != "2014-01-01" {
 			t.Fatalf("mismatched tag Date: %v", mp3.Date())
@@ -77,6 +117,11 @@ func TestMP3(t *testing.T) {
 			t.Fatalf("mismatched property Duration: %v", mp3.Duration().Seconds())
 		}
 
+		// EncodedBy
+		if mp3.EncodedBy() != "" {
+			t.Fatalf("mismatched tag EncodedBy: %v", mp3.EncodedBy())
+		}
+
 		// Encoder
 		if mp3.Encoder() != encoders[i] {
 			t.Fatalf("mismatched property Encoder: %v", mp3.Encoder())
@@ -92,6 +137,16 @@ func TestMP3(t *testing.T) {
 			t.Fatalf("mismatched tag Genre: %v", mp3.Genre())
 		}
 
+		// HasPicture
+		if mp3.HasPicture() != pictures[i] {
+			t.Fatalf("mismatched property HasPicture: %v", mp3.HasPicture())
+		}
+
+		// Lyrics
+		if mp3.Lyrics() != "" {
+			t.Fatalf("mismatched tag Lyrics: %v", mp3.Lyrics())
+		}
+
 		// SampleRate
 		if mp3.SampleRate() != 44100 {
 			t.Fatalf("mismatched property SampleRate: %v", mp3.SampleRate())
@@ -126,9 +181,1235 @@ func TestMP3(t *testing.T) {
 			t.Fatalf("unexpected raw tag TITLE: %v", mp3.Tag("TITLE"))
 		}
 
+		// Tag names are case-insensitive
+		if mp3.Tag("title") != "Title" {
+			t.Fatalf("unexpected raw tag title: %v", mp3.Tag("title"))
+		}
+
 		// Check a non-existant tag
 		if mp3.Tag("NOTEXISTS") != "" {
 			t.Fatalf("unexpected raw tag NOTEXISTS: %v", mp3.Tag("NOTEXISTS"))
 		}
 	}
 }
+
+// TestParseID3v2 verifies that an ID3v2 tag is parsed on its own, as it would be when embedded in
+// another container
+func TestParseID3v2(t *testing.T) {
+	stream := mp3ID3v23Stream(
+		mp3ID3v23Frame("TIT2", []byte("\x00Title")),
+		mp3ID3v23Frame("TPE1", []byte("\x00Artist")),
+		mp3ID3v23Frame("APIC", []byte("\x00image/png\x00\x03\x00\x89PNG")),
+	)
+	tag := stream[:bytes.IndexByte(stream, 255)]
+
+	// Embed the tag in a chunk, similar to the "ID3 " chunk of a WAV file
+	chunk := append([]byte("ID3 \x00\x00\x00\x00"), tag...)
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(tag)))
+
+	reader := bytes.NewReader(chunk)
+	if _, err := reader.Seek(8, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id3, err := parseID3v2(reader, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if id3.header.MajorVersion != 3 || id3.header.Size != uint32(len(tag)-10) {
+		t.Fatalf("mismatched ID3v2 header: %+v", id3.header)
+	}
+
+	if id3.end != int64(len(chunk)) {
+		t.Fatalf("mismatched end of tag: %v != %v", id3.end, len(chunk))
+	}
+
+	want := map[string]string{
+		tagTitle:  "Title",
+		tagArtist: "Artist",
+	}
+	if !reflect.DeepEqual(id3.tags, want) {
+		t.Fatalf("unexpected tags:\n- want: %v\n-  got: %v", want, id3.tags)
+	}
+
+	if !id3.hasPicture || len(id3.pictures) != 1 || id3.pictures[0].MIMEType != "image/png" || id3.pictures[0].Data != nil {
+		t.Fatalf("unexpected pictures: %+v", id3.pictures)
+	}
+
+	// Streams which do not begin with an ID3v2 tag are invalid
+	if _, err := parseID3v2(bytes.NewReader(tag[3:]), Options{}); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestMP3DecodeID3v2Text verifies that ID3v2 text is properly decoded for each text encoding
+func TestMP3DecodeID3v2Text(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		encoding byte
+		data     []byte
+		text     string
+	}{
+		// ISO-8859-1
+		{0, []byte("Caf\xe9\x00"), "Caf\u00e9"},
+		// UTF-16, little endian byte order mark
+		{1, []byte{0xff, 0xfe, 'A', 0, 'b', 0, 0, 0}, "Ab"},
+		// UTF-16, big endian byte order mark
+		{1, []byte{0xfe, 0xff, 0, 'A', 0, 'b'}, "Ab"},
+		// UTF-16, no byte order mark assumes little endian
+		{1, []byte{'A', 0, 'b', 0}, "Ab"},
+		// UTF-16 big endian, no byte order mark
+		{2, []byte{0, 'A', 0, 'b', 0, 0}, "Ab"},
+		// UTF-16 big endian, with a character outside the basic multilingual plane
+		{2, []byte{0xd8, 0x3c, 0xdf, 0xb5}, "\U0001f3b5"},
+		// UTF-8
+		{3, []byte("Caf\xc3\xa9\x00"), "Caf\u00e9"},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		if text := mp3DecodeID3v2Text(test.encoding, test.data); text != test.text {
+			t.Fatalf("mismatched text for encoding %d: %q != %q", test.encoding, text, test.text)
+		}
+	}
+}
+
+// TestMP3ParseRVA2 verifies that ReplayGain information is properly parsed from a RVA2 frame
+func TestMP3ParseRVA2(t *testing.T) {
+	// Track identification, one front right channel which should be skipped, then master volume
+	// with a -3.5 dB adjustment and a 16-bit half scale peak
+	frame := []byte("track\x00")
+	frame = append(frame, 2, 0x00, 0x00, 8, 0xff)
+	frame = append(frame, 1, 0xf9, 0x00, 16, 0x40, 0x00)
+
+	gain, peak, album, ok := mp3ParseRVA2(frame)
+	if !ok {
+		t.Fatalf("failed to parse RVA2 frame")
+	}
+
+	if gain != "-3.50 dB" || peak != "0.500000" || album {
+		t.Fatalf("unexpected RVA2 values: %v, %v, %v", gain, peak, album)
+	}
+
+	// Ensure a truncated frame is rejected
+	if _, _, _, ok := mp3ParseRVA2(frame[:len(frame)-1]); ok {
+		t.Fatalf("truncated RVA2 frame should not parse")
+	}
+}
+
+// TestMP3Lyrics verifies that lyrics are properly parsed from a USLT frame
+func TestMP3Lyrics(t *testing.T) {
+	// Generate a USLT frame long enough to require its own buffer, with encoding, language,
+	// and content descriptor
+	lyrics := string(bytes.Repeat([]byte("la "), 1000))
+	uslt := append([]byte("\x03engverse\x00"), lyrics...)
+
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(
+		mp3ID3v23Frame("TIT2", []byte("\x00Title")),
+		mp3ID3v23Frame("USLT", uslt),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", mp3.Title())
+	}
+
+	if mp3.Lyrics() != lyrics {
+		t.Fatalf("mismatched tag Lyrics: %v", mp3.Lyrics())
+	}
+}
+
+// TestMP3Comment verifies that the language and content descriptor of COMM frames are not included
+// in the Comment tag, and that comments without a descriptor are preferred
+func TestMP3Comment(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		frames  [][]byte
+		comment string
+	}{
+		{[][]byte{mp3ID3v23Frame("COMM", []byte("\x00eng\x00Comment"))}, "Comment"},
+		{[][]byte{mp3ID3v23Frame("COMM", []byte("\x01eng\xff\xfe\x00\x00\xff\xfeC\x00o\x00m\x00"))}, "Com"},
+		{[][]byte{
+			mp3ID3v23Frame("COMM", []byte("\x00engiTunNORM\x00 00000A1B")),
+			mp3ID3v23Frame("COMM", []byte("\x00eng\x00Comment")),
+		}, "Comment"},
+		{[][]byte{
+			mp3ID3v23Frame("COMM", []byte("\x00eng\x00Comment")),
+			mp3ID3v23Frame("COMM", []byte("\x00engiTunNORM\x00 00000A1B")),
+		}, "Comment"},
+		{[][]byte{mp3ID3v23Frame("COMM", []byte("\x00engNote\x00Comment"))}, "Comment"},
+	}
+
+	// Iterate all tests
+	for i, test := range tests {
+		mp3, err := New(bytes.NewReader(mp3ID3v23Stream(test.frames...)))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if mp3.Comment() != test.comment {
+			t.Fatalf("[%02d] mismatched tag Comment: %q != %q", i, mp3.Comment(), test.comment)
+		}
+	}
+}
+
+// TestMP3Pictures verifies that pictures are parsed from APIC frames, and from ID3v2.2 PIC frames,
+// which use an image format in place of a MIME type
+func TestMP3Pictures(t *testing.T) {
+	image := []byte{0xff, 0xd8, 0xff, 0x00, 0x01}
+
+	// Generate an ID3v2.2 tag containing a PIC frame followed by a text frame
+	var tag []byte
+	for _, f := range []struct {
+		id   string
+		data []byte
+	}{
+		{"PIC", append([]byte("\x00JPG\x03Cover\x00"), image...)},
+		{"TT2", []byte("\x00Title")},
+	} {
+		tag = append(tag, f.id...)
+		tag = append(tag, byte(len(f.data)>>16), byte(len(f.data)>>8), byte(len(f.data)))
+		tag = append(tag, f.data...)
+	}
+
+	size := len(tag)
+	id3v22 := []byte{'I', 'D', '3', 2, 0, 0, byte(size>>21) & 0x7f, byte(size>>14) & 0x7f, byte(size>>7) & 0x7f, byte(size) & 0x7f}
+	id3v22 = append(append(id3v22, tag...), mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255):]...)
+
+	// Table of tests
+	var tests = []struct {
+		stream  []byte
+		picture Picture
+	}{
+		{id3v22, Picture{PictureTypeFrontCover, "image/jpeg", "Cover", image}},
+		{mp3ID3v23Stream(
+			mp3ID3v23Frame("APIC", append([]byte("\x00image/png\x00\x04\x00"), image...)),
+			mp3ID3v23Frame("APIC", append([]byte("\x00image/jpeg\x00\x03\x00"), image...)),
+			mp3ID3v23Frame("TIT2", []byte("\x00Title")),
+		), Picture{PictureTypeBackCover, "image/png", "", image}},
+	}
+
+	// Iterate all tests
+	for i, test := range tests {
+		mp3, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if !mp3.HasPicture() {
+			t.Fatalf("[%02d] expected picture", i)
+		}
+
+		picture, ok := mp3.(*MP3Parser).Picture()
+		if !ok {
+			t.Fatalf("[%02d] expected picture", i)
+		}
+
+		if !reflect.DeepEqual(picture, test.picture) {
+			t.Fatalf("[%02d] mismatched Picture: %+v != %+v", i, picture, test.picture)
+		}
+
+		// Frames following the picture are parsed
+		if mp3.Title() != "Title" {
+			t.Fatalf("[%02d] mismatched tag Title: %v", i, mp3.Title())
+		}
+	}
+
+	// Every APIC frame is retained, and may be filtered by picture type
+	mp3, err := New(bytes.NewReader(tests[1].stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parser := mp3.(*MP3Parser)
+
+	if pictures := parser.Pictures(); len(pictures) != 2 {
+		t.Fatalf("mismatched Pictures count: %v", len(pictures))
+	}
+
+	front := parser.Pictures(PictureTypeFrontCover)
+	if len(front) != 1 || front[0].MIMEType != "image/jpeg" {
+		t.Fatalf("mismatched front cover Pictures: %+v", front)
+	}
+}
+
+// TestMP3BinaryFrames verifies that binary frames are skipped, and do not affect the parsing of
+// the text frames which follow them
+func TestMP3BinaryFrames(t *testing.T) {
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(
+		mp3ID3v23Frame("PRIV", []byte("owner\x00\xff\xfb\x90\x00\xff\xfe")),
+		mp3ID3v23Frame("MCDI", bytes.Repeat([]byte{0xff, 0x00}, 2048)),
+		mp3ID3v23Frame("GEOB", []byte("\x00application/octet-stream\x00file\x00object\x00\xff")),
+		mp3ID3v23Frame("TIT2", []byte("\x00Title")),
+		mp3ID3v23Frame("TXXX", []byte("\x00MOOD\x00Calm")),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", mp3.Title())
+	}
+
+	if mood := mp3.Tag("MOOD"); mood != "Calm" {
+		t.Fatalf("mismatched tag MOOD: %v", mood)
+	}
+
+	// Table of tests
+	var tests = []struct {
+		id   string
+		text bool
+	}{
+		{"TIT2", true},
+		{"TT2", true},
+		{"TXXX", true},
+		{"COMM", true},
+		{"USLT", true},
+		{"ULT", true},
+		{"RVA2", true},
+		{"PRIV", false},
+		{"MCDI", false},
+		{"GEOB", false},
+		{"WXXX", false},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		if text := mp3IsID3v2TextFrame(test.id); text != test.text {
+			t.Fatalf("mismatched text frame for %s: %v", test.id, text)
+		}
+	}
+}
+
+// TestMP3RegisterID3Frame verifies that frames registered using RegisterID3Frame are mapped to tags,
+// and that built-in mappings cannot be replaced
+func TestMP3RegisterID3Frame(t *testing.T) {
+	stream := mp3ID3v23Stream(
+		mp3ID3v23Frame("TIT2", []byte("\x00Title")),
+		mp3ID3v23Frame("TOWN", []byte("\x00Owner")),
+		mp3ID3v23Frame("XKEY", []byte("\x00Am")),
+	)
+
+	// Frames are not mapped before registration
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if owner := mp3.Tag("OWNER"); owner != "" {
+		t.Fatalf("unexpected tag OWNER: %v", owner)
+	}
+
+	RegisterID3Frame("TOWN", "owner")
+	RegisterID3Frame("XKEY", "INITIALKEY")
+	RegisterID3Frame("TIT2", "NAME")
+	defer func() {
+		mp3RegisteredFramesMu.Lock()
+		delete(mp3RegisteredFrames, "TOWN")
+		delete(mp3RegisteredFrames, "XKEY")
+		delete(mp3RegisteredFrames, "TIT2")
+		mp3RegisteredFramesMu.Unlock()
+	}()
+
+	mp3, err = New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if owner := mp3.Tag("OWNER"); owner != "Owner" {
+		t.Fatalf("mismatched tag OWNER: %v", owner)
+	}
+
+	if key := mp3.Tag("INITIALKEY"); key != "Am" {
+		t.Fatalf("mismatched tag INITIALKEY: %v", key)
+	}
+
+	if mp3.Title() != "Title" || mp3.Tag("NAME") != "" {
+		t.Fatalf("mismatched tag Title: %v", mp3.Title())
+	}
+
+	// Registered frames are used to save their tags, rather than TXXX frames
+	mp3.(TagWriter).SetTag("OWNER", "New Owner")
+
+	buf := bytes.NewBuffer(nil)
+	if err := mp3.(TagWriter).Save(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Contains(buf.Bytes(), append([]byte("TOWN\x00\x00\x00\x0a\x00\x00\x00"), "New Owner"...)) {
+		t.Fatalf("saved stream does not contain TOWN frame")
+	}
+
+	saved, err := New(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if owner := saved.Tag("OWNER"); owner != "New Owner" {
+		t.Fatalf("mismatched tag OWNER: %v", owner)
+	}
+}
+
+// TestMP3CombineID3v2Date verifies that ID3v2.3 year, date, and time frames are combined into
+// a single timestamp
+func TestMP3CombineID3v2Date(t *testing.T) {
+	var tests = []struct {
+		year   string
+		date   string
+		clock  string
+		result string
+	}{
+		{"2014", "", "", "2014"},
+		{"2014", "0201", "", "2014-01-02"},
+		{"2014", "0201", "1504", "2014-01-02T15:04"},
+		{"2014", "", "1504", "2014"},
+		{"2014", "201", "", "2014"},
+		{"2014-01-02", "0201", "", "2014-01-02"},
+		{"14", "0201", "", "14"},
+	}
+
+	for i, test := range tests {
+		if result := mp3CombineID3v2Date(test.year, test.date, test.clock); result != test.result {
+			t.Fatalf("[%02d] unexpected date: %v != %v", i, result, test.result)
+		}
+	}
+
+	// Verify frames are combined when parsing a stream
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(
+		mp3ID3v23Frame("TYER", []byte("\x002014")),
+		mp3ID3v23Frame("TDAT", []byte("\x000201")),
+		mp3ID3v23Frame("TIME", []byte("\x001504")),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Date() != "2014-01-02T15:04" {
+		t.Fatalf("mismatched tag Date: %v", mp3.Date())
+	}
+}
+
+// TestMP3SplitID3v2Date verifies that timestamps are split into ID3v2.3 year, date, and time frames
+// when saving an ID3v2.3 tag, so that the saved tag contains the same timestamp
+func TestMP3SplitID3v2Date(t *testing.T) {
+	var tests = []struct {
+		timestamp string
+		year      string
+		date      string
+		clock     string
+	}{
+		{"2014", "2014", "", ""},
+		{"2014-01", "2014", "", ""},
+		{"2014-01-02", "2014", "0201", ""},
+		{"2014-01-02T15:04", "2014", "0201", "1504"},
+		{"2014-01-02T15:04:05", "2014", "0201", "1504"},
+		{"2014-01-02 15:04", "2014", "0201", ""},
+		{"14", "14", "", ""},
+	}
+
+	for i, test := range tests {
+		year, date, clock := mp3SplitID3v2Date(test.timestamp)
+		if year != test.year || date != test.date || clock != test.clock {
+			t.Fatalf("[%02d] unexpected frames: %q, %q, %q", i, year, date, clock)
+		}
+	}
+
+	// Verify frames are split when saving a stream
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(mp3ID3v23Frame("TYER", []byte("\x002013")))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mp3.(TagWriter).SetTag("DATE", "2014-01-02T15:04")
+
+	buf := bytes.NewBuffer(nil)
+	if err := mp3.(TagWriter).Save(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, frame := range [][]byte{
+		mp3ID3v23Frame("TYER", []byte("\x002014")),
+		mp3ID3v23Frame("TDAT", []byte("\x000201")),
+		mp3ID3v23Frame("TIME", []byte("\x001504")),
+	} {
+		if !bytes.Contains(buf.Bytes(), frame) {
+			t.Fatalf("saved stream does not contain frame: %q", frame)
+		}
+	}
+
+	saved, err := New(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if saved.Date() != "2014-01-02T15:04" {
+		t.Fatalf("mismatched tag Date: %v", saved.Date())
+	}
+}
+
+// TestMP3FrameMappings verifies that ID3v2 frames are mapped to the appropriate accessors
+func TestMP3FrameMappings(t *testing.T) {
+	var tests = []struct {
+		frame    string
+		accessor func(p Parser) string
+	}{
+		{"TCOP", Parser.Copyright},
+		{"TLAN", Parser.Language},
+		{"TKEY", Parser.InitialKey},
+		{"TMOO", Parser.Mood},
+		{"TPE3", Parser.Conductor},
+		{"TPE4", Parser.Remixer},
+		{"TIT1", Parser.Grouping},
+		{"GRP1", Parser.Grouping},
+		{"TSOP", Parser.ArtistSort},
+		{"TSO2", Parser.AlbumArtistSort},
+		{"TSOA", Parser.AlbumSort},
+		{"TSOT", Parser.TitleSort},
+	}
+
+	for i, test := range tests {
+		mp3, err := New(bytes.NewReader(mp3ID3v23Stream(mp3ID3v23Frame(test.frame, []byte("\x00Value")))))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if tag := test.accessor(mp3); tag != "Value" {
+			t.Fatalf("[%02d] mismatched tag for frame %s: %v", i, test.frame, tag)
+		}
+	}
+
+	// Work is stored in a user-defined text frame
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(mp3ID3v23Frame("TXXX", []byte("\x00WORK\x00Value")))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Work() != "Value" {
+		t.Fatalf("mismatched tag Work: %v", mp3.Work())
+	}
+}
+
+// TestMP3Genre verifies that numeric genre references in TCON frames are translated into genre
+// names, while the raw value is still available using Tag
+func TestMP3Genre(t *testing.T) {
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(mp3ID3v23Frame("TCON", []byte("\x00(17)")))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Genre() != "Rock" {
+		t.Fatalf("mismatched tag Genre: %v", mp3.Genre())
+	}
+	if tag := mp3.Tag("GENRE"); tag != "(17)" {
+		t.Fatalf("mismatched raw tag GENRE: %v", tag)
+	}
+}
+
+// TestMP3MultipleValues verifies that null-separated values in ID3v2.4 text frames are returned by
+// TagMulti, while Tag and the tag accessors return the first value
+func TestMP3MultipleValues(t *testing.T) {
+	// UTF-16 values each begin with a byte order mark
+	genres := []byte{1, 0xff, 0xfe, 'R', 0, 'o', 0, 'c', 0, 'k', 0, 0, 0, 0xfe, 0xff, 0, 'P', 0, 'o', 0, 'p'}
+
+	stream := mp3ID3v23Stream(
+		mp3ID3v23Frame("TPE1", []byte("\x00Artist One\x00Artist Two\x00")),
+		mp3ID3v23Frame("TCON", genres),
+		mp3ID3v23Frame("TIT2", []byte("\x03Title")),
+	)
+	stream[3] = 4
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		name   string
+		tag    string
+		values []string
+	}{
+		{"ARTIST", "Artist One", []string{"Artist One", "Artist Two"}},
+		{"genre", "Rock", []string{"Rock", "Pop"}},
+		{"TITLE", "Title", []string{"Title"}},
+		{"MOOD", "", nil},
+	}
+
+	for i, test := range tests {
+		if tag := mp3.Tag(test.name); tag != test.tag {
+			t.Fatalf("[%02d] mismatched Tag: %v != %v", i, tag, test.tag)
+		}
+		if values := mp3.TagMulti(test.name); !reflect.DeepEqual(values, test.values) {
+			t.Fatalf("[%02d] mismatched TagMulti: %v != %v", i, values, test.values)
+		}
+	}
+
+	if mp3.Artist() != "Artist One" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+
+	// Setting a tag replaces all of its values
+	mp3.(*MP3Parser).SetTag("artist", "Artist")
+	if values := mp3.TagMulti("ARTIST"); !reflect.DeepEqual(values, []string{"Artist"}) {
+		t.Fatalf("mismatched TagMulti after SetTag: %v", values)
+	}
+}
+
+// TestMP3Totals verifies that track and disc totals are parsed from TRCK and TPOS frames
+func TestMP3Totals(t *testing.T) {
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(
+		mp3ID3v23Frame("TRCK", []byte("\x002/8")),
+		mp3ID3v23Frame("TPOS", []byte("\x001/2")),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.TrackNumber() != 2 {
+		t.Fatalf("mismatched tag TrackNumber: %v", mp3.TrackNumber())
+	}
+
+	if mp3.TotalTracks() != 8 {
+		t.Fatalf("mismatched tag TotalTracks: %v", mp3.TotalTracks())
+	}
+
+	if mp3.DiscNumber() != 1 {
+		t.Fatalf("mismatched tag DiscNumber: %v", mp3.DiscNumber())
+	}
+
+	if mp3.TotalDiscs() != 2 {
+		t.Fatalf("mismatched tag TotalDiscs: %v", mp3.TotalDiscs())
+	}
+}
+
+// TestMP3LAMETag verifies that encoder delay and padding are parsed from a LAME tag following
+// a Xing header
+func TestMP3LAMETag(t *testing.T) {
+	// Copy the test file, and insert a LAME tag after the Xing header, which contains only
+	// the frame count field
+	stream := make([]byte, len(mp3ID3v23File))
+	copy(stream, mp3ID3v23File)
+
+	index := bytes.Index(stream, mp3XingMarker) + len(mp3XingMarker) + 8
+	lame := append([]byte("LAME3.99r"), make([]byte, 12)...)
+	copy(stream[index:], append(lame, 0x24, 0x04, 0x80))
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parser := mp3.(*MP3Parser)
+
+	if parser.xingHeader.LAME.Version != "LAME3.99r" {
+		t.Fatalf("mismatched LAME encoder version: %v", parser.xingHeader.LAME.Version)
+	}
+
+	if parser.EncoderDelay() != 576 {
+		t.Fatalf("mismatched property EncoderDelay: %v", parser.EncoderDelay())
+	}
+
+	if parser.EncoderPadding() != 1152 {
+		t.Fatalf("mismatched property EncoderPadding: %v", parser.EncoderPadding())
+	}
+
+	// The unmodified test file contains no LAME tag
+	mp3, err = New(bytes.NewReader(mp3ID3v23File))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if delay := mp3.(*MP3Parser).EncoderDelay(); delay != 0 {
+		t.Fatalf("mismatched property EncoderDelay: %v", delay)
+	}
+}
+
+// TestMP3XingFlags verifies that only the Xing header fields indicated by its flags are parsed
+func TestMP3XingFlags(t *testing.T) {
+	// Copy the test file, whose Xing header contains only the frame count field, and flag that
+	// field as the stream size instead
+	stream := make([]byte, len(mp3ID3v23File))
+	copy(stream, mp3ID3v23File)
+
+	index := bytes.Index(stream, mp3XingMarker) + len(mp3XingMarker)
+	frames := binary.BigEndian.Uint32(stream[index+4 : index+8])
+	binary.BigEndian.PutUint32(stream[index:index+4], mp3XingBytes)
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	xing := mp3.(*MP3Parser).xingHeader
+	if xing.FrameCount != 0 || xing.StreamSize != frames {
+		t.Fatalf("mismatched Xing header fields: %+v", xing)
+	}
+}
+
+// TestMP3ShortVBR verifies that the duration and bitrate of a VBR stream shorter than one second
+// are calculated from its Xing header
+func TestMP3ShortVBR(t *testing.T) {
+	// Copy the test file, and replace the frame count and stream size in its Xing header
+	stream := make([]byte, len(mp3VBRFile))
+	copy(stream, mp3VBRFile)
+
+	index := bytes.Index(stream, mp3XingMarker) + len(mp3XingMarker)
+	binary.BigEndian.PutUint32(stream[index+4:index+8], 20)
+	binary.BigEndian.PutUint32(stream[index+8:index+12], 10000)
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	duration := samplesDuration(20*mp3SamplesPerFrame, uint64(mp3.SampleRate()))
+	if d := mp3.Duration(); d != duration || d >= time.Second {
+		t.Fatalf("mismatched Duration: %v != %v", d, duration)
+	}
+
+	if b := int(10000 * 8 / duration.Seconds() / 1000); mp3.Bitrate() != b {
+		t.Fatalf("mismatched Bitrate: %v != %v", mp3.Bitrate(), b)
+	}
+}
+
+// TestMP3EncoderSettings verifies that encoder settings are parsed from a LAME tag and the quality
+// indicator of a Xing header
+func TestMP3EncoderSettings(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		method   byte
+		quality  byte
+		bitrate  byte
+		lowpass  byte
+		settings MP3EncoderSettings
+	}{
+		// LAME V0, VBR method 4 (mtrh)
+		{0x04, 97, 32, 195, MP3EncoderSettings{BitrateModeVBR, 0, 32, 19500}},
+		// LAME V2, VBR method 3 (old)
+		{0x13, 78, 32, 185, MP3EncoderSettings{BitrateModeVBR, 2, 32, 18500}},
+		// CBR 320
+		{0x01, 0, 255, 200, MP3EncoderSettings{BitrateModeCBR, -1, 255, 20000}},
+		// ABR 192, 2-pass
+		{0x09, 57, 192, 190, MP3EncoderSettings{BitrateModeABR, -1, 192, 19000}},
+		// Unknown method
+		{0x00, 0, 0, 0, MP3EncoderSettings{BitrateModeUnknown, -1, 0, 0}},
+	}
+
+	// Iterate all tests
+	for i, test := range tests {
+		// Copy the test file, and add a quality indicator to the Xing header, which contains only
+		// the frame count field, followed by a LAME tag
+		stream := make([]byte, len(mp3ID3v23File))
+		copy(stream, mp3ID3v23File)
+
+		index := bytes.Index(stream, mp3XingMarker) + len(mp3XingMarker)
+		stream[index+3] |= mp3XingQuality
+		index += 8
+
+		lame := make([]byte, mp3LAMETagSize)
+		copy(lame, "LAME3.99r")
+		lame[9] = test.method
+		lame[10] = test.lowpass
+		lame[20] = test.bitrate
+		copy(stream[index:], append([]byte{0, 0, 0, test.quality}, lame...))
+
+		mp3, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		settings, ok := mp3.(*MP3Parser).EncoderSettings()
+		if !ok {
+			t.Fatalf("[%02d] expected LAME tag", i)
+		}
+
+		if settings != test.settings {
+			t.Fatalf("[%02d] mismatched EncoderSettings: %+v != %+v", i, settings, test.settings)
+		}
+	}
+
+	// The unmodified test file contains no LAME tag
+	mp3, err := New(bytes.NewReader(mp3ID3v23File))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := mp3.(*MP3Parser).EncoderSettings(); ok {
+		t.Fatalf("unexpected LAME tag")
+	}
+}
+
+// TestMP3StereoMode verifies that each MP3 channel mode is reported by StereoMode, ChannelLayout,
+// and Channels
+func TestMP3StereoMode(t *testing.T) {
+	var tests = []struct {
+		mode     StereoMode
+		name     string
+		channels int
+	}{
+		{StereoModeStereo, "stereo", 2},
+		{StereoModeJointStereo, "joint stereo", 2},
+		{StereoModeDualChannel, "dual channel", 2},
+		{StereoModeMono, "mono", 1},
+	}
+
+	for i, test := range tests {
+		// Build a stream, and set the channel mode in the header of the first audio frame
+		stream := mp3ID3v23Stream(mp3ID3v23Frame("TIT2", []byte("\x00Title")))
+		header := bytes.IndexByte(stream[10:], 255) + 10
+		stream[header+3] = stream[header+3]&0x3f | byte(test.mode)<<6
+
+		mp3, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if mode := mp3.(*MP3Parser).StereoMode(); mode != test.mode {
+			t.Fatalf("[%02d] mismatched StereoMode: %v != %v", i, mode, test.mode)
+		}
+		if layout := mp3.ChannelLayout(); layout != test.name {
+			t.Fatalf("[%02d] mismatched ChannelLayout: %v != %v", i, layout, test.name)
+		}
+		if channels := mp3.Channels(); channels != test.channels {
+			t.Fatalf("[%02d] mismatched Channels: %v != %v", i, channels, test.channels)
+		}
+	}
+}
+
+// TestMP3IsVBR verifies that VBR streams are detected by the presence of a Xing header
+func TestMP3IsVBR(t *testing.T) {
+	// Copy the test file, and replace its Xing header with an Info header, or remove it entirely
+	info := make([]byte, len(mp3VBRFile))
+	copy(info, mp3VBRFile)
+	copy(info[bytes.Index(info, mp3XingMarker):], mp3InfoMarker)
+
+	cbr := make([]byte, len(mp3VBRFile))
+	copy(cbr, mp3VBRFile)
+	copy(cbr[bytes.Index(cbr, mp3XingMarker):], "xxxx")
+
+	var tests = []struct {
+		stream []byte
+		vbr    bool
+	}{
+		{mp3VBRFile, true},
+		{info, false},
+		{cbr, false},
+	}
+
+	for i, test := range tests {
+		mp3, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if vbr := mp3.(*MP3Parser).IsVBR(); vbr != test.vbr {
+			t.Fatalf("[%02d] mismatched property IsVBR: %v != %v", i, vbr, test.vbr)
+		}
+	}
+}
+
+// TestMP3HeaderFlags verifies that ID3v2 header flags are validated according to strictness
+func TestMP3HeaderFlags(t *testing.T) {
+	var tests = []struct {
+		flag    byte
+		options Options
+		invalid bool
+	}{
+		// Footer flag set prior to ID3v2.4
+		{0x10, Options{}, true},
+		{0x10, Options{Strictness: Lenient}, false},
+		// Reserved flag bits set
+		{0x01, Options{}, false},
+		{0x01, Options{Strictness: Strict}, true},
+		{0x01, Options{CheckReservedFlags: true}, true},
+	}
+
+	for i, test := range tests {
+		// Build an ID3v2.3 stream, and set the flag in the ID3v2 header
+		stream := mp3ID3v23Stream(mp3ID3v23Frame("TIT2", []byte("\x00Title")))
+		stream[5] |= test.flag
+
+		_, err := NewWithOptions(bytes.NewReader(stream), test.options)
+		if test.invalid && !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+		if !test.invalid && err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+	}
+}
+
+// TestMP3MissingFrameSync verifies that a MP3 stream with no frame sync after its ID3v2 tag
+// is reported as invalid, rather than scanned indefinitely
+func TestMP3MissingFrameSync(t *testing.T) {
+	var tests = [][]byte{
+		// Truncated after a small amount of padding
+		make([]byte, 16),
+		// Followed by non-audio data, larger than the scan margin
+		make([]byte, mp3FrameSyncMargin*2),
+	}
+
+	for i, test := range tests {
+		stream := mp3ID3v23Stream(mp3ID3v23Frame("TIT2", []byte("\x00Title")))
+		stream = append(stream[:bytes.IndexByte(stream, 255)], test...)
+
+		if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+	}
+}
+
+// TestMP3TagSize verifies that frame parsing stops at the end of the ID3v2 tag, even when
+// tag data contains bytes which resemble a frame sync
+func TestMP3TagSize(t *testing.T) {
+	mp3, err := New(bytes.NewReader(mp3ID3v23Stream(
+		mp3ID3v23Frame("TIT2", []byte("\x00\xff\xfbTitle")),
+		mp3ID3v23Frame("TPE1", []byte("\x00Artist")),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+
+	if mp3.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", mp3.SampleRate())
+	}
+}
+
+// TestMP3Footer verifies that audio frames are located after an ID3v2.4 footer
+func TestMP3Footer(t *testing.T) {
+	frame := []byte{'T', 'I', 'T', '2', 0, 0, 0, 6, 0, 0, 0, 'T', 'i', 't', 'l', 'e'}
+	size := byte(len(frame))
+
+	stream := append([]byte{'I', 'D', '3', 4, 0, 0x10, 0, 0, 0, size}, frame...)
+	stream = append(stream, '3', 'D', 'I', 4, 0, 0x10, 0, 0, 0, size)
+	stream = append(stream, mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255):]...)
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", mp3.Title())
+	}
+
+	if start := mp3.(*MP3Parser).audioStart; start != int64(10+len(frame)+10) {
+		t.Fatalf("unexpected audio start offset: %v", start)
+	}
+}
+
+// TestMP3AppendedTag verifies that tags are parsed from an ID3v2.4 tag appended to the end of a MP3
+// stream, which may be followed by an ID3v1 tag
+func TestMP3AppendedTag(t *testing.T) {
+	frame := []byte{'T', 'I', 'T', '2', 0, 0, 0, 6, 0, 0, 0, 'T', 'i', 't', 'l', 'e'}
+	size := byte(len(frame))
+
+	audio := mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255):]
+	tag := append([]byte{'I', 'D', '3', 4, 0, 0x10, 0, 0, 0, size}, frame...)
+	tag = append(tag, '3', 'D', 'I', 4, 0, 0x10, 0, 0, 0, size)
+
+	id3v1 := make([]byte, 128)
+	copy(id3v1, "TAG")
+
+	var tests = [][]byte{
+		append(append([]byte{}, audio...), tag...),
+		append(append(append([]byte{}, audio...), tag...), id3v1...),
+	}
+
+	for i, stream := range tests {
+		mp3, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if mp3.Title() != "Title" {
+			t.Fatalf("[%02d] mismatched tag Title: %v", i, mp3.Title())
+		}
+
+		if start := mp3.(*MP3Parser).audioStart; start != 0 {
+			t.Fatalf("[%02d] unexpected audio start offset: %v", i, start)
+		}
+	}
+}
+
+// TestMP3ReadSynchsafe verifies that readSynchsafe properly decodes synch-safe integers
+func TestMP3ReadSynchsafe(t *testing.T) {
+	var tests = []struct {
+		data  []byte
+		value uint32
+	}{
+		{[]byte{0, 0, 0, 0}, 0},
+		{[]byte{0, 0, 0, 0x7f}, 127},
+		{[]byte{0, 0, 1, 0}, 128},
+		{[]byte{0, 0, 0x0d, 0x67}, 1767},
+		{[]byte{0, 2, 0x40, 0}, 40960},
+		{[]byte{0x7f, 0x7f, 0x7f, 0x7f}, 1<<28 - 1},
+	}
+
+	for i, test := range tests {
+		value, err := readSynchsafe(bytes.NewReader(test.data))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if value != test.value {
+			t.Fatalf("[%02d] mismatched synch-safe integer: %v != %v", i, value, test.value)
+		}
+	}
+}
+
+// TestMP3ReverseUnsynchronization verifies that mp3ReverseUnsynchronization properly removes
+// zero bytes inserted by the unsynchronization scheme
+func TestMP3ReverseUnsynchronization(t *testing.T) {
+	var tests = []struct {
+		data     []byte
+		reversed []byte
+	}{
+		{[]byte{}, []byte{}},
+		{[]byte{'a', 'b'}, []byte{'a', 'b'}},
+		{[]byte{0xff, 0x00, 0xfb}, []byte{0xff, 0xfb}},
+		{[]byte{0xff, 0x00, 0x00}, []byte{0xff, 0x00}},
+		{[]byte{0xff, 0x00, 0xff, 0x00}, []byte{0xff, 0xff}},
+		{[]byte{'a', 0xff}, []byte{'a', 0xff}},
+	}
+
+	for i, test := range tests {
+		if reversed := mp3ReverseUnsynchronization(test.data); !bytes.Equal(reversed, test.reversed) {
+			t.Fatalf("[%02d] mismatched data: %v != %v", i, reversed, test.reversed)
+		}
+	}
+}
+
+// TestMP3Unsynchronization verifies that tags are parsed properly from an ID3v2.3 tag which
+// uses the unsynchronization scheme
+func TestMP3Unsynchronization(t *testing.T) {
+	// Generate a picture larger than the buffer used to parse frames, containing 0xFF bytes
+	image := bytes.Repeat([]byte{0xff, 0xe0, 0x01}, 1024)
+
+	// Apply unsynchronization to frames containing 0xFF bytes
+	var frames []byte
+	for _, b := range bytes.Join([][]byte{
+		mp3ID3v23Frame("TIT2", []byte("\x00Title\xff")),
+		mp3ID3v23Frame("APIC", append([]byte("\x00image/jpeg\x00\x03\x00"), image...)),
+		mp3ID3v23Frame("TPE1", []byte("\x00Artist")),
+	}, nil) {
+		frames = append(frames, b)
+		if b == 0xff {
+			frames = append(frames, 0x00)
+		}
+	}
+
+	// Set the unsynchronization flag in the ID3v2 header
+	stream := mp3ID3v23Stream(frames)
+	stream[5] |= 0x80
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Title() != "Titleÿ" {
+		t.Fatalf("mismatched tag Title: %v", mp3.Title())
+	}
+
+	if mp3.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+
+	// The picture is read from the tag when requested, once unsynchronization is reversed
+	picture, ok := mp3.(*MP3Parser).Picture()
+	if !ok || picture.MIMEType != "image/jpeg" || !bytes.Equal(picture.Data, image) {
+		t.Fatalf("mismatched Picture: %v, %v, %d bytes", ok, picture.MIMEType, len(picture.Data))
+	}
+}
+
+// TestMP3APEv2 verifies that tags from an APEv2 tag appended to a MP3 stream are merged with
+// the tags from its ID3v2 tag
+func TestMP3APEv2(t *testing.T) {
+	stream := append(mp3ID3v23Stream(mp3ID3v23Frame("TIT2", []byte("\x00Title"))), apev2Tag(
+		apev2Item(0, "Title", "APE Title"),
+		apev2Item(0, "Artist", "APE Artist"),
+	)...)
+
+	mp3, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", mp3.Title())
+	}
+
+	if mp3.Artist() != "APE Artist" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+}
+
+// TestMP3CBRDuration verifies that the duration of a constant bitrate stream without a Xing header
+// or length tag is calculated from the size of its audio frames, excluding tags at the end of the stream
+func TestMP3CBRDuration(t *testing.T) {
+	// Use the audio frames of the ID3v2.4 test file, which has no Xing header, without the ID3v1
+	// tag which follows them
+	parser, err := New(bytes.NewReader(mp3ID3v24File))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	audio := mp3ID3v24File[parser.(*MP3Parser).audioStart : len(mp3ID3v24File)-id3v1Size]
+
+	var tests = []struct {
+		description string
+		trailer     []byte
+	}{
+		{"no trailing tags", nil},
+		{"ID3v1 tag", id3v1Tag("Title", "Artist", "Album", "2014", "Comment", 1, 17)},
+		{"APEv2 tag", apev2Tag(apev2Item(0, "Title", "APE Title"))},
+		{"APEv2 and ID3v1 tags", append(apev2Tag(apev2Item(0, "Title", "APE Title")), id3v1Tag("Title", "Artist", "Album", "2014", "Comment", 1, 17)...)},
+	}
+
+	// Replace the audio frames following a generated ID3v2.3 tag
+	tag := mp3ID3v23Stream(mp3ID3v23Frame("TIT2", []byte("\x00Title")))
+	tag = tag[:len(tag)-len(mp3ID3v23File)+bytes.IndexByte(mp3ID3v23File, 255)]
+
+	var duration time.Duration
+	for _, test := range tests {
+		stream := append(append(append([]byte{}, tag...), audio...), test.trailer...)
+
+		mp3, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.description, err)
+		}
+
+		// The test file's audio frames hold 5 seconds of audio, and a partial frame, and tags
+		// at the end of the stream must not affect the duration
+		d := mp3.Duration()
+		if d < 5*time.Second || d > 5*time.Second+100*time.Millisecond {
+			t.Fatalf("%s: mismatched Duration: %v", test.description, d)
+		}
+
+		if duration == 0 {
+			duration = d
+		}
+		if d != duration {
+			t.Fatalf("%s: mismatched Duration: %v != %v", test.description, d, duration)
+		}
+	}
+}
+
+// TestMP3WithoutID3v2 verifies that MP3 streams which begin with a frame sync are detected, and
+// that tags are parsed from an ID3v1 tag when present
+func TestMP3WithoutID3v2(t *testing.T) {
+	// Strip the ID3v2 tag and the ID3v1 tag from the test file
+	audio := mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255) : len(mp3ID3v23File)-id3v1Size]
+
+	mp3, err := New(bytes.NewReader(audio))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", mp3.SampleRate())
+	}
+
+	if mp3.Artist() != "" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+
+	// Append an ID3v1 tag, which is used as a fallback
+	stream := append(append([]byte{}, audio...), id3v1Tag("Title", "Artist", "Album", "2014", "", 3, 17)...)
+	mp3, err = New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+
+	if mp3.TrackNumber() != 3 {
+		t.Fatalf("mismatched tag TrackNumber: %v", mp3.TrackNumber())
+	}
+
+	// ID3v2 tags are preferred over ID3v1 tags
+	stream = append(mp3ID3v23Stream(mp3ID3v23Frame("TPE1", []byte("\x00ID3v2 Artist"))), id3v1Tag("", "Artist", "Album", "", "", 0, 0)...)
+	mp3, err = New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mp3.Artist() != "ID3v2 Artist" {
+		t.Fatalf("mismatched tag Artist: %v", mp3.Artist())
+	}
+
+	if mp3.Album() != "Album" {
+		t.Fatalf("mismatched tag Album: %v", mp3.Album())
+	}
+}
+
+// TestMP3Save verifies that tags set on a MP3 parser are saved to a copy of the stream, while other
+// ID3v2 frames and the audio frames are preserved
+func TestMP3Save(t *testing.T) {
+	var tests = []struct {
+		stream  []byte
+		comment string
+	}{
+		// Modified frames fit in the original tag
+		{mp3ID3v23File, "Comment"},
+		{mp3ID3v24File, "Comment"},
+		// Modified frames require a larger tag, with non-ASCII text
+		{mp3ID3v23File, strings.Repeat("\u00e9\u4e16", 4096)},
+		{mp3ID3v24File, strings.Repeat("\u00e9\u4e16", 4096)},
+		// Stream without an ID3v2 tag
+		{mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255):], "Comment"},
+	}
+
+	for i, test := range tests {
+		mp3, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		writer := mp3.(TagWriter)
+		writer.SetTag("Title", "New Title")
+		writer.SetTag("ALBUM", "")
+		writer.SetTag("Mood", "Calm")
+		writer.SetTag("COMMENT", test.comment)
+
+		buf := bytes.NewBuffer(nil)
+		if err := writer.Save(buf); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		saved, err := New(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if saved.Title() != "New Title" {
+			t.Fatalf("[%02d] mismatched tag Title: %v", i, saved.Title())
+		}
+		if saved.Album() != "" {
+			t.Fatalf("[%02d] unexpected tag Album: %v", i, saved.Album())
+		}
+		if saved.Tag("MOOD") != "Calm" {
+			t.Fatalf("[%02d] mismatched tag MOOD: %v", i, saved.Tag("MOOD"))
+		}
+		if saved.Artist() != mp3.Artist() {
+			t.Fatalf("[%02d] mismatched tag Artist: %v != %v", i, saved.Artist(), mp3.Artist())
+		}
+
+		// The audio frames must be unchanged, excluding an ID3v1 tag which may be updated
+		audio := test.stream[mp3.(*MP3Parser).audioStart:]
+		savedAudio := buf.Bytes()[saved.(*MP3Parser).audioStart:]
+		if bytes.HasPrefix(audio[len(audio)-id3v1Size:], id3v1Marker) {
+			audio = audio[:len(audio)-id3v1Size]
+			savedAudio = savedAudio[:len(savedAudio)-id3v1Size]
+		}
+
+		if !bytes.Equal(savedAudio, audio) {
+			t.Fatalf("[%02d] audio frames were modified", i)
+		}
+	}
+}
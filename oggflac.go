@@ -0,0 +1,147 @@
+package taggolib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+var (
+	// oggFLACMagicNumber is the signature which begins the first packet of an Ogg FLAC stream
+	oggFLACMagicNumber = []byte("\x7fFLAC")
+)
+
+// init registers the Ogg FLAC format with New, for Ogg streams beginning with a FLAC mapping header
+func init() {
+	magic, mask := oggCodecMagic(oggFLACMagicNumber)
+	registerFormatMask(FormatOggFLAC, magic, mask, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newOGGFLACParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
+// newOGGFLACParser creates a parser for FLAC audio streams stored in an Ogg container
+func newOGGFLACParser(reader io.ReadSeeker, options Options) (*FLACParser, error) {
+	// Create FLAC parser, which reads its metadata blocks from Ogg packets
+	parser := &FLACParser{
+		buffer:  make([]byte, 2048),
+		ogg:     true,
+		options: options,
+		reader:  reader,
+	}
+
+	// Parse the stream
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	// Return parser
+	return parser, nil
+}
+
+// parseOGGMetadata reassembles the metadata blocks of an Ogg FLAC stream from the header packets
+// at its start, and parses them in the same way as the metadata blocks of a FLAC stream.  The first
+// packet contains the following fields:
+//   - 5 bytes: 0x7F, followed by "FLAC"
+//   - 1 byte: major mapping version
+//   - 1 byte: minor mapping version
+//   - 2 bytes: number of header packets which follow, big endian
+//   - 4 bytes: "fLaC"
+//   - N bytes: STREAMINFO metadata block
+//
+// Each header packet which follows contains a single metadata block, and the last metadata block
+// is marked as such.  Audio packets begin on the page following the last header packet.
+func (f *FLACParser) parseOGGMetadata() error {
+	buf := make([]byte, oggMaxPageSize)
+	_, data, err := readOGGPage(f.reader, buf, f.Format())
+	if err != nil {
+		return err
+	}
+
+	if len(data) < len(oggFLACMagicNumber)+4+len(flacMagicNumber) || !bytes.Equal(data[:len(oggFLACMagicNumber)], oggFLACMagicNumber) {
+		return f.invalidStream("first Ogg packet is not a FLAC mapping header")
+	}
+
+	if major := data[5]; major != 1 {
+		return TagError{
+			Err:     ErrUnsupportedVersion,
+			Format:  f.Format(),
+			Details: fmt.Sprintf("unsupported Ogg FLAC mapping version: %d", major),
+		}
+	}
+
+	// The metadata begins with the FLAC magic number and STREAMINFO block
+	metadata := append([]byte(nil), data[9:]...)
+	last := len(metadata) > len(flacMagicNumber) && metadata[len(flacMagicNumber)]&0x80 != 0
+
+	// Reassemble the header packets which follow, each of which contains a metadata block, until
+	// the last metadata block is found
+	var packet []byte
+	for !last {
+		// Stop if parsing has been canceled
+		if err := f.options.err(); err != nil {
+			return err
+		}
+
+		header, data, err := readOGGPage(f.reader, buf, f.Format())
+		if err != nil {
+			if err == io.EOF {
+				return oggTruncatedPage(f.Format())
+			}
+
+			return err
+		}
+
+		for _, l := range header[oggPageHeaderSize:] {
+			packet = append(packet, data[:l]...)
+			data = data[l:]
+			if l == 255 {
+				continue
+			}
+
+			if len(packet) == 0 {
+				return f.invalidStream("empty Ogg FLAC header packet")
+			}
+
+			metadata = append(metadata, packet...)
+			last = packet[0]&0x80 != 0
+			packet = nil
+		}
+	}
+
+	// Note the position where audio pages begin, directly following the header pages
+	audioStart, err := f.reader.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+
+	// Parse the reassembled metadata blocks, restoring the stream reader when finished
+	reader := f.reader
+	metadataReader := bytes.NewReader(metadata)
+	f.reader = metadataReader
+	defer func() {
+		f.reader = reader
+	}()
+
+	if err := f.parseMetadata(); err != nil {
+		return err
+	}
+
+	// Pictures are interleaved with Ogg page headers in the stream, so retain the reassembled
+	// metadata blocks to read them from when they are requested
+	if len(f.pictures) > 0 {
+		f.metadata = metadataReader
+	}
+
+	// Ensure that all metadata blocks were parsed
+	if f.audioStart != int64(len(metadata)) {
+		return f.invalidStream("Ogg FLAC header packets contain data following the last metadata block")
+	}
+	f.audioStart = audioStart
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// oggFLACStream generates an Ogg FLAC stream from the FLAC test file, returning the stream and the
+// number of bytes occupied by its header pages
+func oggFLACStream(t *testing.T) ([]byte, int) {
+	// Split the metadata blocks following STREAMINFO, which end where audio frames begin
+	const audioStart = 8304
+	var blocks [][]byte
+	for i := 42; i < audioStart; {
+		length := int(binary.BigEndian.Uint32(flacFile[i:i+4]) & 0x00ffffff)
+		blocks = append(blocks, flacFile[i:i+4+length])
+		i += 4 + length
+	}
+
+	// The first packet contains the mapping header, followed by the magic number and STREAMINFO
+	first := append([]byte("\x7fFLAC\x01\x00"), 0, byte(len(blocks)))
+	first = append(first, flacFile[:42]...)
+
+	var buf bytes.Buffer
+	if _, err := writeOGGPackets(&buf, 1, 0, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := writeOGGPackets(&buf, 1, 1, blocks...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	headerSize := buf.Len()
+
+	if _, err := writeOGGPackets(&buf, 1, 64, flacFile[audioStart:]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.Bytes(), headerSize
+}
+
+// TestOGGFLAC verifies that FLAC streams stored in an Ogg container are parsed using their
+// metadata blocks, but cannot be saved
+func TestOGGFLAC(t *testing.T) {
+	stream, headerSize := oggFLACStream(t)
+
+	parser, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if parser.Format() != "Ogg FLAC" {
+		t.Fatalf("mismatched Format: %v", parser.Format())
+	}
+	if parser.FormatID() != FormatOggFLAC {
+		t.Fatalf("mismatched FormatID: %v", parser.FormatID())
+	}
+	if parser.ContentType() != "audio/ogg" {
+		t.Fatalf("mismatched ContentType: %v", parser.ContentType())
+	}
+
+	// Tags and properties are identical to those of the FLAC stream
+	if parser.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", parser.Artist())
+	}
+	if parser.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", parser.Title())
+	}
+	if parser.SampleRate() != 44100 {
+		t.Fatalf("mismatched SampleRate: %v", parser.SampleRate())
+	}
+	if parser.Duration().Truncate(time.Second) != 5*time.Second {
+		t.Fatalf("mismatched Duration: %v", parser.Duration())
+	}
+
+	flac := parser.(*FLACParser)
+	if err := flac.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, err := flac.RawTagBlock()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(raw, stream[:headerSize]) {
+		t.Fatalf("mismatched RawTagBlock: %d bytes", len(raw))
+	}
+
+	if err := flac.Save(new(bytes.Buffer)); !IsUnsupportedVersion(err) {
+		t.Fatalf("expected unsupported version error, got: %v", err)
+	}
+
+	// Truncated header pages are reported as invalid
+	if _, err := New(bytes.NewReader(stream[:headerSize/2])); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
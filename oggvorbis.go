@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +12,31 @@ import (
 	"github.com/eaburns/bit"
 )
 
+const (
+	// oggPageContinued is the Ogg page header type flag which indicates that a page continues
+	// a packet from the previous page
+	oggPageContinued = 0x01
+	// oggPageBeginningOfStream is the Ogg page header type flag which indicates the first page of
+	// a logical bitstream
+	oggPageBeginningOfStream = 0x02
+	// oggPageEndOfStream is the Ogg page header type flag which indicates the final page of a
+	// logical bitstream
+	oggPageEndOfStream = 0x04
+
+	// oggPageHeaderSize is the size of the fixed portion of an Ogg page header, which precedes
+	// its segment table
+	oggPageHeaderSize = 27
+	// oggMaxPageSize is the maximum size of an Ogg page: a header with 255 segments of 255 bytes
+	oggMaxPageSize = oggPageHeaderSize + 255 + 255*255
+	// oggDurationChunkSize is the number of bytes read at a time while scanning backward from
+	// the end of a stream for its final page
+	oggDurationChunkSize = 4096
+
+	// Tags specific to Ogg Vorbis, which contain embedded cover art
+	oggVorbisTagCoverArt = "COVERART"
+	oggVorbisTagPicture  = "METADATA_BLOCK_PICTURE"
+)
+
 var (
 	// oggMagicNumber is the magic number used to identify an OGG container audio stream
 	oggMagicNumber = []byte("OggS")
@@ -20,13 +44,38 @@ var (
 	oggVorbisVorbisWord = []byte("vorbis")
 )
 
-// oggVorbisParser represents a OGGVorbis audio metadata tag parser
-type oggVorbisParser struct {
-	duration time.Duration
-	encoder  string
-	idHeader *oggVorbisIDHeader
-	reader   io.ReadSeeker
-	tags     map[string]string
+// oggCRCTable is the lookup table for the CRC-32 used to protect Ogg pages, with polynomial 0x04c11db7
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+
+	return table
+}()
+
+// OggVorbisParser represents an Ogg Vorbis audio metadata tag parser.  A *OggVorbisParser is returned
+// by New for Ogg Vorbis streams, and may be type-asserted to access methods specific to the format.
+type OggVorbisParser struct {
+	duration     time.Duration
+	endPos       int64
+	finalGranule uint64
+	idHeader     *OggVorbisIDHeader
+	options      Options
+	reader       io.ReadSeeker
+	sampleCount  uint64
+	serial       uint32
+	start        int64
+	streams      int
+	tags         map[string]string
+	vendor       string
 
 	// Shared buffer and unsigned integers stored as fields to prevent unneeded allocations
 	buffer []byte
@@ -35,51 +84,145 @@ type oggVorbisParser struct {
 	ui64   uint64
 }
 
+// init registers the Ogg Vorbis format with New, for Ogg streams beginning with a Vorbis identification
+// header
+func init() {
+	magic, mask := oggCodecMagic(append([]byte{1}, oggVorbisVorbisWord...))
+	registerFormatMask(FormatOggVorbis, magic, mask, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newOGGVorbisParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
 // Album returns the Album tag for this stream
-func (o oggVorbisParser) Album() string {
+func (o OggVorbisParser) Album() string {
 	return o.tags[tagAlbum]
 }
 
 // AlbumArtist returns the AlbumArtist tag for this stream
-func (o oggVorbisParser) AlbumArtist() string {
+func (o OggVorbisParser) AlbumArtist() string {
 	return o.tags[tagAlbumArtist]
 }
 
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (o OggVorbisParser) AlbumArtistSort() string {
+	return o.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (o OggVorbisParser) AlbumSort() string {
+	return o.tags[tagAlbumSort]
+}
+
 // Artist returns the Artist tag for this stream
-func (o oggVorbisParser) Artist() string {
+func (o OggVorbisParser) Artist() string {
 	return o.tags[tagArtist]
 }
 
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (o OggVorbisParser) ArtistSort() string {
+	return o.tags[tagArtistSort]
+}
+
 // BitDepth returns the bits-per-sample of this stream
-func (o oggVorbisParser) BitDepth() int {
+func (o OggVorbisParser) BitDepth() int {
 	// Ogg Vorbis should always provide 16 bit depth
 	return 16
 }
 
 // Bitrate calculates the audio bitrate for this stream
-func (o oggVorbisParser) Bitrate() int {
+func (o OggVorbisParser) Bitrate() int {
 	// BUG(mdlayher): Ogg Vorbis: check if maximum/minimum bitrate from headers should be used in calculation
-	return int(o.idHeader.NomBitrate) / 1000
+	if nominal := oggVorbisBitrate(o.idHeader.NomBitrate); nominal > 0 {
+		return nominal
+	}
+
+	// Pure VBR streams may not set a nominal bitrate, so calculate the average bitrate using the
+	// stream size and duration, checking for zero values to prevent a division-by-zero panic
+	seconds := o.duration.Seconds()
+	if o.endPos == 0 || seconds == 0 {
+		return 0
+	}
+
+	return int(float64(o.endPos*8) / seconds / 1000)
+}
+
+// BitrateMode returns the rate management mode used to encode this stream, as indicated by its
+// minimum, maximum, and nominal bitrates.  Streams with all three bitrates set and equal are hard CBR,
+// streams with a nominal bitrate bounded by a minimum or maximum bitrate are ABR, and all others are
+// quality-based VBR.
+func (o OggVorbisParser) BitrateMode() BitrateMode {
+	nominal := oggVorbisBitrate(o.idHeader.NomBitrate)
+	min, max := o.MinBitrate(), o.MaxBitrate()
+
+	switch {
+	case nominal > 0 && min == nominal && max == nominal:
+		return BitrateModeCBR
+	case nominal > 0 && (min > 0 || max > 0):
+		return BitrateModeABR
+	default:
+		return BitrateModeVBR
+	}
+}
+
+// BPM returns the BPM (beats per minute) tag for this stream
+func (o OggVorbisParser) BPM() int {
+	bpm, err := strconv.Atoi(o.tags[tagBPM])
+	if err != nil {
+		return 0
+	}
+
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, such as "mono", "stereo", or "5.1"
+func (o OggVorbisParser) ChannelLayout() string {
+	return channelLayout(o.Channels())
 }
 
 // Channels returns the number of channels for this stream
-func (o oggVorbisParser) Channels() int {
+func (o OggVorbisParser) Channels() int {
 	return int(o.idHeader.ChannelCount)
 }
 
 // Comment returns the Comment tag for this stream
-func (o oggVorbisParser) Comment() string {
+func (o OggVorbisParser) Comment() string {
 	return o.tags[tagComment]
 }
 
+// Composer returns the Composer tag for this stream
+func (o OggVorbisParser) Composer() string {
+	return o.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (o OggVorbisParser) Conductor() string {
+	return o.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (o OggVorbisParser) ContentType() string {
+	return o.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (o OggVorbisParser) Copyright() string {
+	return o.tags[tagCopyright]
+}
+
 // Date returns the Date tag for this stream
-func (o oggVorbisParser) Date() string {
+func (o OggVorbisParser) Date() string {
 	return o.tags[tagDate]
 }
 
 // DiscNumber returns the DiscNumber tag for this stream
-func (o oggVorbisParser) DiscNumber() int {
-	disc, err := strconv.Atoi(o.tags[tagDiscNumber])
+func (o OggVorbisParser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(o.tags[tagDiscNumber], "/")[0])
 	if err != nil {
 		return 0
 	}
@@ -88,47 +231,341 @@ func (o oggVorbisParser) DiscNumber() int {
 }
 
 // Duration returns the time duration for this stream
-func (o oggVorbisParser) Duration() time.Duration {
+func (o OggVorbisParser) Duration() time.Duration {
 	return o.duration
 }
 
-// Encoder returns the encoder for this stream
-func (o oggVorbisParser) Encoder() string {
-	return o.encoder
+// EncodedBy returns the EncodedBy tag for this stream
+func (o OggVorbisParser) EncodedBy() string {
+	return o.tags[tagEncodedBy]
+}
+
+// Encoder returns the encoder for this stream, preferring the ENCODER tag over the vendor string
+func (o OggVorbisParser) Encoder() string {
+	if encoder := o.tags[tagEncoder]; encoder != "" {
+		return encoder
+	}
+
+	return o.vendor
+}
+
+// FinalGranule returns the granule position of the final Ogg page of this stream, which is the
+// position of its last sample at the sample rate of the stream.  For chained streams, the final page
+// belongs to the last logical bitstream.  If the final page was not read, because only tags were
+// requested or only a prefix of the stream is available, FinalGranule returns 0.
+func (o OggVorbisParser) FinalGranule() uint64 {
+	return o.finalGranule
 }
 
 // Format returns the name of the Ogg Vorbis format
-func (o oggVorbisParser) Format() string {
-	return "Ogg Vorbis"
+func (o OggVorbisParser) Format() string {
+	return o.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (o OggVorbisParser) FormatID() Format {
+	return FormatOggVorbis
 }
 
 // Genre returns the Genre tag for this stream
-func (o oggVorbisParser) Genre() string {
+func (o OggVorbisParser) Genre() string {
 	return o.tags[tagGenre]
 }
 
-// Publisher returns the Publisher (record-label) tag for this stream
-func (o oggVorbisParser) Publisher() string {
-	return o.tags[tagPublisher]
+// Grouping returns the Grouping (content group) tag for this stream
+func (o OggVorbisParser) Grouping() string {
+	return o.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+func (o OggVorbisParser) HasPicture() bool {
+	return o.tags[oggVorbisTagPicture] != "" || o.tags[oggVorbisTagCoverArt] != ""
+}
+
+// HeaderFingerprint returns a stable identifier derived from the identification header and size of this stream
+func (o OggVorbisParser) HeaderFingerprint() []byte {
+	return headerFingerprint(o.endPos, *o.idHeader)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (o OggVorbisParser) InitialKey() string {
+	return firstTag(o.tags, tagInitialKey, tagKey)
+}
+
+// IsVBR returns whether or not this stream is effectively variable bitrate.  A stream is only
+// considered constant bitrate if its minimum, maximum, and nominal bitrates are all set and equal.
+func (o OggVorbisParser) IsVBR() bool {
+	nominal := oggVorbisBitrate(o.idHeader.NomBitrate)
+	return nominal == 0 || o.MinBitrate() != nominal || o.MaxBitrate() != nominal
+}
+
+// Language returns the Language tag for this stream
+func (o OggVorbisParser) Language() string {
+	return o.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream, falling back to the UnsyncedLyrics tag
+func (o OggVorbisParser) Lyrics() string {
+	if lyrics := o.tags[tagLyrics]; lyrics != "" {
+		return lyrics
+	}
+
+	return o.tags[tagUnsyncedLyrics]
+}
+
+// MaxBitrate returns the maximum bitrate for this stream, or 0 if it is not set
+func (o OggVorbisParser) MaxBitrate() int {
+	return oggVorbisBitrate(o.idHeader.MaxBitrate)
+}
+
+// MinBitrate returns the minimum bitrate for this stream, or 0 if it is not set
+func (o OggVorbisParser) MinBitrate() int {
+	return oggVorbisBitrate(o.idHeader.MinBitrate)
+}
+
+// Mood returns the Mood tag for this stream
+func (o OggVorbisParser) Mood() string {
+	return o.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (o OggVorbisParser) OriginalFilename() string {
+	return o.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (o OggVorbisParser) Properties() AudioProperties {
+	return audioProperties(&o)
+}
+
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (o OggVorbisParser) Publisher() string {
+	return firstTag(o.tags, tagPublisher, tagLabel, tagOrganization)
+}
+
+// Raw returns a copy of the format-specific header parsed from this stream, as an OggVorbisIDHeader
+func (o OggVorbisParser) Raw() interface{} {
+	return *o.idHeader
+}
+
+// RawTagBlock returns a copy of the raw bytes of the Ogg pages which contain the identification,
+// comment, and setup headers of this stream.  The setup header is included because it commonly shares
+// a page with the comment header, and audio pages begin directly after it.
+func (o OggVorbisParser) RawTagBlock() ([]byte, error) {
+	if _, err := o.reader.Seek(o.start, 0); err != nil {
+		return nil, err
+	}
+
+	// Read pages until all three header packets are complete
+	buf := make([]byte, oggMaxPageSize)
+	var block []byte
+	for packets := 0; packets < 3; {
+		header, data, err := o.readOGGPage(buf)
+		if err != nil {
+			if err == io.EOF {
+				return nil, o.truncatedPage()
+			}
+
+			return nil, err
+		}
+		block = append(block, buf[:len(header)+len(data)]...)
+
+		for _, l := range header[oggPageHeaderSize:] {
+			if l < 255 {
+				packets++
+			}
+		}
+	}
+
+	return block, nil
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (o OggVorbisParser) Remixer() string {
+	return firstTag(o.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (o OggVorbisParser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(o.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (o OggVorbisParser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(o.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (o OggVorbisParser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(o.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (o OggVorbisParser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(o.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new Ogg Vorbis stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (o *OggVorbisParser) Reset(reader io.ReadSeeker) error {
+	*o = OggVorbisParser{
+		buffer:  o.buffer,
+		options: o.options,
+		reader:  reader,
+	}
+
+	return o.parse()
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (o OggVorbisParser) SampleFormat() SampleFormat {
+	// Ogg Vorbis is a lossy format, so its decoded samples have no inherent format
+	return SampleFormatUnknown
+}
+
+// Save writes a copy of this Ogg Vorbis stream to w, replacing its comment header with one containing
+// the tags set on this parser.  The pages containing the comment and setup headers are rewritten, and
+// the pages which follow them are renumbered if the number of header pages changes.  The identification
+// header and audio data are copied unchanged.
+func (o *OggVorbisParser) Save(w io.Writer) error {
+	if _, err := o.reader.Seek(o.start, 0); err != nil {
+		return err
+	}
+
+	// Copy the first page, which contains only the identification header
+	buf := make([]byte, oggMaxPageSize)
+	header, data, err := o.readOGGPage(buf)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf[:len(header)+len(data)]); err != nil {
+		return err
+	}
+
+	// Reassemble the comment and setup header packets from the pages which follow, keeping only
+	// the setup header
+	var packets [][]byte
+	var packet []byte
+	pages := 0
+	for len(packets) < 2 {
+		header, data, err := o.readOGGPage(buf)
+		if err != nil {
+			if err == io.EOF {
+				return o.truncatedPage()
+			}
+
+			return err
+		}
+		pages++
+
+		for _, l := range header[oggPageHeaderSize:] {
+			packet = append(packet, data[:l]...)
+			data = data[l:]
+			if l < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+	}
+
+	// Write the new comment header, which ends with a set framing flag, followed by the setup header
+	comment := append([]byte{3}, oggVorbisVorbisWord...)
+	comment = append(comment, vorbisCommentBytes(o.vendor, o.tags)...)
+	comment = append(comment, 1)
+
+	n, err := writeOGGPackets(w, o.serial, 1, comment, packets[1])
+	if err != nil {
+		return err
+	}
+
+	// Copy the remaining pages, renumbering pages of this logical bitstream if the number of header
+	// pages has changed, which requires the checksum of each page to be recalculated
+	delta := uint32(n - pages)
+	for {
+		header, data, err := o.readOGGPage(buf)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if delta != 0 && binary.LittleEndian.Uint32(header[14:18]) == o.serial {
+			binary.LittleEndian.PutUint32(header[18:22], binary.LittleEndian.Uint32(header[18:22])+delta)
+			binary.LittleEndian.PutUint32(header[22:26], oggPageCRC(header, data))
+		}
+
+		if _, err := w.Write(buf[:len(header)+len(data)]); err != nil {
+			return err
+		}
+	}
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (o OggVorbisParser) SampleCount() uint64 {
+	return o.sampleCount
 }
 
 // SampleRate returns the sample rate in Hertz for this stream
-func (o oggVorbisParser) SampleRate() int {
+func (o OggVorbisParser) SampleRate() int {
 	return int(o.idHeader.SampleRate)
 }
 
+// Streams returns the number of chained Vorbis logical bitstreams in this stream, which is typically 1.
+// If the duration of the stream was not determined, 0 is returned.
+func (o OggVorbisParser) Streams() int {
+	return o.streams
+}
+
+// SetTag sets the value of the named tag in the comment header of this stream, replacing any existing
+// value.  An empty value removes the tag.  The stream is not modified until Save is called.
+func (o *OggVorbisParser) SetTag(name string, value string) {
+	setTag(&o.tags, name, value)
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (o OggVorbisParser) String() string {
+	return summarize(&o)
+}
+
 // Tag attempts to return the raw, unprocessed tag with the specified name for this stream
-func (o oggVorbisParser) Tag(name string) string {
-	return o.tags[name]
+func (o OggVorbisParser) Tag(name string) string {
+	return o.tags[strings.ToUpper(name)]
+}
+
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream
+func (o OggVorbisParser) TagMulti(name string) []string {
+	return tagValues(o.tags, name)
 }
 
 // Title returns the Title tag for this stream
-func (o oggVorbisParser) Title() string {
+func (o OggVorbisParser) Title() string {
 	return o.tags[tagTitle]
 }
 
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (o OggVorbisParser) TitleSort() string {
+	return o.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (o OggVorbisParser) TotalDiscs() int {
+	return parseTotal(o.tags[tagDiscNumber], firstTag(o.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (o OggVorbisParser) TotalTracks() int {
+	return parseTotal(o.tags[tagTrackNumber], firstTag(o.tags, tagTrackTotal, tagTotalTracks))
+}
+
 // TrackNumber returns the TrackNumber tag for this stream
-func (o oggVorbisParser) TrackNumber() int {
+func (o OggVorbisParser) TrackNumber() int {
 	// Check for a /, such as 2/8
 	track, err := strconv.Atoi(strings.Split(o.tags[tagTrackNumber], "/")[0])
 	if err != nil {
@@ -138,31 +575,219 @@ func (o oggVorbisParser) TrackNumber() int {
 	return track
 }
 
+// Vendor returns the vendor string for this stream, which typically identifies the encoding software
+func (o OggVorbisParser) Vendor() string {
+	return o.vendor
+}
+
+// VerifyChecksums reads every Ogg page in this stream, and verifies that the CRC-32 checksum stored in
+// each page header matches the contents of the page.  Because the entire stream is read, checksums
+// are only verified on request, and not while parsing.
+func (o OggVorbisParser) VerifyChecksums() error {
+	if _, err := o.reader.Seek(o.start, 0); err != nil {
+		return err
+	}
+
+	buf := make([]byte, oggMaxPageSize)
+	for pages := 0; ; pages++ {
+		// Stop if verification has been canceled
+		if err := o.options.err(); err != nil {
+			return err
+		}
+
+		// Read each page, stopping cleanly at the end of the stream
+		header, data, err := o.readOGGPage(buf)
+		if err != nil {
+			if err == io.EOF && pages > 0 {
+				return nil
+			}
+			if err == io.EOF {
+				return o.truncatedPage()
+			}
+
+			return err
+		}
+
+		checksum := binary.LittleEndian.Uint32(header[22:26])
+		if crc := oggPageCRC(header, data); crc != checksum {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  o.Format(),
+				Details: fmt.Sprintf("Ogg page %d checksum mismatch: %08x != %08x", pages, crc, checksum),
+			}
+		}
+	}
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (o OggVorbisParser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range o.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (o OggVorbisParser) Work() string {
+	return o.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (o OggVorbisParser) Year() int {
+	return parseYear(o.tags[tagDate])
+}
+
+// writeOGGPackets writes the input packets to w as a sequence of Ogg pages belonging to the logical
+// bitstream with the input serial number, with page sequence numbers beginning at sequence.  Each page
+// holds up to 255 segments, and packets may span multiple pages.  The number of pages written is returned.
+func writeOGGPackets(w io.Writer, serial uint32, sequence uint32, packets ...[]byte) (int, error) {
+	// Generate the lacing values for each packet, where a value less than 255 ends a packet
+	var lacing []byte
+	var all []byte
+	for _, p := range packets {
+		for i := 0; i < len(p)/255; i++ {
+			lacing = append(lacing, 255)
+		}
+		lacing = append(lacing, byte(len(p)%255))
+		all = append(all, p...)
+	}
+
+	pages := 0
+	continued := false
+	for len(lacing) > 0 {
+		segments := lacing
+		if len(segments) > 255 {
+			segments = segments[:255]
+		}
+		lacing = lacing[len(segments):]
+
+		// The granule position of header pages is 0, unless no packet ends on the page
+		size := 0
+		granule := ^uint64(0)
+		for _, l := range segments {
+			size += int(l)
+			if l < 255 {
+				granule = 0
+			}
+		}
+
+		header := make([]byte, oggPageHeaderSize, oggPageHeaderSize+len(segments))
+		copy(header, oggMagicNumber)
+		if continued {
+			header[5] = oggPageContinued
+		}
+		binary.LittleEndian.PutUint64(header[6:14], granule)
+		binary.LittleEndian.PutUint32(header[14:18], serial)
+		binary.LittleEndian.PutUint32(header[18:22], sequence+uint32(pages))
+		header[26] = byte(len(segments))
+		header = append(header, segments...)
+
+		data := all[:size]
+		all = all[size:]
+		binary.LittleEndian.PutUint32(header[22:26], oggPageCRC(header, data))
+
+		if _, err := w.Write(header); err != nil {
+			return pages, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return pages, err
+		}
+
+		pages++
+		continued = segments[len(segments)-1] == 255
+	}
+
+	return pages, nil
+}
+
+// oggPageCRC calculates the CRC-32 checksum of an Ogg page, which is calculated over the entire page
+// with the checksum field of its header set to zero
+func oggPageCRC(header []byte, data []byte) uint32 {
+	crc := oggCRC32(0, header[:22])
+	crc = oggCRC32(crc, []byte{0, 0, 0, 0})
+	crc = oggCRC32(crc, header[26:])
+	return oggCRC32(crc, data)
+}
+
+// oggCRC32 updates the CRC-32 used to protect Ogg pages with the input data
+func oggCRC32(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+
+	return crc
+}
+
+// oggVorbisBitrate converts a bitrate from an Ogg Vorbis identification header into kbps.  Bitrates
+// are signed values, and values less than or equal to 0 indicate that the bitrate is not set.
+func oggVorbisBitrate(bitrate uint32) int {
+	if int32(bitrate) <= 0 {
+		return 0
+	}
+
+	return int(bitrate) / 1000
+}
+
 // newOGGVorbisParser creates a parser for OGGVorbis audio streams
-func newOGGVorbisParser(reader io.ReadSeeker) (*oggVorbisParser, error) {
+func newOGGVorbisParser(reader io.ReadSeeker, options Options) (*OggVorbisParser, error) {
 	// Create OGGVorbis parser
-	parser := &oggVorbisParser{
-		buffer: make([]byte, 128),
-		reader: reader,
+	parser := &OggVorbisParser{
+		buffer:  make([]byte, 128),
+		options: options,
+		reader:  reader,
 	}
 
-	// Parse the required ID header
-	if err := parser.parseOGGVorbisIDHeader(); err != nil {
+	// Parse the stream
+	if err := parser.parse(); err != nil {
 		return nil, err
 	}
 
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a Ogg Vorbis stream from the reader of this parser
+func (o *OggVorbisParser) parse() error {
+	// Note the start of the stream, so it may be copied when saving tags
+	start, err := o.reader.Seek(0, 1)
+	if err != nil {
+		return err
+	}
+	o.start = start
+
+	// Parse the required ID header
+	if err := o.parseOGGVorbisIDHeader(); err != nil {
+		return err
+	}
+
 	// Parse the required comment header
-	if err := parser.parseOGGVorbisCommentHeader(); err != nil {
-		return nil, err
+	if err := o.parseOGGVorbisCommentHeader(); err != nil {
+		return err
 	}
 
-	// Parse the file's duration
-	if err := parser.parseOGGVorbisDuration(); err != nil {
-		return nil, err
+	// If only a prefix of the stream is available, the duration cannot be determined, and it
+	// is not needed if only tags are requested
+	if o.options.streaming || o.options.TagsOnly {
+		return nil
 	}
 
-	// Return parser
-	return parser, nil
+	// Determine the size of the stream
+	n, err := streamSize(o.reader)
+	if err != nil {
+		return err
+	}
+	o.endPos = n
+
+	// Parse the file's duration, stopping first if parsing has been canceled
+	if err := o.options.err(); err != nil {
+		return err
+	}
+	if err := o.parseOGGVorbisDuration(); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // oggVorbisPageHeader represents the information contained in an Ogg Page header
@@ -175,31 +800,27 @@ type oggVorbisPageHeader struct {
 	PageSequence    uint32
 	Checksum        []byte
 	PageSegments    uint8
+	SegmentTable    []byte
 }
 
 // parseOGGVorbisPageHeader parses an Ogg page header
-func (o *oggVorbisParser) parseOGGVorbisPageHeader(skipMagicNumber bool) (*oggVorbisPageHeader, error) {
+func (o *OggVorbisParser) parseOGGVorbisPageHeader() (*oggVorbisPageHeader, error) {
 	// Create page header
 	pageHeader := new(oggVorbisPageHeader)
 
-	// Unless skip is specified, check for capture pattern
-	if !skipMagicNumber {
-		if _, err := o.reader.Read(o.buffer[:4]); err != nil {
-			return nil, err
-		}
-		pageHeader.CapturePattern = o.buffer[:4]
+	// Check for capture pattern
+	if _, err := o.reader.Read(o.buffer[:4]); err != nil {
+		return nil, err
+	}
+	pageHeader.CapturePattern = o.buffer[:4]
 
-		// Verify proper capture pattern
-		if !bytes.Equal(pageHeader.CapturePattern, oggMagicNumber) {
-			return nil, TagError{
-				Err:     errInvalidStream,
-				Format:  o.Format(),
-				Details: "unrecognized capture pattern in Ogg page header",
-			}
+	// Verify proper capture pattern
+	if !bytes.Equal(pageHeader.CapturePattern, oggMagicNumber) {
+		return nil, TagError{
+			Err:     ErrInvalidStream,
+			Format:  o.Format(),
+			Details: "unrecognized capture pattern in Ogg page header",
 		}
-	} else {
-		// If skipped, assume capture pattern is correct magic number
-		pageHeader.CapturePattern = oggMagicNumber
 	}
 
 	// Version (must always be 0)
@@ -211,7 +832,7 @@ func (o *oggVorbisParser) parseOGGVorbisPageHeader(skipMagicNumber bool) (*oggVo
 	// Verify mandated version 0
 	if pageHeader.Version != 0 {
 		return nil, TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  o.Format(),
 			Details: fmt.Sprintf("Vorbis version must be 0, but found version %d", pageHeader.Version),
 		}
@@ -253,17 +874,236 @@ func (o *oggVorbisParser) parseOGGVorbisPageHeader(skipMagicNumber bool) (*oggVo
 	}
 	pageHeader.PageSegments = o.ui8
 
-	// Segment table is next, but we won't need it for tag parsing, so seek ahead
-	// size of uint8 (1 byte) multiplied by number of page segments
-	if _, err := o.reader.Seek(int64(pageHeader.PageSegments), 1); err != nil {
+	// Segment table, which contains the lacing values used to determine packet boundaries
+	pageHeader.SegmentTable = make([]byte, pageHeader.PageSegments)
+	if _, err := io.ReadFull(o.reader, pageHeader.SegmentTable); err != nil {
 		return nil, err
-
 	}
+
 	return pageHeader, nil
 }
 
+// oggCodecMagic generates a magic number and mask which match the capture pattern of the first page
+// of an Ogg stream, followed by the input codec signature at the start of the page's data.  The first
+// page of a logical bitstream contains only the codec's identification header, which is shorter than
+// 255 bytes for all supported codecs, so the page must contain exactly one segment.
+func oggCodecMagic(signature []byte) ([]byte, []byte) {
+	magic := make([]byte, oggPageHeaderSize+1+len(signature))
+	mask := make([]byte, len(magic))
+
+	copy(magic, oggMagicNumber)
+	magic[oggPageHeaderSize-1] = 1
+	copy(magic[oggPageHeaderSize+1:], signature)
+
+	for i := range mask {
+		if i < len(oggMagicNumber) || i == oggPageHeaderSize-1 || i > oggPageHeaderSize {
+			mask[i] = 0xff
+		}
+	}
+
+	return magic, mask
+}
+
+// readOGGPage reads a complete Ogg page from the reader of this parser into buf, using readOGGPage
+func (o OggVorbisParser) readOGGPage(buf []byte) ([]byte, []byte, error) {
+	return readOGGPage(o.reader, buf, o.Format())
+}
+
+// truncatedPage generates an invalid stream error for an Ogg page which extends past the end of the stream
+func (o OggVorbisParser) truncatedPage() error {
+	return oggTruncatedPage(o.Format())
+}
+
+// readOGGPage reads a complete Ogg page from an input reader into buf, which must be large enough to
+// hold a page of the maximum size.  The page header, including its segment table, and the page data
+// are returned as slices of buf.  io.EOF is returned only if the stream ends before a page begins.
+// Errors are reported using the input format name.
+func readOGGPage(reader io.Reader, buf []byte, format string) ([]byte, []byte, error) {
+	// Read the fixed portion of the page header
+	if _, err := io.ReadFull(reader, buf[:oggPageHeaderSize]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, nil, oggTruncatedPage(format)
+		}
+
+		return nil, nil, err
+	}
+
+	if !bytes.Equal(buf[:len(oggMagicNumber)], oggMagicNumber) {
+		return nil, nil, TagError{
+			Err:     ErrInvalidStream,
+			Format:  format,
+			Details: "unrecognized capture pattern in Ogg page header",
+		}
+	}
+
+	// Read the segment table, and the page data described by its lacing values
+	segments := int(buf[oggPageHeaderSize-1])
+	header := buf[:oggPageHeaderSize+segments]
+	if _, err := io.ReadFull(reader, header[oggPageHeaderSize:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, nil, oggTruncatedPage(format)
+		}
+
+		return nil, nil, err
+	}
+
+	size := 0
+	for _, l := range header[oggPageHeaderSize:] {
+		size += int(l)
+	}
+
+	data := buf[len(header) : len(header)+size]
+	if _, err := io.ReadFull(reader, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, nil, oggTruncatedPage(format)
+		}
+
+		return nil, nil, err
+	}
+
+	return header, data, nil
+}
+
+// oggFinalPage scans backward from the input end position of an Ogg stream to find its final page,
+// returning the granule position and serial number of that page, and whether it was found.  buf is
+// used to read the stream, and must hold at least oggDurationChunkSize+oggPageHeaderSize-1 bytes.
+func oggFinalPage(reader io.ReadSeeker, endPos int64, buf []byte, options Options) (uint64, uint32, bool, error) {
+	// Scan backward from the end of the stream one chunk at a time, so that memory use is bounded
+	// regardless of the size of the stream or its pages.  The last granule position is the total
+	// number of samples in the stream, and is taken from the page which marks the end of stream.
+	// If no such page is found, the granule position of the last page found is used instead.
+	var granule uint64
+	var serial uint32
+	found := false
+scan:
+	for end := endPos; end > 0; end -= oggDurationChunkSize {
+		// Stop if parsing has been canceled
+		if err := options.err(); err != nil {
+			return 0, 0, false, err
+		}
+
+		start := end - oggDurationChunkSize
+		if start < 0 {
+			start = 0
+		}
+
+		stop := end + oggPageHeaderSize - 1
+		if stop > endPos {
+			stop = endPos
+		}
+
+		if _, err := reader.Seek(start, 0); err != nil {
+			return 0, 0, false, err
+		}
+
+		window := buf[:stop-start]
+		if _, err := io.ReadFull(reader, window); err != nil {
+			return 0, 0, false, err
+		}
+
+		// Check each capture pattern which begins within this chunk, from last to first
+		i := int(end - start)
+		for {
+			i = bytes.LastIndex(window[:i+len(oggMagicNumber)-1], oggMagicNumber)
+			if i == -1 {
+				break
+			}
+
+			// Skip capture patterns which are truncated, are not followed by the mandated
+			// version 0, or occur on pages where no packet ends
+			header := window[i:]
+			if len(header) < oggPageHeaderSize || header[4] != 0 {
+				continue
+			}
+
+			position := binary.LittleEndian.Uint64(header[6:14])
+			if position == ^uint64(0) {
+				continue
+			}
+
+			if !found {
+				granule = position
+				serial = binary.LittleEndian.Uint32(header[14:18])
+				found = true
+			}
+
+			if header[5]&oggPageEndOfStream != 0 {
+				granule = position
+				serial = binary.LittleEndian.Uint32(header[14:18])
+				break scan
+			}
+		}
+
+		// The final page must begin within the maximum size of a page from the end of the stream
+		if endPos-start >= oggMaxPageSize {
+			break
+		}
+	}
+
+	return granule, serial, found, nil
+}
+
+// oggTruncatedPage generates an invalid stream error for an Ogg page which extends past the end of
+// the stream, using the input format name
+func oggTruncatedPage(format string) error {
+	return TagError{
+		Err:     ErrInvalidStream,
+		Format:  format,
+		Details: "Ogg page extends past end of stream",
+	}
+}
+
+// parseOGGVorbisPacket reads a complete Ogg packet which begins at the start of the next page,
+// following the segment table across continuation pages until the packet is complete
+func (o *OggVorbisParser) parseOGGVorbisPacket() ([]byte, error) {
+	var packet []byte
+	for page := 0; ; page++ {
+		// Stop if parsing has been canceled
+		if err := o.options.err(); err != nil {
+			return nil, err
+		}
+
+		// Read OGGVorbis page header
+		pageHeader, err := o.parseOGGVorbisPageHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		// Every page after the first must continue the packet
+		if page > 0 && pageHeader.HeaderType&oggPageContinued == 0 {
+			return nil, TagError{
+				Err:     ErrInvalidStream,
+				Format:  o.Format(),
+				Details: "Ogg packet is not continued on following page",
+			}
+		}
+
+		// Sum lacing values for this page, where a value less than 255 marks the end of the packet
+		size := 0
+		complete := false
+		for _, l := range pageHeader.SegmentTable {
+			size += int(l)
+			if l < 255 {
+				complete = true
+				break
+			}
+		}
+
+		// Append this page's portion of the packet
+		n := len(packet)
+		packet = append(packet, make([]byte, size)...)
+		if _, err := io.ReadFull(o.reader, packet[n:]); err != nil {
+			return nil, err
+		}
+
+		if complete {
+			return packet, nil
+		}
+	}
+}
+
 // parseOGGVorbisCommonHeader parses information common to all Ogg Vorbis headers
-func (o *oggVorbisParser) parseOGGVorbisCommonHeader() (byte, error) {
+func (o *OggVorbisParser) parseOGGVorbisCommonHeader() (byte, error) {
 	// Read the first byte to get header type
 	if _, err := o.reader.Read(o.buffer[:1]); err != nil {
 		return 0, err
@@ -280,7 +1120,7 @@ func (o *oggVorbisParser) parseOGGVorbisCommonHeader() (byte, error) {
 	// Ensure 'vorbis' identification word is present
 	if !bytes.Equal(o.buffer[:len(oggVorbisVorbisWord)], oggVorbisVorbisWord) {
 		return 0, TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  o.Format(),
 			Details: "unrecognized identification word in header",
 		}
@@ -290,8 +1130,9 @@ func (o *oggVorbisParser) parseOGGVorbisCommonHeader() (byte, error) {
 	return o.buffer[len(o.buffer)-1], nil
 }
 
-// oggVorbisIDHeader represents the information contained in an Ogg Vorbis identification header
-type oggVorbisIDHeader struct {
+// OggVorbisIDHeader represents the information contained in an Ogg Vorbis identification header.
+// A copy of the OggVorbisIDHeader for an Ogg Vorbis stream is returned by Raw.
+type OggVorbisIDHeader struct {
 	VorbisVersion uint32
 	ChannelCount  uint8
 	SampleRate    uint32
@@ -304,12 +1145,14 @@ type oggVorbisIDHeader struct {
 }
 
 // parseOGGVorbisIDHeader parses the required identification header for an Ogg Vorbis stream
-func (o *oggVorbisParser) parseOGGVorbisIDHeader() error {
-	// Read OGGVorbis page header, skipping the capture pattern because New() already verified
-	// the magic number for us
-	if _, err := o.parseOGGVorbisPageHeader(true); err != nil {
+func (o *OggVorbisParser) parseOGGVorbisIDHeader() error {
+	// Read OGGVorbis page header, which begins with the magic number, and note the serial
+	// number of the first logical bitstream
+	pageHeader, err := o.parseOGGVorbisPageHeader()
+	if err != nil {
 		return err
 	}
+	o.serial = pageHeader.BitstreamSerial
 
 	// Check for valid common header
 	headerType, err := o.parseOGGVorbisCommonHeader()
@@ -320,14 +1163,14 @@ func (o *oggVorbisParser) parseOGGVorbisIDHeader() error {
 	// Ensure header type 1: identification header
 	if headerType != byte(1) {
 		return TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  o.Format(),
 			Details: "invalid header type for identification header",
 		}
 	}
 
 	// Read fields found in identification header
-	header := new(oggVorbisIDHeader)
+	header := new(OggVorbisIDHeader)
 
 	// Vorbis version
 	if err := binary.Read(o.reader, binary.LittleEndian, &o.ui32); err != nil {
@@ -338,7 +1181,7 @@ func (o *oggVorbisParser) parseOGGVorbisIDHeader() error {
 	// Ensure Vorbis version is 0, per specification
 	if header.VorbisVersion != 0 {
 		return TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  o.Format(),
 			Details: fmt.Sprintf("Vorbis version must be 0, but found version %d", header.VorbisVersion),
 		}
@@ -365,18 +1208,38 @@ func (o *oggVorbisParser) parseOGGVorbisIDHeader() error {
 	header.NomBitrate = uint32Slice[2]
 	header.MinBitrate = uint32Slice[3]
 
-	// Create and use a bit reader to parse the following fields
-	//    4 - Blocksize 0
+	// Ensure sample rate is greater than 0, per specification
+	if header.SampleRate == 0 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  o.Format(),
+			Details: "Vorbis sample rate must be greater than 0",
+		}
+	}
+
+	// Create and use a bit reader to parse the following fields.  Vorbis packs bits starting
+	// from the least significant bit of each byte, so fields appear reversed within each byte.
 	//    4 - Blocksize 1
+	//    4 - Blocksize 0
+	//    7 - (empty)
 	//    1 - Framing flag
-	fields, err := bit.NewReader(o.reader).ReadFields(4, 4, 1)
+	fields, err := bit.NewReader(o.reader).ReadFields(4, 4, 7, 1)
 	if err != nil {
 		return err
 	}
 
-	header.Blocksize0 = uint8(fields[0])
-	header.Blocksize1 = uint8(fields[1])
-	header.Framing = fields[2] == 1
+	header.Blocksize0 = uint8(fields[1])
+	header.Blocksize1 = uint8(fields[0])
+	header.Framing = fields[3] == 1
+
+	// Ensure framing flag is set, if requested
+	if o.options.checkFraming() && !header.Framing {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  o.Format(),
+			Details: "Vorbis identification header framing flag is not set",
+		}
+	}
 
 	// Store ID header
 	o.idHeader = header
@@ -384,12 +1247,22 @@ func (o *oggVorbisParser) parseOGGVorbisIDHeader() error {
 }
 
 // parseOGGVorbisCommentHeader parses the Vorbis Comment tags in an Ogg Vorbis file
-func (o *oggVorbisParser) parseOGGVorbisCommentHeader() error {
-	// Read OGGVorbis page header, specifying false to check the capture pattern
-	if _, err := o.parseOGGVorbisPageHeader(false); err != nil {
+func (o *OggVorbisParser) parseOGGVorbisCommentHeader() error {
+	// Reassemble the comment header packet, which may span multiple pages when many tags
+	// or embedded cover art are present
+	packet, err := o.parseOGGVorbisPacket()
+	if err != nil {
 		return err
 	}
 
+	// Parse the comment header from the packet, restoring the stream reader when finished
+	reader := o.reader
+	packetReader := bytes.NewReader(packet)
+	o.reader = packetReader
+	defer func() {
+		o.reader = reader
+	}()
+
 	// Parse common header
 	headerType, err := o.parseOGGVorbisCommonHeader()
 	if err != nil {
@@ -399,7 +1272,7 @@ func (o *oggVorbisParser) parseOGGVorbisCommentHeader() error {
 	// Verify header type (3: Vorbis Comment)
 	if headerType != byte(3) {
 		return TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  o.Format(),
 			Details: "invalid header type for Vorbis comment header",
 		}
@@ -410,11 +1283,19 @@ func (o *oggVorbisParser) parseOGGVorbisCommentHeader() error {
 		return err
 	}
 
-	// Read vendor string, store as encoder
-	if _, err := o.reader.Read(o.buffer[:o.ui32]); err != nil {
+	// Ensure vendor string fits in the packet, before growing shared buffer if it is too long for it
+	if int64(o.ui32) > int64(packetReader.Len()) {
+		return o.truncatedComments()
+	}
+	if int(o.ui32) > len(o.buffer) {
+		o.buffer = make([]byte, o.ui32)
+	}
+
+	// Read vendor string
+	if _, err := io.ReadFull(o.reader, o.buffer[:o.ui32]); err != nil {
 		return err
 	}
-	o.encoder = string(o.buffer[:o.ui32])
+	o.vendor = string(o.buffer[:o.ui32])
 
 	// Read comment length (new allocation for use with loop counter)
 	var commentLength uint32
@@ -430,61 +1311,218 @@ func (o *oggVorbisParser) parseOGGVorbisCommentHeader() error {
 			return err
 		}
 
+		// Ensure tag string fits in the packet, before growing shared buffer if it is too long for it
+		if int64(o.ui32) > int64(packetReader.Len()) {
+			return o.truncatedComments()
+		}
+		if int(o.ui32) > len(o.buffer) {
+			o.buffer = make([]byte, o.ui32)
+		}
+
 		// Read tag string
-		n, err := o.reader.Read(o.buffer[:o.ui32])
+		n, err := io.ReadFull(o.reader, o.buffer[:o.ui32])
 		if err != nil {
 			return err
 		}
 
 		// Split tag name and data, store in map
-		pair := strings.Split(string(o.buffer[:n]), "=")
-		tagMap[strings.ToUpper(pair[0])] = pair[1]
+		name, tag, ok := parseVorbisComment(string(o.buffer[:n]), o.options)
+		if !ok {
+			// Malformed comments are only an error with strict options
+			if o.options.strict() {
+				return TagError{
+					Err:     ErrInvalidStream,
+					Format:  o.Format(),
+					Details: "malformed Vorbis comment in comment header",
+				}
+			}
+
+			continue
+		}
+		tagMap[name] = tag
 	}
 
-	// Seek one byte forward to prepare for the setup header
-	if _, err := o.reader.Seek(1, 1); err != nil {
-		return err
+	// The comment header packet ends with a framing flag.  The packet was reassembled using the
+	// segment table, so the setup header which follows it need not be located.  Ensure framing
+	// flag is set, if requested.
+	if o.options.checkFraming() {
+		if _, err := io.ReadFull(o.reader, o.buffer[:1]); err != nil || o.buffer[0]&1 == 0 {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  o.Format(),
+				Details: "Vorbis comment header framing flag is not set",
+			}
+		}
 	}
 
 	// Store tags
+	o.options.normalizeTags(tagMap)
 	o.tags = tagMap
 	return nil
 }
 
-// parseOGGVorbisDuration reads out the rest of the file to find the last Ogg Vorbis page header, which
-// contains information needed to parse the file duration
-func (o *oggVorbisParser) parseOGGVorbisDuration() error {
-	// Seek as far forward as sanely possible so we don't need to read tons of excess data
-	// For now, a value of 4096 bytes before the end appears to work, and should give a bit
-	// of wiggle-room without causing us to read the entire file
-	if _, err := o.reader.Seek(-4096, 2); err != nil {
-		return err
+// truncatedComments generates an invalid stream error for a comment header which ends before all
+// of its fields
+func (o OggVorbisParser) truncatedComments() error {
+	return TagError{
+		Err:     ErrInvalidStream,
+		Format:  o.Format(),
+		Details: "Vorbis comment header extends past end of packet",
+	}
+}
+
+// parseOGGVorbisDuration scans backward from the end of the file to find the last Ogg Vorbis page
+// header, which contains information needed to parse the file duration.  An error is returned if no
+// final page is found, or if the stream contains no audio samples, such as when a stream is truncated
+// after its headers, rather than silently reporting a zero duration.
+func (o *OggVorbisParser) parseOGGVorbisDuration() error {
+	// Ensure the shared buffer can hold a chunk, along with enough trailing bytes to hold a page
+	// header which begins at the end of the chunk
+	if len(o.buffer) < oggDurationChunkSize+oggPageHeaderSize-1 {
+		o.buffer = make([]byte, oggDurationChunkSize+oggPageHeaderSize-1)
 	}
 
-	// Read the rest of the file to find the last page header
-	vorbisFile, err := ioutil.ReadAll(o.reader)
+	granule, serial, found, err := oggFinalPage(o.reader, o.endPos, o.buffer, o.options)
 	if err != nil {
 		return err
 	}
 
-	// Find the index of the last OGGVorbis page header
-	index := bytes.LastIndex(vorbisFile, oggMagicNumber)
-	if index == -1 {
+	if !found {
 		return TagError{
-			Err:     errInvalidStream,
+			Err:     ErrInvalidStream,
 			Format:  o.Format(),
 			Details: "could not detect final Ogg page header",
 		}
 	}
+	o.finalGranule = granule
 
-	// Read using the in-memory bytes to grab the last page header information
-	o.reader = bytes.NewReader(vorbisFile[index:])
-	pageHeader, err := o.parseOGGVorbisPageHeader(false)
-	if err != nil {
-		return nil
+	// If the final page belongs to a different logical bitstream than the first page, the stream
+	// is chained, and the duration of each logical bitstream must be summed
+	if serial != o.serial {
+		return o.parseOGGVorbisChainedDuration()
+	}
+
+	if granule == 0 {
+		return o.noSamplesError()
 	}
 
 	// Calculate duration using last granule position divided by sample rate
-	o.duration = time.Duration(pageHeader.GranulePosition/uint64(o.idHeader.SampleRate)) * time.Second
+	o.sampleCount = granule
+	o.duration = samplesDuration(granule, uint64(o.idHeader.SampleRate))
+	o.streams = 1
+	return nil
+}
+
+// parseOGGVorbisChainedDuration reads every Ogg page header in a chained stream, which contains multiple
+// logical bitstreams one after another, and sums the durations of each Vorbis logical bitstream using
+// its final granule position and sample rate.  Logical bitstreams which do not contain Vorbis audio are
+// ignored.
+// BUG(mdlayher): Ogg Vorbis: the durations of chained logical bitstreams are assumed to begin at granule position 0
+func (o *OggVorbisParser) parseOGGVorbisChainedDuration() error {
+	// The sample rate and final granule position of each Vorbis logical bitstream, by serial number
+	type logicalStream struct {
+		sampleRate uint32
+		granule    uint64
+	}
+	streams := map[uint32]*logicalStream{}
+	var serials []uint32
+
+	// Begin scanning at the first page, skipping any data which precedes the stream
+	header := o.buffer[:oggPageHeaderSize+255]
+	for pos := o.start; ; {
+		// Stop if parsing has been canceled
+		if err := o.options.err(); err != nil {
+			return err
+		}
+
+		if _, err := o.reader.Seek(pos, 0); err != nil {
+			return err
+		}
+
+		// Read each page header in full, stopping at the end of the stream or a truncated page
+		if _, err := io.ReadFull(o.reader, header[:oggPageHeaderSize]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+
+			return err
+		}
+
+		if !bytes.Equal(header[:len(oggMagicNumber)], oggMagicNumber) {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  o.Format(),
+				Details: "unrecognized capture pattern in Ogg page header",
+			}
+		}
+
+		segments := int(header[oggPageHeaderSize-1])
+		if _, err := io.ReadFull(o.reader, header[oggPageHeaderSize:oggPageHeaderSize+segments]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+
+			return err
+		}
+
+		size := 0
+		for _, l := range header[oggPageHeaderSize : oggPageHeaderSize+segments] {
+			size += int(l)
+		}
+		pos += int64(oggPageHeaderSize + segments + size)
+
+		serial := binary.LittleEndian.Uint32(header[14:18])
+		granule := binary.LittleEndian.Uint64(header[6:14])
+
+		// The first page of each logical bitstream begins with its identification header, which
+		// contains the sample rate in the case of a Vorbis logical bitstream
+		if header[5]&oggPageBeginningOfStream != 0 {
+			id := make([]byte, 16)
+			if size < len(id) {
+				continue
+			}
+			if _, err := io.ReadFull(o.reader, id); err != nil {
+				return err
+			}
+
+			if id[0] != 1 || !bytes.Equal(id[1:7], oggVorbisVorbisWord) {
+				continue
+			}
+
+			streams[serial] = &logicalStream{sampleRate: binary.LittleEndian.Uint32(id[12:16])}
+			serials = append(serials, serial)
+			continue
+		}
+
+		// Track the last granule position of each Vorbis logical bitstream
+		if s, ok := streams[serial]; ok && granule != ^uint64(0) {
+			s.granule = granule
+		}
+	}
+
+	// Sum the samples and durations of each logical bitstream
+	o.sampleCount = 0
+	o.duration = 0
+	for _, serial := range serials {
+		s := streams[serial]
+		o.sampleCount += s.granule
+		o.duration += samplesDuration(s.granule, uint64(s.sampleRate))
+	}
+	o.streams = len(serials)
+
+	if o.sampleCount == 0 {
+		return o.noSamplesError()
+	}
+
 	return nil
 }
+
+// noSamplesError returns an error which indicates that the duration of a stream could not be
+// determined, because its pages contain no audio samples
+func (o OggVorbisParser) noSamplesError() error {
+	return TagError{
+		Err:     ErrInvalidStream,
+		Format:  o.Format(),
+		Details: "Ogg pages contain no audio samples, stream may be truncated",
+	}
+}
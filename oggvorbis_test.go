@@ -2,8 +2,11 @@ package taggolib
 
 import (
 	"bytes"
+	"encoding/binary"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestOGGVorbis verifies that all oggParser methods work properly
@@ -15,7 +18,7 @@ func TestOGGVorbis(t *testing.T) {
 	}
 
 	// Verify that we actually got a Ogg Vorbis parser
-	if reflect.TypeOf(ogg) != reflect.TypeOf(&oggVorbisParser{}) {
+	if reflect.TypeOf(ogg) != reflect.TypeOf(&OggVorbisParser{}) {
 		t.Fatalf("unexpected Ogg Vorbis type: %v", reflect.TypeOf(ogg))
 	}
 
@@ -46,6 +49,11 @@ func TestOGGVorbis(t *testing.T) {
 		t.Fatalf("mismatched property Bitrate: %v", ogg.Bitrate())
 	}
 
+	// BPM
+	if ogg.BPM() != 0 {
+		t.Fatalf("mismatched tag BPM: %v", ogg.BPM())
+	}
+
 	// Channels
 	if ogg.Channels() != 2 {
 		t.Fatalf("mismatched property Channels: %v", ogg.Channels())
@@ -56,6 +64,11 @@ func TestOGGVorbis(t *testing.T) {
 		t.Fatalf("mismatched tag Comment: %v", ogg.Comment())
 	}
 
+	// Composer
+	if ogg.Composer() != "" {
+		t.Fatalf("mismatched tag Composer: %v", ogg.Composer())
+	}
+
 	// Date
 	if ogg.Date() != "2014-01-01" {
 		t.Fatalf("mismatched tag Date: %v", ogg.Date())
@@ -71,6 +84,11 @@ func TestOGGVorbis(t *testing.T) {
 		t.Fatalf("mismatched property Duration: %v", ogg.Duration().Seconds())
 	}
 
+	// EncodedBy
+	if ogg.EncodedBy() != "" {
+		t.Fatalf("mismatched tag EncodedBy: %v", ogg.EncodedBy())
+	}
+
 	// Encoder
 	if ogg.Encoder() != "Lavf53.21.1" {
 		t.Fatalf("mismatched property Encoder: %v", ogg.Encoder())
@@ -86,6 +104,16 @@ func TestOGGVorbis(t *testing.T) {
 		t.Fatalf("mismatched tag Genre: %v", ogg.Genre())
 	}
 
+	// HasPicture
+	if ogg.HasPicture() != false {
+		t.Fatalf("mismatched property HasPicture: %v", ogg.HasPicture())
+	}
+
+	// Lyrics
+	if ogg.Lyrics() != "" {
+		t.Fatalf("mismatched tag Lyrics: %v", ogg.Lyrics())
+	}
+
 	// SampleRate
 	if ogg.SampleRate() != 44100 {
 		t.Fatalf("mismatched property SampleRate: %v", ogg.SampleRate())
@@ -106,6 +134,36 @@ func TestOGGVorbis(t *testing.T) {
 		t.Fatalf("mismatched tag TrackNumber: %v", ogg.TrackNumber())
 	}
 
+	// IsVBR
+	if !ogg.(*OggVorbisParser).IsVBR() {
+		t.Fatalf("mismatched property IsVBR: %v", ogg.(*OggVorbisParser).IsVBR())
+	}
+
+	// MaxBitrate
+	if ogg.(*OggVorbisParser).MaxBitrate() != 0 {
+		t.Fatalf("mismatched property MaxBitrate: %v", ogg.(*OggVorbisParser).MaxBitrate())
+	}
+
+	// MinBitrate
+	if ogg.(*OggVorbisParser).MinBitrate() != 0 {
+		t.Fatalf("mismatched property MinBitrate: %v", ogg.(*OggVorbisParser).MinBitrate())
+	}
+
+	// TotalDiscs
+	if ogg.TotalDiscs() != 0 {
+		t.Fatalf("mismatched tag TotalDiscs: %v", ogg.TotalDiscs())
+	}
+
+	// TotalTracks
+	if ogg.TotalTracks() != 0 {
+		t.Fatalf("mismatched tag TotalTracks: %v", ogg.TotalTracks())
+	}
+
+	// Vendor
+	if ogg.(*OggVorbisParser).Vendor() != "Lavf53.21.1" {
+		t.Fatalf("mismatched property Vendor: %v", ogg.(*OggVorbisParser).Vendor())
+	}
+
 	// Check a few raw tags
 
 	if ogg.Tag("ARTIST") != "Artist" {
@@ -120,8 +178,464 @@ func TestOGGVorbis(t *testing.T) {
 		t.Fatalf("unexpected raw tag TITLE: %v", ogg.Tag("TITLE"))
 	}
 
+	// Tag names are case-insensitive
+	if ogg.Tag("title") != "Title" {
+		t.Fatalf("unexpected raw tag title: %v", ogg.Tag("title"))
+	}
+
 	// Check a non-existant tag
 	if ogg.Tag("NOTEXISTS") != "" {
 		t.Fatalf("unexpected raw tag NOTEXISTS: %v", ogg.Tag("NOTEXISTS"))
 	}
 }
+
+// TestOGGVorbisZeroSampleRate verifies that an Ogg Vorbis stream with a zero sample rate
+// in its identification header is rejected as an invalid stream
+func TestOGGVorbisZeroSampleRate(t *testing.T) {
+	// Copy the test file, and zero out the sample rate in the identification header
+	stream := make([]byte, len(oggVorbisFile))
+	copy(stream, oggVorbisFile)
+
+	index := bytes.Index(stream, oggVorbisVorbisWord) + len(oggVorbisVorbisWord) + 5
+	copy(stream[index:index+4], []byte{0, 0, 0, 0})
+
+	// Attempt to parse the stream
+	if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestOGGVorbisBitrates verifies that minimum, maximum, and nominal bitrates are properly
+// used to determine if a stream is variable bitrate, and its bitrate management mode
+func TestOGGVorbisBitrates(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		min  uint32
+		nom  uint32
+		max  uint32
+		vbr  bool
+		mode BitrateMode
+	}{
+		// Quality-based VBR, minimum and maximum unset
+		{0xffffffff, 192000, 0xffffffff, true, BitrateModeVBR},
+		// Managed bitrate with bounds
+		{128000, 192000, 256000, true, BitrateModeABR},
+		// Managed bitrate with only a maximum bound
+		{0xffffffff, 192000, 256000, true, BitrateModeABR},
+		// Hard CBR
+		{192000, 192000, 192000, false, BitrateModeCBR},
+		// Nothing set
+		{0, 0, 0, true, BitrateModeVBR},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		ogg := OggVorbisParser{idHeader: &OggVorbisIDHeader{
+			MinBitrate: test.min,
+			NomBitrate: test.nom,
+			MaxBitrate: test.max,
+		}}
+
+		if ogg.IsVBR() != test.vbr {
+			t.Fatalf("mismatched IsVBR for %d/%d/%d: %v", test.min, test.nom, test.max, ogg.IsVBR())
+		}
+
+		if mode := ogg.BitrateMode(); mode != test.mode {
+			t.Fatalf("mismatched BitrateMode for %d/%d/%d: %v", test.min, test.nom, test.max, mode)
+		}
+	}
+}
+
+// TestOGGVorbisAverageBitrate verifies that the average bitrate is calculated from the stream size
+// and duration when no nominal bitrate is set
+func TestOGGVorbisAverageBitrate(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		nom      uint32
+		endPos   int64
+		duration time.Duration
+		bitrate  int
+	}{
+		// Nominal bitrate is used when set
+		{192000, 100000, 5 * time.Second, 192},
+		// Average bitrate is calculated when nominal bitrate is unset
+		{0, 100000, 5 * time.Second, 160},
+		{0xffffffff, 100000, 5 * time.Second, 160},
+		// Zero duration does not cause a panic
+		{0, 100000, 0, 0},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		ogg := OggVorbisParser{
+			duration: test.duration,
+			endPos:   test.endPos,
+			idHeader: &OggVorbisIDHeader{NomBitrate: test.nom},
+		}
+
+		if ogg.Bitrate() != test.bitrate {
+			t.Fatalf("mismatched property Bitrate: %v != %v", ogg.Bitrate(), test.bitrate)
+		}
+	}
+}
+
+// TestOGGVorbisSmallStream verifies that Ogg Vorbis streams smaller than the duration search
+// window are properly parsed
+func TestOGGVorbisSmallStream(t *testing.T) {
+	// Generate a small stream containing the identification and comment headers from the test file,
+	// followed by only the header of its final page, which contains the final granule position
+	commentEnd := 2919
+	lastPage := bytes.LastIndex(oggVorbisFile, oggMagicNumber)
+	stream := append(append([]byte{}, oggVorbisFile[:commentEnd]...), oggVorbisFile[lastPage:lastPage+27+22]...)
+
+	ogg, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ogg.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", ogg.Artist())
+	}
+
+	if int(ogg.Duration().Seconds()) != 5 {
+		t.Fatalf("mismatched property Duration: %v", ogg.Duration().Seconds())
+	}
+}
+
+// TestOGGVorbisLargeFinalPage verifies that the duration of an Ogg Vorbis stream is found when its
+// final page header is further from the end of the stream than a single read chunk
+func TestOGGVorbisLargeFinalPage(t *testing.T) {
+	// Generate a stream containing the identification and comment headers from the test file, followed
+	// by a final page which copies the header of the test file's final page, but contains 32 segments
+	// of 255 bytes each.  The page data contains a capture pattern with an invalid version, which
+	// must be skipped.
+	commentEnd := 2919
+	lastPage := bytes.LastIndex(oggVorbisFile, oggMagicNumber)
+	stream := append([]byte{}, oggVorbisFile[:commentEnd]...)
+	stream = append(stream, oggVorbisFile[lastPage:lastPage+26]...)
+	stream = append(stream, 32)
+	stream = append(stream, bytes.Repeat([]byte{255}, 32)...)
+
+	data := make([]byte, 32*255)
+	copy(data[len(data)-100:], append(append([]byte{}, oggMagicNumber...), 1, 4))
+	stream = append(stream, data...)
+
+	ogg, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if int(ogg.Duration().Seconds()) != 5 {
+		t.Fatalf("mismatched property Duration: %v", ogg.Duration().Seconds())
+	}
+
+	// A stream with no page header within the maximum size of a page from its end must be rejected
+	if _, err := New(bytes.NewReader(append(oggVorbisFile[:commentEnd:commentEnd], make([]byte, oggMaxPageSize)...))); err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+}
+
+// TestOGGVorbisTruncated verifies that an Ogg Vorbis stream which is truncated after its headers
+// is rejected, rather than reporting a zero duration
+func TestOGGVorbisTruncated(t *testing.T) {
+	// The test file's headers end in the page which follows the comment header
+	end := bytes.Index(oggVorbisFile[2919:], oggMagicNumber) + 2919
+
+	if _, err := New(bytes.NewReader(oggVorbisFile[:end])); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestOGGVorbisChained verifies that the durations of chained Ogg Vorbis logical bitstreams are summed
+func TestOGGVorbisChained(t *testing.T) {
+	// Generate a chained stream containing the test file twice, with a different serial number in
+	// each page of the second logical bitstream
+	second := append([]byte{}, oggVorbisFile...)
+	for i := 0; i < len(second); {
+		second[i+14] ^= 0xff
+		segments := int(second[i+26])
+		size := 0
+		for _, l := range second[i+27 : i+27+segments] {
+			size += int(l)
+		}
+		i += 27 + segments + size
+	}
+
+	chained := append(append([]byte{}, oggVorbisFile...), second...)
+
+	var tests = []struct {
+		leading []byte
+		stream  []byte
+		streams int
+		samples uint64
+	}{
+		{nil, oggVorbisFile, 1, 220544},
+		{nil, chained, 2, 2 * 220544},
+		// Data which precedes the first page, such as an ID3v2 tag, is skipped
+		{[]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), chained, 2, 2 * 220544},
+	}
+
+	for i, test := range tests {
+		reader := bytes.NewReader(append(append([]byte{}, test.leading...), test.stream...))
+		if _, err := reader.Seek(int64(len(test.leading)), 0); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		ogg, err := New(reader)
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if streams := ogg.(*OggVorbisParser).Streams(); streams != test.streams {
+			t.Fatalf("[%02d] mismatched Streams: %v != %v", i, streams, test.streams)
+		}
+
+		if ogg.SampleCount() != test.samples {
+			t.Fatalf("[%02d] mismatched property SampleCount: %v != %v", i, ogg.SampleCount(), test.samples)
+		}
+
+		if duration := samplesDuration(test.samples, 44100); ogg.Duration() != duration {
+			t.Fatalf("[%02d] mismatched property Duration: %v != %v", i, ogg.Duration(), duration)
+		}
+	}
+}
+
+// TestOGGVorbisVerifyChecksums verifies that VerifyChecksums detects corrupted and truncated Ogg pages
+func TestOGGVorbisVerifyChecksums(t *testing.T) {
+	lastPage := bytes.LastIndex(oggVorbisFile, oggMagicNumber)
+
+	var tests = []struct {
+		modify func(stream []byte) []byte
+		valid  bool
+	}{
+		// Unmodified file
+		{func(stream []byte) []byte { return stream }, true},
+		// Corrupted tag in comment header page
+		{func(stream []byte) []byte { stream[200] ^= 0xff; return stream }, false},
+		// Corrupted checksum in final page
+		{func(stream []byte) []byte { stream[lastPage+22] ^= 0xff; return stream }, false},
+		// Truncated final page
+		{func(stream []byte) []byte { return stream[:len(stream)-1] }, false},
+	}
+
+	for i, test := range tests {
+		stream := test.modify(append([]byte{}, oggVorbisFile...))
+
+		ogg, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		err = ogg.(*OggVorbisParser).VerifyChecksums()
+		if test.valid && err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("[%02d] expected error, but got nil", i)
+		}
+	}
+}
+
+// TestOGGVorbisFramingFlag verifies that an unset framing flag is only rejected with strict options
+func TestOGGVorbisFramingFlag(t *testing.T) {
+	// The unmodified test file must be accepted with strict options
+	if _, err := NewWithOptions(bytes.NewReader(oggVorbisFile), Options{Strictness: Strict}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Copy the test file, and clear the framing flag in the identification header
+	stream := make([]byte, len(oggVorbisFile))
+	copy(stream, oggVorbisFile)
+
+	index := bytes.Index(stream, oggVorbisVorbisWord) + len(oggVorbisVorbisWord) + 22
+	stream[index] = 0
+
+	if _, err := New(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := NewWithOptions(bytes.NewReader(stream), Options{Strictness: Strict}); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+
+	if _, err := NewWithOptions(bytes.NewReader(stream), Options{CheckFraming: true}); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+
+	// Copy the test file, and clear the framing flag which directly follows the final comment in
+	// the comment header
+	copy(stream, oggVorbisFile)
+	stream[bytes.Index(stream, []byte("TRACKNUMBER=1"))+len("TRACKNUMBER=1")] = 0
+
+	if _, err := New(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := NewWithOptions(bytes.NewReader(stream), Options{CheckFraming: true}); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestOGGVorbisCommentLengths verifies that vendor and tag string lengths which extend past the end
+// of the comment header packet are rejected, rather than used to allocate a buffer
+func TestOGGVorbisCommentLengths(t *testing.T) {
+	header := bytes.Index(oggVorbisFile, append([]byte{3}, oggVorbisVorbisWord...)) + 1 + len(oggVorbisVorbisWord)
+	vendor := int(binary.LittleEndian.Uint32(oggVorbisFile[header : header+4]))
+
+	// Offsets of the vendor length, and the length of the first tag string
+	for i, offset := range []int{header, header + 4 + vendor + 4} {
+		stream := make([]byte, len(oggVorbisFile))
+		copy(stream, oggVorbisFile)
+		binary.LittleEndian.PutUint32(stream[offset:offset+4], 0xffffffff)
+
+		if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+	}
+}
+
+// oggVorbisPage generates an Ogg page with the specified header type and packet data, laced
+// according to the specified segment table
+func oggVorbisPage(headerType byte, sequence uint32, segments []byte, data []byte) []byte {
+	page := make([]byte, 27)
+	copy(page, oggMagicNumber)
+	page[5] = headerType
+	binary.LittleEndian.PutUint32(page[18:22], sequence)
+	page[26] = byte(len(segments))
+
+	return append(append(page, segments...), data...)
+}
+
+// TestOGGVorbisMultiPageComments verifies that a comment header which spans multiple pages
+// is reassembled and parsed properly
+func TestOGGVorbisMultiPageComments(t *testing.T) {
+	// Generate a comment header packet with a tag long enough to span pages
+	comments := [][]byte{[]byte("ARTIST=Artist"), []byte("COMMENT=" + strings.Repeat("a", 900))}
+	vendor := []byte("taggolib")
+
+	packet := append([]byte{3}, oggVorbisVorbisWord...)
+	packet = append(packet, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(packet[len(packet)-4:], uint32(len(vendor)))
+	packet = append(packet, vendor...)
+	packet = append(packet, byte(len(comments)), 0, 0, 0)
+	for _, c := range comments {
+		length := make([]byte, 4)
+		binary.LittleEndian.PutUint32(length, uint32(len(c)))
+		packet = append(append(packet, length...), c...)
+	}
+	packet = append(packet, 1)
+
+	// Split the packet into two pages: two full segments, followed by the remainder
+	stream := append([]byte{}, oggVorbisFile[:bytes.Index(oggVorbisFile[4:], oggMagicNumber)+4]...)
+	stream = append(stream, oggVorbisPage(0, 1, []byte{255, 255}, packet[:510])...)
+
+	remainder := packet[510:]
+	stream = append(stream, oggVorbisPage(oggPageContinued, 2, []byte{255, byte(len(remainder) - 255)}, remainder)...)
+
+	// Append the final page of the test file, which contains audio samples
+	stream = append(stream, oggVorbisFile[bytes.LastIndex(oggVorbisFile, oggMagicNumber):]...)
+
+	ogg, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ogg.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", ogg.Artist())
+	}
+
+	if ogg.Comment() != strings.Repeat("a", 900) {
+		t.Fatalf("mismatched tag Comment: %v", ogg.Comment())
+	}
+}
+
+// TestOGGVorbisSave verifies that tags set on an Ogg Vorbis parser are saved to a copy of the stream,
+// with valid page checksums, while the audio data is preserved
+func TestOGGVorbisSave(t *testing.T) {
+	var tests = []struct {
+		artist string
+		pages  int
+	}{
+		// Comment header remains the same number of pages
+		{"New Artist", 0},
+		// Comment header grows by multiple pages, so audio pages must be renumbered
+		{strings.Repeat("A", 2*oggMaxPageSize), 2},
+	}
+
+	for i, test := range tests {
+		ogg, err := New(bytes.NewReader(oggVorbisFile))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		ogg.(TagWriter).SetTag("Artist", test.artist)
+		ogg.(TagWriter).SetTag("ALBUM", "")
+
+		buf := bytes.NewBuffer(nil)
+		if err := ogg.(TagWriter).Save(buf); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		saved, err := New(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if saved.Artist() != test.artist {
+			t.Fatalf("[%02d] mismatched tag Artist", i)
+		}
+		if saved.Album() != "" {
+			t.Fatalf("[%02d] unexpected tag Album: %v", i, saved.Album())
+		}
+		if saved.Title() != ogg.Title() {
+			t.Fatalf("[%02d] mismatched tag Title: %v != %v", i, saved.Title(), ogg.Title())
+		}
+		if saved.SampleCount() != ogg.SampleCount() {
+			t.Fatalf("[%02d] mismatched property SampleCount: %v != %v", i, saved.SampleCount(), ogg.SampleCount())
+		}
+
+		if err := saved.(*OggVorbisParser).VerifyChecksums(); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		// The final page must be renumbered by the number of pages added to the comment header
+		lastPage := bytes.LastIndex(oggVorbisFile, oggMagicNumber)
+		savedLastPage := bytes.LastIndex(buf.Bytes(), oggMagicNumber)
+		sequence := binary.LittleEndian.Uint32(oggVorbisFile[lastPage+18:])
+		savedSequence := binary.LittleEndian.Uint32(buf.Bytes()[savedLastPage+18:])
+		if int(savedSequence-sequence) != test.pages {
+			t.Fatalf("[%02d] mismatched final page sequence: %v != %v", i, savedSequence, sequence)
+		}
+
+		// The data of the final page must be unchanged
+		if !bytes.Equal(buf.Bytes()[savedLastPage+26:], oggVorbisFile[lastPage+26:]) {
+			t.Fatalf("[%02d] final page data was modified", i)
+		}
+	}
+}
+
+// TestOGGVorbisFinalGranule verifies that the granule position of the final page is exposed, and
+// that it is not set when only tags are requested
+func TestOGGVorbisFinalGranule(t *testing.T) {
+	parser, err := New(bytes.NewReader(oggVorbisFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ogg := parser.(*OggVorbisParser)
+	if ogg.FinalGranule() != 220544 {
+		t.Fatalf("mismatched FinalGranule: %v", ogg.FinalGranule())
+	}
+	if ogg.FinalGranule() != ogg.SampleCount() {
+		t.Fatalf("mismatched FinalGranule and SampleCount: %v != %v", ogg.FinalGranule(), ogg.SampleCount())
+	}
+
+	parser, err = NewWithOptions(bytes.NewReader(oggVorbisFile), Options{TagsOnly: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if granule := parser.(*OggVorbisParser).FinalGranule(); granule != 0 {
+		t.Fatalf("unexpected FinalGranule: %v", granule)
+	}
+}
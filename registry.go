@@ -0,0 +1,97 @@
+package taggolib
+
+import (
+	"io"
+	"sync"
+)
+
+// formatEntry represents an audio format which New can detect and parse, identified by a magic
+// number at an offset from the start of a stream.  If a mask is set, it is applied to the stream's
+// bytes before they are compared to the magic number.
+type formatEntry struct {
+	format    Format
+	magic     []byte
+	mask      []byte
+	offset    int
+	newParser func(reader io.ReadSeeker, options Options) (Parser, error)
+}
+
+// matches returns whether or not the input bytes from the start of a stream match this format
+func (f formatEntry) matches(b []byte) bool {
+	end := f.offset + len(f.magic)
+	if end > len(b) {
+		return false
+	}
+
+	for i, m := range f.magic {
+		c := b[f.offset+i]
+		if f.mask != nil {
+			c &= f.mask[i]
+		}
+
+		if c != m {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unsupportedFormats is the list of audio formats which New can detect, but cannot parse, so that
+// a more specific error may be returned for streams of those formats
+var unsupportedFormats = []struct {
+	name string
+	formatEntry
+}{
+	{"AIFF", formatEntry{magic: []byte("FORM"), offset: 0}},
+	{"MP4", formatEntry{magic: []byte("ftyp"), offset: 4}},
+	{"Musepack", formatEntry{magic: []byte("MPCK"), offset: 0}},
+	{"Ogg", formatEntry{magic: []byte("OggS"), offset: 0}},
+	{"RIFF", formatEntry{magic: []byte("RIFF"), offset: 0}},
+	{"WavPack", formatEntry{magic: []byte("wvpk"), offset: 0}},
+}
+
+var (
+	// formats is the ordered list of audio formats checked by New, protected by formatsMu
+	formats   []formatEntry
+	formatsMu sync.RWMutex
+)
+
+// RegisterFormat registers an audio format with New, so that New can detect and parse streams of
+// that format.  A stream matches the format when magic appears at offset bytes from the start of
+// the stream.  Formats are checked in the order they are registered, following taggolib's built-in
+// formats, and the first matching format is used.  When a stream matches, factory is invoked with
+// the stream positioned at its start, so the parser may read and verify the magic number itself.
+func RegisterFormat(magic []byte, offset int, factory func(io.ReadSeeker) (Parser, error)) {
+	registerFormat(FormatUnknown, magic, offset, func(reader io.ReadSeeker, _ Options) (Parser, error) {
+		return factory(reader)
+	})
+}
+
+// registerFormat registers an audio format with New, using a parser constructor which accepts options
+func registerFormat(format Format, magic []byte, offset int, newParser func(io.ReadSeeker, Options) (Parser, error)) {
+	registerFormatMask(format, magic, nil, offset, newParser)
+}
+
+// registerFormatMask registers an audio format with New in the same way as registerFormat, but applies
+// a mask to the stream's bytes before comparing them to the magic number
+func registerFormatMask(format Format, magic []byte, mask []byte, offset int, newParser func(io.ReadSeeker, Options) (Parser, error)) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	formats = append(formats, formatEntry{
+		format:    format,
+		magic:     append([]byte(nil), magic...),
+		mask:      append([]byte(nil), mask...),
+		offset:    offset,
+		newParser: newParser,
+	})
+}
+
+// registeredFormats returns a copy of the ordered list of registered audio formats
+func registeredFormats() []formatEntry {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	return append([]formatEntry(nil), formats...)
+}
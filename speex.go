@@ -0,0 +1,678 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// speexHeaderSize is the size of a Speex header, which is the first packet of a Speex stream
+	speexHeaderSize = 80
+)
+
+var (
+	// speexMagicNumber is the signature which begins the header of a Speex stream
+	speexMagicNumber = []byte("Speex   ")
+)
+
+// speexModeNames maps Speex mode numbers to their names
+var speexModeNames = map[int32]string{
+	0: "narrowband",
+	1: "wideband",
+	2: "ultra-wideband",
+}
+
+// SpeexParser represents a Speex audio metadata tag parser.  A *SpeexParser is returned by New for
+// Speex streams, and may be type-asserted to access methods specific to the format.
+type SpeexParser struct {
+	duration     time.Duration
+	endPos       int64
+	finalGranule uint64
+	header       *SpeexHeader
+	options      Options
+	reader       io.ReadSeeker
+	sampleCount  uint64
+	serial       uint32
+	tags         map[string]string
+	vendor       string
+
+	// Shared buffer to prevent unneeded allocations
+	buffer []byte
+}
+
+// init registers the Speex format with New, for Ogg streams beginning with a Speex header
+func init() {
+	magic, mask := oggCodecMagic(speexMagicNumber)
+	registerFormatMask(FormatSpeex, magic, mask, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newSpeexParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
+// Album returns the Album tag for this stream
+func (s SpeexParser) Album() string {
+	return s.tags[tagAlbum]
+}
+
+// AlbumArtist returns the AlbumArtist tag for this stream
+func (s SpeexParser) AlbumArtist() string {
+	return s.tags[tagAlbumArtist]
+}
+
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (s SpeexParser) AlbumArtistSort() string {
+	return s.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (s SpeexParser) AlbumSort() string {
+	return s.tags[tagAlbumSort]
+}
+
+// Artist returns the Artist tag for this stream
+func (s SpeexParser) Artist() string {
+	return s.tags[tagArtist]
+}
+
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (s SpeexParser) ArtistSort() string {
+	return s.tags[tagArtistSort]
+}
+
+// BitDepth returns the bits-per-sample of this stream
+func (s SpeexParser) BitDepth() int {
+	// Speex should always provide 16 bit depth
+	return 16
+}
+
+// Bitrate calculates the audio bitrate for this stream
+func (s SpeexParser) Bitrate() int {
+	// Use the bitrate from the header, which is -1 if it is not known
+	if s.header.Bitrate > 0 {
+		return int(s.header.Bitrate) / 1000
+	}
+
+	// Calculate the average bitrate using the stream size and duration, checking for zero values
+	// to prevent a division-by-zero panic
+	seconds := s.duration.Seconds()
+	if s.endPos == 0 || seconds == 0 {
+		return 0
+	}
+
+	return int(float64(s.endPos*8) / seconds / 1000)
+}
+
+// BPM returns the BPM (beats per minute) tag for this stream
+func (s SpeexParser) BPM() int {
+	bpm, err := strconv.Atoi(s.tags[tagBPM])
+	if err != nil {
+		return 0
+	}
+
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, such as "mono", "stereo", or "5.1"
+func (s SpeexParser) ChannelLayout() string {
+	return channelLayout(s.Channels())
+}
+
+// Channels returns the number of channels for this stream
+func (s SpeexParser) Channels() int {
+	return int(s.header.Channels)
+}
+
+// Comment returns the Comment tag for this stream
+func (s SpeexParser) Comment() string {
+	return s.tags[tagComment]
+}
+
+// Composer returns the Composer tag for this stream
+func (s SpeexParser) Composer() string {
+	return s.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (s SpeexParser) Conductor() string {
+	return s.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (s SpeexParser) ContentType() string {
+	return s.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (s SpeexParser) Copyright() string {
+	return s.tags[tagCopyright]
+}
+
+// Date returns the Date tag for this stream
+func (s SpeexParser) Date() string {
+	return s.tags[tagDate]
+}
+
+// DiscNumber returns the DiscNumber tag for this stream
+func (s SpeexParser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(s.tags[tagDiscNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return disc
+}
+
+// Duration returns the time duration for this stream
+func (s SpeexParser) Duration() time.Duration {
+	return s.duration
+}
+
+// EncodedBy returns the EncodedBy tag for this stream
+func (s SpeexParser) EncodedBy() string {
+	return s.tags[tagEncodedBy]
+}
+
+// Encoder returns the encoder for this stream, preferring the ENCODER tag over the vendor string
+func (s SpeexParser) Encoder() string {
+	if encoder := s.tags[tagEncoder]; encoder != "" {
+		return encoder
+	}
+
+	return s.vendor
+}
+
+// FinalGranule returns the granule position of the final Ogg page of this stream, which is the
+// position of its last sample at the sample rate of the stream.  If the final page was not read,
+// because only tags were requested or only a prefix of the stream is available, FinalGranule
+// returns 0.
+func (s SpeexParser) FinalGranule() uint64 {
+	return s.finalGranule
+}
+
+// Format returns the name of the Speex format
+func (s SpeexParser) Format() string {
+	return s.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (s SpeexParser) FormatID() Format {
+	return FormatSpeex
+}
+
+// Genre returns the Genre tag for this stream
+func (s SpeexParser) Genre() string {
+	return s.tags[tagGenre]
+}
+
+// Grouping returns the Grouping (content group) tag for this stream
+func (s SpeexParser) Grouping() string {
+	return s.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+func (s SpeexParser) HasPicture() bool {
+	return s.tags[oggVorbisTagPicture] != "" || s.tags[oggVorbisTagCoverArt] != ""
+}
+
+// HeaderFingerprint returns a stable identifier derived from the header and size of this stream
+func (s SpeexParser) HeaderFingerprint() []byte {
+	return headerFingerprint(s.endPos, *s.header)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (s SpeexParser) InitialKey() string {
+	return firstTag(s.tags, tagInitialKey, tagKey)
+}
+
+// Language returns the Language tag for this stream
+func (s SpeexParser) Language() string {
+	return s.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream
+func (s SpeexParser) Lyrics() string {
+	return s.tags[tagLyrics]
+}
+
+// Mode returns the name of the Speex mode used to encode this stream: "narrowband",
+// "wideband", or "ultra-wideband"
+func (s SpeexParser) Mode() string {
+	return speexModeNames[s.header.Mode]
+}
+
+// Mood returns the Mood tag for this stream
+func (s SpeexParser) Mood() string {
+	return s.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (s SpeexParser) OriginalFilename() string {
+	return s.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (s SpeexParser) Properties() AudioProperties {
+	return audioProperties(&s)
+}
+
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (s SpeexParser) Publisher() string {
+	return firstTag(s.tags, tagPublisher, tagLabel, tagOrganization)
+}
+
+// Raw returns a copy of the format-specific header parsed from this stream, as a SpeexHeader
+func (s SpeexParser) Raw() interface{} {
+	return *s.header
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (s SpeexParser) Remixer() string {
+	return firstTag(s.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (s SpeexParser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(s.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (s SpeexParser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(s.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (s SpeexParser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(s.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (s SpeexParser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(s.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new Speex stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (s *SpeexParser) Reset(reader io.ReadSeeker) error {
+	*s = SpeexParser{
+		buffer:  s.buffer,
+		options: s.options,
+		reader:  reader,
+	}
+
+	return s.parse()
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (s SpeexParser) SampleCount() uint64 {
+	return s.sampleCount
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (s SpeexParser) SampleFormat() SampleFormat {
+	// Speex is a lossy format, so its decoded samples have no inherent format
+	return SampleFormatUnknown
+}
+
+// SampleRate returns the sample rate in Hertz for this stream
+func (s SpeexParser) SampleRate() int {
+	return int(s.header.SampleRate)
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (s SpeexParser) String() string {
+	return summarize(&s)
+}
+
+// Tag attempts to return the raw, unprocessed tag with the specified name for this stream
+func (s SpeexParser) Tag(name string) string {
+	return s.tags[strings.ToUpper(name)]
+}
+
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream
+func (s SpeexParser) TagMulti(name string) []string {
+	return tagValues(s.tags, name)
+}
+
+// Title returns the Title tag for this stream
+func (s SpeexParser) Title() string {
+	return s.tags[tagTitle]
+}
+
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (s SpeexParser) TitleSort() string {
+	return s.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (s SpeexParser) TotalDiscs() int {
+	return parseTotal(s.tags[tagDiscNumber], firstTag(s.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (s SpeexParser) TotalTracks() int {
+	return parseTotal(s.tags[tagTrackNumber], firstTag(s.tags, tagTrackTotal, tagTotalTracks))
+}
+
+// TrackNumber returns the TrackNumber tag for this stream
+func (s SpeexParser) TrackNumber() int {
+	// Check for a /, such as 2/8
+	track, err := strconv.Atoi(strings.Split(s.tags[tagTrackNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return track
+}
+
+// Vendor returns the vendor string for this stream, which typically identifies the encoding software
+func (s SpeexParser) Vendor() string {
+	return s.vendor
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (s SpeexParser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range s.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (s SpeexParser) Work() string {
+	return s.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (s SpeexParser) Year() int {
+	return parseYear(s.tags[tagDate])
+}
+
+// newSpeexParser creates a parser for Speex audio streams
+func newSpeexParser(reader io.ReadSeeker, options Options) (*SpeexParser, error) {
+	// Create Speex parser
+	parser := &SpeexParser{
+		buffer:  make([]byte, oggMaxPageSize),
+		options: options,
+		reader:  reader,
+	}
+
+	// Parse the stream
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a Speex stream from the reader of this parser
+func (s *SpeexParser) parse() error {
+	// Parse the required header, which is the only packet on the first page
+	header, data, err := readOGGPage(s.reader, s.buffer, s.Format())
+	if err != nil {
+		return err
+	}
+	s.serial = binary.LittleEndian.Uint32(header[14:18])
+
+	if err := s.parseHeader(data); err != nil {
+		return err
+	}
+
+	// Parse the required comment header, which begins on the second page
+	packet, err := s.readPacket()
+	if err != nil {
+		return err
+	}
+
+	if err := s.parseComments(packet); err != nil {
+		return err
+	}
+
+	// If only a prefix of the stream is available, the duration cannot be determined, and it
+	// is not needed if only tags are requested
+	if s.options.streaming || s.options.TagsOnly {
+		return nil
+	}
+
+	// Determine the size of the stream
+	n, err := streamSize(s.reader)
+	if err != nil {
+		return err
+	}
+	s.endPos = n
+
+	// Parse the stream's duration, stopping first if parsing has been canceled
+	if err := s.options.err(); err != nil {
+		return err
+	}
+
+	return s.parseDuration()
+}
+
+// SpeexHeader represents the information contained in a Speex header.  A copy of the SpeexHeader
+// for a Speex stream is returned by Raw.
+type SpeexHeader struct {
+	Version              string
+	VersionID            int32
+	HeaderSize           int32
+	SampleRate           int32
+	Mode                 int32
+	ModeBitstreamVersion int32
+	Channels             int32
+	Bitrate              int32
+	FrameSize            int32
+	VBR                  bool
+	FramesPerPacket      int32
+	ExtraHeaders         int32
+}
+
+// parseHeader parses a Speex header from the input packet, which contains the following fields:
+//   - 8 bytes: "Speex   "
+//   - 20 bytes: encoder version string, null padded
+//   - 4 bytes x 13: version ID, header size, sample rate, mode, mode bitstream version, channel count,
+//     bitrate, frame size, VBR flag, frames per packet, extra header count, and two reserved fields,
+//     all little endian
+func (s *SpeexParser) parseHeader(packet []byte) error {
+	if len(packet) < speexHeaderSize || !bytes.Equal(packet[:len(speexMagicNumber)], speexMagicNumber) {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  s.Format(),
+			Details: "first Ogg packet is not a Speex header",
+		}
+	}
+
+	// Read the integer fields which follow the version string
+	var fields [11]int32
+	if err := binary.Read(bytes.NewReader(packet[28:]), binary.LittleEndian, &fields); err != nil {
+		return err
+	}
+
+	header := &SpeexHeader{
+		Version:              strings.TrimRight(string(packet[8:28]), "\x00"),
+		VersionID:            fields[0],
+		HeaderSize:           fields[1],
+		SampleRate:           fields[2],
+		Mode:                 fields[3],
+		ModeBitstreamVersion: fields[4],
+		Channels:             fields[5],
+		Bitrate:              fields[6],
+		FrameSize:            fields[7],
+		VBR:                  fields[8] != 0,
+		FramesPerPacket:      fields[9],
+		ExtraHeaders:         fields[10],
+	}
+
+	// Ensure sample rate and channel count are greater than 0, to prevent a division-by-zero
+	// panic when calculating duration
+	if header.SampleRate <= 0 || header.Channels <= 0 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  s.Format(),
+			Details: "sample rate and channel count must be greater than 0",
+		}
+	}
+
+	// Ensure mode is known
+	if _, ok := speexModeNames[header.Mode]; !ok {
+		return TagError{
+			Err:     ErrUnsupportedVersion,
+			Format:  s.Format(),
+			Details: fmt.Sprintf("unsupported Speex mode: %d", header.Mode),
+		}
+	}
+
+	// Store header
+	s.header = header
+	return nil
+}
+
+// readPacket reads a complete Ogg packet which begins at the start of the next page, following the
+// segment table across continuation pages until the packet is complete
+func (s *SpeexParser) readPacket() ([]byte, error) {
+	var packet []byte
+	for {
+		// Stop if parsing has been canceled
+		if err := s.options.err(); err != nil {
+			return nil, err
+		}
+
+		header, data, err := readOGGPage(s.reader, s.buffer, s.Format())
+		if err != nil {
+			if err == io.EOF {
+				return nil, oggTruncatedPage(s.Format())
+			}
+
+			return nil, err
+		}
+
+		// Sum lacing values for this page, where a value less than 255 marks the end of the packet
+		size := 0
+		for _, l := range header[oggPageHeaderSize:] {
+			size += int(l)
+			if l < 255 {
+				return append(packet, data[:size]...), nil
+			}
+		}
+		packet = append(packet, data...)
+	}
+}
+
+// parseComments parses the Vorbis comments in the comment header packet of a Speex stream.  Unlike
+// the comment header of an Ogg Vorbis stream, the packet has no type or identification word, and no
+// framing flag.
+func (s *SpeexParser) parseComments(packet []byte) error {
+	reader := bytes.NewReader(packet)
+
+	// Read vendor string
+	var length uint32
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return s.truncatedComments()
+	}
+	if int64(length) > int64(reader.Len()) {
+		return s.truncatedComments()
+	}
+
+	vendor := make([]byte, length)
+	reader.Read(vendor)
+	s.vendor = string(vendor)
+
+	// Read comment count (new allocation for use with loop counter)
+	var commentLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &commentLength); err != nil {
+		return s.truncatedComments()
+	}
+
+	// Begin iterating tags, and building tag map
+	tagMap := map[string]string{}
+	for i := 0; i < int(commentLength); i++ {
+		// Read tag string
+		if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+			return s.truncatedComments()
+		}
+		if int64(length) > int64(reader.Len()) {
+			return s.truncatedComments()
+		}
+
+		comment := make([]byte, length)
+		reader.Read(comment)
+
+		// Split tag name and data, store in map
+		name, tag, ok := parseVorbisComment(string(comment), s.options)
+		if !ok {
+			// Malformed comments are only an error with strict options
+			if s.options.strict() {
+				return TagError{
+					Err:     ErrInvalidStream,
+					Format:  s.Format(),
+					Details: "malformed Vorbis comment in comment header",
+				}
+			}
+
+			continue
+		}
+		tagMap[name] = tag
+	}
+
+	// Store tags
+	s.options.normalizeTags(tagMap)
+	s.tags = tagMap
+	return nil
+}
+
+// truncatedComments generates an invalid stream error for a comment header which ends before all
+// of its fields
+func (s SpeexParser) truncatedComments() error {
+	return TagError{
+		Err:     ErrInvalidStream,
+		Format:  s.Format(),
+		Details: "Speex comment header extends past end of packet",
+	}
+}
+
+// parseDuration scans backward from the end of the stream to find its final Ogg page, whose granule
+// position is the total number of samples in the stream
+// BUG(mdlayher): Speex: the durations of chained logical bitstreams are not summed
+func (s *SpeexParser) parseDuration() error {
+	granule, serial, found, err := oggFinalPage(s.reader, s.endPos, s.buffer, s.options)
+	if err != nil {
+		return err
+	}
+
+	if !found || serial != s.serial {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  s.Format(),
+			Details: "could not detect final Ogg page header",
+		}
+	}
+
+	if granule == 0 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  s.Format(),
+			Details: "Ogg pages contain no audio samples, stream may be truncated",
+		}
+	}
+	s.finalGranule = granule
+
+	// Calculate duration using last granule position divided by sample rate
+	s.sampleCount = granule
+	s.duration = samplesDuration(granule, uint64(s.header.SampleRate))
+	return nil
+}
@@ -0,0 +1,127 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// speexStream generates a Speex stream with the input tags, containing the input number of samples
+func speexStream(t *testing.T, samples uint64, comments ...string) []byte {
+	// Header: wideband, 16kHz mono, 27.8kbps VBR
+	header := make([]byte, speexHeaderSize)
+	copy(header, speexMagicNumber)
+	copy(header[8:], "1.2.1")
+	for i, v := range []int32{1, speexHeaderSize, 16000, 1, 4, 1, 27800, 320, 1, 1, 0} {
+		binary.LittleEndian.PutUint32(header[28+i*4:], uint32(v))
+	}
+
+	// Comment header: vendor string, followed by each comment
+	vendor := "Encoded with Speex 1.2.1"
+	var comment bytes.Buffer
+	binary.Write(&comment, binary.LittleEndian, uint32(len(vendor)))
+	comment.WriteString(vendor)
+	binary.Write(&comment, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		binary.Write(&comment, binary.LittleEndian, uint32(len(c)))
+		comment.WriteString(c)
+	}
+
+	var buf bytes.Buffer
+	for i, packet := range [][]byte{header, comment.Bytes(), make([]byte, 64)} {
+		if _, err := writeOGGPackets(&buf, 1, uint32(i), packet); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Mark the final page as the end of stream, with its granule position set to the number of samples
+	stream := buf.Bytes()
+	last := bytes.LastIndex(stream, oggMagicNumber)
+	stream[last+5] |= oggPageEndOfStream
+	binary.LittleEndian.PutUint64(stream[last+6:last+14], samples)
+
+	return stream
+}
+
+// TestSpeex verifies that Speex streams in an Ogg container are detected, and that their header,
+// comments, and duration are parsed
+func TestSpeex(t *testing.T) {
+	stream := speexStream(t, 48000, "ARTIST=Artist", "title=Title")
+
+	parser, err := New(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reflect.TypeOf(parser) != reflect.TypeOf(&SpeexParser{}) {
+		t.Fatalf("unexpected parser type: %v", reflect.TypeOf(parser))
+	}
+	speex := parser.(*SpeexParser)
+
+	if speex.Format() != "Speex" {
+		t.Fatalf("mismatched Format: %v", speex.Format())
+	}
+	if speex.ContentType() != "audio/ogg" {
+		t.Fatalf("mismatched ContentType: %v", speex.ContentType())
+	}
+	if speex.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", speex.Artist())
+	}
+	if speex.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", speex.Title())
+	}
+	if speex.Vendor() != "Encoded with Speex 1.2.1" {
+		t.Fatalf("mismatched Vendor: %v", speex.Vendor())
+	}
+	if speex.Mode() != "wideband" {
+		t.Fatalf("mismatched Mode: %v", speex.Mode())
+	}
+	if speex.SampleRate() != 16000 {
+		t.Fatalf("mismatched SampleRate: %v", speex.SampleRate())
+	}
+	if speex.Channels() != 1 {
+		t.Fatalf("mismatched Channels: %v", speex.Channels())
+	}
+	if speex.Bitrate() != 27 {
+		t.Fatalf("mismatched Bitrate: %v", speex.Bitrate())
+	}
+	if speex.Duration() != 3*time.Second {
+		t.Fatalf("mismatched Duration: %v", speex.Duration())
+	}
+	if speex.SampleCount() != 48000 {
+		t.Fatalf("mismatched SampleCount: %v", speex.SampleCount())
+	}
+	if speex.FinalGranule() != 48000 {
+		t.Fatalf("mismatched FinalGranule: %v", speex.FinalGranule())
+	}
+
+	raw := speex.Raw().(SpeexHeader)
+	if raw.Version != "1.2.1" || !raw.VBR || raw.FrameSize != 320 {
+		t.Fatalf("mismatched Raw: %+v", raw)
+	}
+}
+
+// TestSpeexInvalid verifies that Speex streams with invalid headers or no audio samples are rejected
+func TestSpeexInvalid(t *testing.T) {
+	var tests = []struct {
+		description string
+		modify      func(stream []byte) []byte
+	}{
+		{"zero sample rate", func(stream []byte) []byte { copy(stream[oggPageHeaderSize+1+36:], []byte{0, 0, 0, 0}); return stream }},
+		{"truncated comment header", func(stream []byte) []byte { return stream[:oggPageHeaderSize+1+speexHeaderSize+oggPageHeaderSize+1+8] }},
+		{"no audio samples", func(stream []byte) []byte {
+			last := bytes.LastIndex(stream, oggMagicNumber)
+			binary.LittleEndian.PutUint64(stream[last+6:last+14], 0)
+			return stream
+		}},
+	}
+
+	for _, test := range tests {
+		stream := test.modify(speexStream(t, 48000, "ARTIST=Artist"))
+		if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+			t.Fatalf("%s: expected invalid stream error, got: %v", test.description, err)
+		}
+	}
+}
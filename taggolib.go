@@ -2,35 +2,79 @@ package taggolib
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 const (
 	// These constants represent the built-in tags
-	tagAlbum       = "ALBUM"
-	tagAlbumArtist = "ALBUMARTIST"
-	tagArtist      = "ARTIST"
-	tagComment     = "COMMENT"
-	tagDate        = "DATE"
-	tagDiscNumber  = "DISCNUMBER"
-	tagGenre       = "GENRE"
-	tagPublisher   = "PUBLISHER"
-	tagTitle       = "TITLE"
-	tagTrackNumber = "TRACKNUMBER"
+	tagAlbum               = "ALBUM"
+	tagAlbumArtist         = "ALBUMARTIST"
+	tagAlbumArtistSort     = "ALBUMARTISTSORT"
+	tagAlbumSort           = "ALBUMSORT"
+	tagArtist              = "ARTIST"
+	tagArtistSort          = "ARTISTSORT"
+	tagBPM                 = "BPM"
+	tagComment             = "COMMENT"
+	tagComposer            = "COMPOSER"
+	tagConductor           = "CONDUCTOR"
+	tagCopyright           = "COPYRIGHT"
+	tagDate                = "DATE"
+	tagDiscNumber          = "DISCNUMBER"
+	tagDiscTotal           = "DISCTOTAL"
+	tagEncodedBy           = "ENCODED_BY"
+	tagEncoder             = "ENCODER"
+	tagGenre               = "GENRE"
+	tagGrouping            = "GROUPING"
+	tagInitialKey          = "INITIALKEY"
+	tagKey                 = "KEY"
+	tagLabel               = "LABEL"
+	tagLanguage            = "LANGUAGE"
+	tagLyrics              = "LYRICS"
+	tagMixArtist           = "MIXARTIST"
+	tagMood                = "MOOD"
+	tagOrganization        = "ORGANIZATION"
+	tagOriginalFilename    = "ORIGINALFILENAME"
+	tagPublisher           = "PUBLISHER"
+	tagRemixer             = "REMIXER"
+	tagReplayGainAlbumGain = "REPLAYGAIN_ALBUM_GAIN"
+	tagReplayGainAlbumPeak = "REPLAYGAIN_ALBUM_PEAK"
+	tagReplayGainTrackGain = "REPLAYGAIN_TRACK_GAIN"
+	tagReplayGainTrackPeak = "REPLAYGAIN_TRACK_PEAK"
+	tagTitle               = "TITLE"
+	tagTitleSort           = "TITLESORT"
+	tagTotalDiscs          = "TOTALDISCS"
+	tagTotalTracks         = "TOTALTRACKS"
+	tagTrackNumber         = "TRACKNUMBER"
+	tagTrackTotal          = "TRACKTOTAL"
+	tagUnsyncedLyrics      = "UNSYNCEDLYRICS"
+	tagWork                = "WORK"
 )
 
+// streamPrefixSize is the maximum number of bytes which NewReader buffers from an input stream
+const streamPrefixSize = 4 << 20
+
+// Errors which taggolib returns wrapped in a TagError, and which may be checked using errors.Is
 var (
-	// errInvalidStream is returned when taggolib encounters a broken input stream, but
+	// ErrInvalidStream is returned when taggolib encounters a broken input stream, but
 	// does recognize the input stream format
-	errInvalidStream = errors.New("invalid input stream")
-	// errUnknownFormat is returned when taggolib cannot recognize the input stream format
-	errUnknownFormat = errors.New("unknown format")
-	// errUnsupportedVersion is returned when taggolib recognizes an input stream format, but
+	ErrInvalidStream = errors.New("invalid input stream")
+	// ErrUnknownFormat is returned when taggolib cannot recognize the input stream format
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrUnsupportedVersion is returned when taggolib recognizes an input stream format, but
 	// can not currently handle the version specified by the input stream
-	errUnsupportedVersion = errors.New("unsupported version")
+	ErrUnsupportedVersion = errors.New("unsupported version")
 )
 
 // TagError represents an error which occurs during the metadata parsing process.  It is used internally to
@@ -47,62 +91,345 @@ func (e TagError) Error() string {
 	return fmt.Sprintf("%s - %s: %s", e.Err.Error(), e.Format, e.Details)
 }
 
+// Unwrap returns the internal taggolib error wrapped by a TagError, such as ErrInvalidStream, so that
+// it may be checked using errors.Is
+func (e TagError) Unwrap() error {
+	return e.Err
+}
+
 // IsInvalidStream is a convenience method which checks if an error is caused by an invalid stream
 // of a known format.  This may happen if the input stream is corrupt, or if the input stream contains flags which
 // should not be present in a valid input stream.
 func IsInvalidStream(err error) bool {
-	// Attempt to type-assert to TagError
-	tagErr, ok := err.(TagError)
-	if !ok {
+	// Attempt to find a TagError in the error's chain, which may wrap it
+	var tagErr TagError
+	if !errors.As(err, &tagErr) {
 		return false
 	}
 
-	// Return if error matches errInvalidStream
-	return tagErr.Err == errInvalidStream
+	// Return if error matches ErrInvalidStream
+	return tagErr.Err == ErrInvalidStream
 }
 
 // IsUnknownFormat is a convenience method which checks if an error is caused by an unknown format.  This may happen
 // if the input stream contains a magic number which taggolib cannot handle, such as an unsupported audio format,
 // or any kind of file which is not an audio file.
 func IsUnknownFormat(err error) bool {
-	// Attempt to type-assert to TagError
-	tagErr, ok := err.(TagError)
-	if !ok {
+	// Attempt to find a TagError in the error's chain, which may wrap it
+	var tagErr TagError
+	if !errors.As(err, &tagErr) {
 		return false
 	}
 
-	// Return if error matches errUnknownFormat
-	return tagErr.Err == errUnknownFormat
+	// Return if error matches ErrUnknownFormat
+	return tagErr.Err == ErrUnknownFormat
 }
 
 // IsUnsupportedVersion is a convenience method which checks if an error is caused by an unsupported version
 // of a known format.  This may happen if the input stream is recognized by taggolib, but taggolib does not support
 // parsing a certain version of the metadata, such as ID3v1.
 func IsUnsupportedVersion(err error) bool {
-	// Attempt to type-assert to TagError
-	tagErr, ok := err.(TagError)
-	if !ok {
+	// Attempt to find a TagError in the error's chain, which may wrap it
+	var tagErr TagError
+	if !errors.As(err, &tagErr) {
 		return false
 	}
 
-	// Return if error matches errUnsupportedVersion
-	return tagErr.Err == errUnsupportedVersion
+	// Return if error matches ErrUnsupportedVersion
+	return tagErr.Err == ErrUnsupportedVersion
+}
+
+// IsRenamed is a convenience method which checks if the original filename embedded in a parsed stream's
+// metadata differs from the filename at the input path.  This may be used to detect files which have been
+// renamed since they were tagged.  If no original filename is embedded, IsRenamed returns false.
+func IsRenamed(parser Parser, path string) bool {
+	// Ensure an original filename is present
+	original := parser.OriginalFilename()
+	if original == "" {
+		return false
+	}
+
+	// Compare only the base names, since the original filename may or may not contain a directory
+	return filepath.Base(original) != filepath.Base(path)
+}
+
+// firstTag returns the first non-empty tag from a tag map, checking the input tag names in order
+func firstTag(tags map[string]string, names ...string) string {
+	for _, name := range names {
+		if tag := tags[name]; tag != "" {
+			return tag
+		}
+	}
+
+	return ""
+}
+
+// headerFingerprint generates a SHA-1 hash of a stream's size and its parsed header structures
+func headerFingerprint(size int64, headers ...interface{}) []byte {
+	hash := sha1.New()
+
+	// Hash the size of the stream, followed by the contents of each header
+	binary.Write(hash, binary.BigEndian, size)
+	for _, h := range headers {
+		fmt.Fprintf(hash, "%+v", h)
+	}
+
+	return hash.Sum(nil)
+}
+
+// readMagicNumber reads and verifies the magic number at the current position of an input stream,
+// returning an invalid stream error for the specified format if it does not match
+func readMagicNumber(reader io.Reader, magic []byte, format string) error {
+	magicBuf := make([]byte, len(magic))
+	if _, err := io.ReadFull(reader, magicBuf); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(magicBuf, magic) {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  format,
+			Details: "unrecognized magic number",
+		}
+	}
+
+	return nil
+}
+
+// streamSize determines the total size of an input stream, restoring the stream's original
+// position afterward
+func streamSize(reader io.ReadSeeker) (int64, error) {
+	// Store current position
+	current, err := reader.Seek(0, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	// Seek to end of stream to determine its size
+	size, err := reader.Seek(0, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	// Restore original position
+	if _, err := reader.Seek(current, 0); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// readRange reads the bytes between the start and end offsets of an input stream into a new slice
+func readRange(reader io.ReadSeeker, start int64, end int64) ([]byte, error) {
+	if _, err := reader.Seek(start, 0); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, end-start)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// samplesDuration calculates the duration of the specified number of samples at the specified sample
+// rate, with nanosecond precision.  If the sample rate is zero, samplesDuration returns 0.
+func samplesDuration(samples uint64, sampleRate uint64) time.Duration {
+	if sampleRate == 0 {
+		return 0
+	}
+
+	// Calculate whole seconds and the remainder separately, to prevent overflow
+	return time.Duration(samples/sampleRate)*time.Second +
+		time.Duration(samples%sampleRate)*time.Second/time.Duration(sampleRate)
+}
+
+// summarize generates a one-line summary of a parsed stream, such as
+// "Artist - Album - Title [FLAC/202kbps/16bit/44kHz/05:00]"
+func summarize(p Parser) string {
+	// Calculate duration in mm:ss format
+	seconds := int(p.Duration().Seconds())
+	minutes := seconds / 60
+	seconds = seconds - (minutes * 60)
+
+	return fmt.Sprintf("%s - %s - %s [%s/%dkbps/%dbit/%dkHz/%02d:%02d]",
+		p.Artist(), p.Album(), p.Title(), p.Format(), p.Bitrate(), p.BitDepth(), p.SampleRate()/1000, minutes, seconds)
+}
+
+// parseVorbisComment splits a raw Vorbis comment into its tag name and data, returning false if the
+// comment does not contain a tag name separator or, if requested, is not valid UTF-8
+func parseVorbisComment(comment string, options Options) (string, string, bool) {
+	// Split only on the first separator, since tag data may also contain the separator
+	pair := strings.SplitN(comment, "=", 2)
+	if len(pair) != 2 {
+		return "", "", false
+	}
+
+	// Vorbis comments must be encoded as UTF-8
+	if options.validateUTF8() && !utf8.ValidString(comment) {
+		return "", "", false
+	}
+
+	return strings.ToUpper(pair[0]), pair[1], true
+}
+
+// tagValues returns the value of the named tag in a tag map as a single-element slice, for parsers
+// which store one value per tag, or nil if the tag is not present
+func tagValues(tags map[string]string, name string) []string {
+	if value, ok := tags[strings.ToUpper(name)]; ok {
+		return []string{value}
+	}
+
+	return nil
+}
+
+// setTag sets the value of the named tag in a tag map, allocating the map if needed, or removes the
+// tag if the value is empty.  Tag names are stored in upper case, as they are by all parsers.
+func setTag(tags *map[string]string, name string, value string) {
+	name = strings.ToUpper(name)
+	if value == "" {
+		delete(*tags, name)
+		return
+	}
+
+	if *tags == nil {
+		*tags = map[string]string{}
+	}
+	(*tags)[name] = value
+}
+
+// vorbisCommentBytes serializes a vendor string and tags as Vorbis comments, in the layout shared by
+// FLAC VORBISCOMMENT blocks and Ogg Vorbis comment headers.  Tags are written in order by name, and
+// multiple values for a single tag are not preserved.
+func vorbisCommentBytes(vendor string, tags map[string]string) []byte {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Each string is preceded by its length, little endian, and the comments are preceded by
+	// their count
+	buf := bytes.NewBuffer(nil)
+	writeString := func(s string) {
+		binary.Write(buf, binary.LittleEndian, uint32(len(s)))
+		buf.WriteString(s)
+	}
+
+	writeString(vendor)
+	binary.Write(buf, binary.LittleEndian, uint32(len(names)))
+	for _, name := range names {
+		writeString(name + "=" + tags[name])
+	}
+
+	return buf.Bytes()
+}
+
+// parseYear extracts a four-digit year from a date tag, which may be a bare year or an ISO 8601
+// date, returning 0 if no year is present
+func parseYear(date string) int {
+	digits := 0
+	for i, r := range date {
+		if r < '0' || r > '9' {
+			digits = 0
+			continue
+		}
+
+		// Use the first run of exactly four digits
+		digits++
+		if digits == 4 && (i+1 == len(date) || date[i+1] < '0' || date[i+1] > '9') {
+			year, _ := strconv.Atoi(date[i-3 : i+1])
+			return year
+		}
+	}
+
+	return 0
+}
+
+// parseTotal parses a total count, such as a track or disc total, from a combined "current/total" tag
+// value and an explicit total tag value.  If both are present and disagree, the explicit total is preferred.
+// If no total is present, parseTotal returns 0.
+func parseTotal(combined string, total string) int {
+	// Check for an explicit total first
+	if n, err := strconv.Atoi(strings.TrimSpace(total)); err == nil {
+		return n
+	}
+
+	// Check for a /, such as 2/8
+	pair := strings.SplitN(combined, "/", 2)
+	if len(pair) != 2 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// parseReplayGain parses a ReplayGain tag value such as "-3.21 dB" or "0.988553" into a float, returning
+// false if the value is absent or cannot be parsed
+func parseReplayGain(value string) (float64, bool) {
+	// Trim whitespace and the optional decibels suffix
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && strings.EqualFold(value[len(value)-2:], "dB") {
+		value = strings.TrimSpace(value[:len(value)-2])
+	}
+
+	gain, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return gain, true
 }
 
 // Parser represents an audio metadata tag parser.  It is the interface which all other parsers implement, and it
-// contains all the standard methods which must be present in an audio parser.
+// contains all the standard methods which must be present in an audio parser.  Parsers returned by New may be
+// type-asserted to their concrete types, such as *FLACParser or *MP3Parser, to access methods specific to a format.
 type Parser interface {
 	// Methods which access the data stored in a typical audio metadata tag
 	Album() string
 	AlbumArtist() string
+	AlbumArtistSort() string
+	AlbumSort() string
 	Artist() string
+	ArtistSort() string
+	BPM() int
 	Comment() string
+	Composer() string
+	Conductor() string
+	Copyright() string
 	Date() string
 	DiscNumber() int
+	EncodedBy() string
 	Genre() string
+	Grouping() string
+	InitialKey() string
+	Language() string
+	Lyrics() string
+	Mood() string
+	OriginalFilename() string
 	Publisher() string
+	Remixer() string
 	Title() string
+	TitleSort() string
+	TotalDiscs() int
+	TotalTracks() int
 	TrackNumber() int
+	Work() string
+	Year() int
+
+	// Methods which access ReplayGain volume normalization information.  Each method
+	// returns the parsed value, and a boolean indicating whether or not the value was
+	// present in the stream.  Gain values are in decibels.
+	ReplayGainAlbumGain() (float64, bool)
+	ReplayGainAlbumPeak() (float64, bool)
+	ReplayGainTrackGain() (float64, bool)
+	ReplayGainTrackPeak() (float64, bool)
 
 	// Tag is a special method which will attempt to retrieve an audio metadata
 	// tag with the input name. Tag will attempt to return a metadata tag's raw
@@ -110,73 +437,623 @@ type Parser interface {
 	// Using Tag, the following two calls are functionally equivalent:
 	//   - parser.Artist()
 	//   - parser.Tag("ARTIST")
+	// Tag names are case-insensitive in all formats, so parser.Tag("accuraterip_crc")
+	// is equivalent to parser.Tag("ACCURATERIP_CRC").  For formats which store Vorbis
+	// comments, such as FLAC and Ogg Vorbis, every comment in the stream is retained
+	// and available using Tag.
 	Tag(name string) string
 
+	// TagMulti returns every value of a raw metadata tag, in the same manner as
+	// Tag, or nil if the tag is not present.  ID3v2.4 text frames may contain
+	// multiple values, and Tag and the tag accessor methods return the first.
+	// Formats which store a single value per tag return one value.
+	TagMulti(name string) []string
+
+	// VisitTags invokes a callback for each raw metadata tag discovered in the
+	// stream, passing the tag's name and contents.  Iteration stops early if the
+	// callback returns false.  VisitTags does not allocate, and is useful for
+	// callers which wish to stream each tag to an output without building a map.
+	VisitTags(fn func(name, value string) bool)
+
+	// HasPicture reports whether or not the stream contains embedded cover art.
+	// HasPicture does not read or decode any image data.
+	HasPicture() bool
+
+	// HeaderFingerprint returns a stable identifier derived from the audio
+	// header information parsed from the stream, and the stream's size.  It is
+	// intended for use as a cache key, which changes only when the audio header
+	// or stream size changes.
+	HeaderFingerprint() []byte
+
+	// Raw returns a copy of the low-level, format-specific header parsed from
+	// the stream, for diagnostic use.  The concrete type of the returned value
+	// depends on the format:
+	//   - FLAC and Ogg FLAC: FLACStreamInfo
+	//   - Monkey's Audio: APEHeader
+	//   - MP3: MP3Header
+	//   - Ogg Vorbis: OggVorbisIDHeader
+	//   - Speex: SpeexHeader
+	//   - True Audio: TTAHeader
+	//   - WMA: WMAHeader
+	Raw() interface{}
+
+	// Reset discards the state of the parser, and parses a new stream of the
+	// same format from the input reader, which must be positioned at the start
+	// of the stream.  Reset reuses memory allocated by the parser, and may be
+	// used to reduce allocations when parsing many streams of the same format.
+	Reset(reader io.ReadSeeker) error
+
 	// Methods which access properties of an audio file, which are
 	// typically calculated at runtime
 	BitDepth() int
 	Bitrate() int
+	ChannelLayout() string
 	Channels() int
+	ContentType() string
 	Duration() time.Duration
 	Encoder() string
 	Format() string
+	FormatID() Format
+	SampleCount() uint64
+	SampleFormat() SampleFormat
 	SampleRate() int
+
+	// Properties returns all of the above audio properties in a single structure,
+	// computing each property only once
+	Properties() AudioProperties
+
+	// String returns a one-line summary of the stream, including its artist, album, and
+	// title tags, and its audio properties, for use in debugging and logging
+	String() string
+}
+
+// TagWriter represents a parser which can modify the tags of its stream.  Parsers for formats which
+// support writing tags implement TagWriter, which may be checked using a type assertion on a Parser.
+// FLAC, MP3, and Ogg Vorbis parsers implement TagWriter.  Ogg FLAC parsers also implement TagWriter,
+// but do not yet support saving tags.
+type TagWriter interface {
+	// SetTag sets the value of the named tag, replacing any existing value.  An empty value removes
+	// the tag.
+	SetTag(name string, value string)
+
+	// Save writes a copy of the parsed stream to w, with its tags replaced by the tags set on this
+	// parser.  All other metadata and the audio data are copied unchanged from the parsed stream,
+	// so w must not write to the parsed stream.
+	Save(w io.Writer) error
+}
+
+// AudioProperties contains the properties of an audio stream, as returned by the individual property
+// methods of Parser
+type AudioProperties struct {
+	BitDepth      int
+	Bitrate       int
+	ChannelLayout string
+	Channels      int
+	Duration      time.Duration
+	Encoder       string
+	Format        string
+	SampleCount   uint64
+	SampleFormat  SampleFormat
+	SampleRate    int
+}
+
+// audioProperties gathers the audio properties of a parsed stream into an AudioProperties structure
+func audioProperties(p Parser) AudioProperties {
+	return AudioProperties{
+		BitDepth:      p.BitDepth(),
+		Bitrate:       p.Bitrate(),
+		ChannelLayout: p.ChannelLayout(),
+		Channels:      p.Channels(),
+		Duration:      p.Duration(),
+		Encoder:       p.Encoder(),
+		Format:        p.Format(),
+		SampleCount:   p.SampleCount(),
+		SampleFormat:  p.SampleFormat(),
+		SampleRate:    p.SampleRate(),
+	}
+}
+
+// SampleFormat identifies the format of the decoded samples of an audio stream, so that decoded audio
+// may be interpreted correctly.  Byte order is not included, because it only applies to samples stored
+// uncompressed, and all formats parsed by taggolib are compressed.
+type SampleFormat int
+
+const (
+	// SampleFormatUnknown is returned for lossy formats, whose decoded samples have no inherent format,
+	// and for formats which are not built into taggolib
+	SampleFormatUnknown SampleFormat = iota
+
+	// SampleFormatSignedInt indicates signed integer samples, of the stream's bit depth
+	SampleFormatSignedInt
+
+	// SampleFormatUnsignedInt indicates unsigned integer samples, of the stream's bit depth
+	SampleFormatUnsignedInt
+
+	// SampleFormatFloat indicates IEEE floating point samples, of the stream's bit depth
+	SampleFormatFloat
+)
+
+// sampleFormatNames maps each SampleFormat to its name
+var sampleFormatNames = map[SampleFormat]string{
+	SampleFormatSignedInt:   "signed integer",
+	SampleFormatUnsignedInt: "unsigned integer",
+	SampleFormatFloat:       "float",
+}
+
+// String returns the name of a SampleFormat
+func (f SampleFormat) String() string {
+	if name, ok := sampleFormatNames[f]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// wavSampleFormat returns the format of samples originally stored in a WAV file with the specified bit
+// depth, which is the source of Monkey's Audio and True Audio streams.  WAV files store 8-bit samples as
+// unsigned integers, and all other integer samples as signed integers.
+func wavSampleFormat(bitDepth int) SampleFormat {
+	if bitDepth == 8 {
+		return SampleFormatUnsignedInt
+	}
+
+	return SampleFormatSignedInt
+}
+
+// channelLayouts maps numbers of channels to the names of their default channel layouts, which are
+// shared by FLAC, Vorbis, and WAV streams
+var channelLayouts = map[int]string{
+	1: "mono",
+	2: "stereo",
+	3: "3.0",
+	4: "quad",
+	5: "5.0",
+	6: "5.1",
+	7: "6.1",
+	8: "7.1",
+}
+
+// channelLayout returns the name of the default channel layout for a stream with the specified number
+// of channels, or an empty string if the number of channels has no default layout
+func channelLayout(channels int) string {
+	return channelLayouts[channels]
+}
+
+// Picture represents a picture embedded in an audio stream, such as cover art
+type Picture struct {
+	// Type is the type of the picture, such as PictureTypeFrontCover
+	Type PictureType
+
+	// MIMEType is the MIME type of the image data, such as "image/jpeg"
+	MIMEType string
+
+	// Description is a text description of the picture, which is often empty
+	Description string
+
+	// Data is the image data of the picture
+	Data []byte
+}
+
+// PictureType identifies the contents of an embedded picture.  FLAC and MP3 use the same picture
+// types, which are defined by the ID3v2 APIC frame.
+type PictureType byte
+
+// Picture types defined by the ID3v2 APIC frame
+const (
+	PictureTypeOther PictureType = iota
+	PictureTypeFileIcon
+	PictureTypeOtherFileIcon
+	PictureTypeFrontCover
+	PictureTypeBackCover
+	PictureTypeLeaflet
+	PictureTypeMedia
+	PictureTypeLeadArtist
+	PictureTypeArtist
+	PictureTypeConductor
+	PictureTypeBand
+	PictureTypeComposer
+	PictureTypeLyricist
+	PictureTypeRecordingLocation
+	PictureTypeDuringRecording
+	PictureTypeDuringPerformance
+	PictureTypeVideoCapture
+	PictureTypeFish
+	PictureTypeIllustration
+	PictureTypeBandLogo
+	PictureTypePublisherLogo
+)
+
+// embeddedPicture locates a picture embedded in a stream.  Only the fields which describe a picture
+// are parsed with its stream, and its image data is read when the picture is requested, so parsing
+// streams which contain large pictures remains cheap.
+type embeddedPicture struct {
+	// Picture contains the fields which describe the picture, without its image data
+	Picture
+
+	// offset and length locate the data of the picture
+	offset int64
+	length int64
+}
+
+// hasPictureType returns whether a picture type is one of the specified types, or true if no types
+// are specified
+func hasPictureType(pictureType PictureType, types []PictureType) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, t := range types {
+		if pictureType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readStreamBytes reads the specified number of bytes at an offset in an input stream, ensuring the
+// bytes lie within the stream before allocating a buffer for them
+func readStreamBytes(reader io.ReadSeeker, offset int64, length int64) ([]byte, error) {
+	size, err := streamSize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset < 0 || length < 0 || offset > size || length > size-offset {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if _, err := reader.Seek(offset, 0); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// BitrateMode identifies the rate management mode used by the encoder of an audio stream
+type BitrateMode int
+
+const (
+	// BitrateModeUnknown is returned when the rate management mode of a stream cannot be determined
+	BitrateModeUnknown BitrateMode = iota
+
+	// BitrateModeCBR indicates a constant bitrate stream
+	BitrateModeCBR
+
+	// BitrateModeABR indicates an average bitrate stream, whose bitrate is managed around a target
+	BitrateModeABR
+
+	// BitrateModeVBR indicates a variable bitrate stream, whose bitrate is determined by a quality setting
+	BitrateModeVBR
+)
+
+// bitrateModeNames maps each BitrateMode to its name
+var bitrateModeNames = map[BitrateMode]string{
+	BitrateModeCBR: "CBR",
+	BitrateModeABR: "ABR",
+	BitrateModeVBR: "VBR",
+}
+
+// String returns the name of a BitrateMode
+func (m BitrateMode) String() string {
+	if name, ok := bitrateModeNames[m]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Format identifies the format of an audio stream, so callers may check the format of a stream without
+// comparing the strings returned by a parser's Format method
+type Format int
+
+const (
+	// FormatUnknown is returned by parsers for formats which are not built into taggolib, such as
+	// those added using RegisterFormat
+	FormatUnknown Format = iota
+
+	// Formats which are built into taggolib
+	FormatAPE
+	FormatFLAC
+	FormatMP3
+	FormatOggVorbis
+	FormatTTA
+	FormatWMA
+	FormatOggFLAC
+	FormatSpeex
+)
+
+// formatNames maps each Format to its name, as returned by a parser's Format method
+var formatNames = map[Format]string{
+	FormatAPE:       "Monkey's Audio",
+	FormatFLAC:      "FLAC",
+	FormatMP3:       "MP3",
+	FormatOggFLAC:   "Ogg FLAC",
+	FormatOggVorbis: "Ogg Vorbis",
+	FormatSpeex:     "Speex",
+	FormatTTA:       "True Audio",
+	FormatWMA:       "WMA",
+}
+
+// formatContentTypes maps each Format to the MIME type of its streams
+var formatContentTypes = map[Format]string{
+	FormatAPE:       "audio/x-ape",
+	FormatFLAC:      "audio/flac",
+	FormatMP3:       "audio/mpeg",
+	FormatOggFLAC:   "audio/ogg",
+	FormatOggVorbis: "audio/ogg",
+	FormatSpeex:     "audio/ogg",
+	FormatTTA:       "audio/x-tta",
+	FormatWMA:       "audio/x-ms-wma",
+}
+
+// ContentType returns the MIME type of streams of a Format, for use in the Content-Type header of
+// HTTP responses.  If the Format is unknown, "application/octet-stream" is returned.
+func (f Format) ContentType() string {
+	if contentType, ok := formatContentTypes[f]; ok {
+		return contentType
+	}
+
+	return "application/octet-stream"
+}
+
+// String returns the name of a Format, which matches the name returned by the Format method of its parser
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Strictness specifies how strictly taggolib validates an input stream against its format's specification
+// while parsing.  Strictness provides a single setting which enables a sensible combination of checks.
+type Strictness int
+
+const (
+	// Normal performs the validation taggolib has always performed, rejecting streams which are clearly
+	// invalid, while tolerating minor problems such as malformed tags.  Normal is the default.
+	Normal Strictness = iota
+
+	// Lenient tolerates specification violations which do not prevent metadata parsing, such as
+	// unexpected header flags.  Lenient may be used to extract as much metadata as possible from
+	// damaged or non-conforming streams.
+	Lenient
+
+	// Strict rejects streams which violate their format's specification in any way taggolib can
+	// detect, such as malformed tags, unset framing bits, and tag values which are not valid UTF-8.
+	Strict
+)
+
+// Options specifies options which modify the behavior of taggolib's parsers.  The zero value of Options
+// specifies taggolib's default behavior, which is used by New.
+type Options struct {
+	// Strictness specifies the level of validation performed on an input stream.  Strict enables
+	// all of the individual checks below.
+	Strictness Strictness
+
+	// CheckFraming requires the framing flag to be set in Ogg Vorbis identification and comment headers
+	CheckFraming bool
+
+	// CheckReservedFlags requires reserved flag bits to be unset in an ID3v2 header
+	CheckReservedFlags bool
+
+	// ValidateUTF8 requires Vorbis comments to be valid UTF-8, skipping those which are not
+	ValidateUTF8 bool
+
+	// TagsOnly skips calculating properties which require scanning the stream beyond its tags and
+	// headers, such as the duration of Ogg Vorbis and FLAC streams and the Xing header of MP3
+	// streams.  Properties which are skipped are left unset.
+	TagsOnly bool
+
+	// TrimSpace removes null bytes from tag values, and trims their leading and trailing whitespace,
+	// so that tag values are returned consistently regardless of format or tagging software
+	TrimSpace bool
+
+	// ctx is checked between parsing stages and in scanning loops, so parsing may be canceled
+	ctx context.Context
+
+	// streaming indicates that only a prefix of the input stream is available, so parsers
+	// must not seek to the end of the stream
+	streaming bool
+}
+
+// checkFraming returns whether or not the Ogg Vorbis framing flag must be set
+func (o Options) checkFraming() bool {
+	return o.CheckFraming || o.strict()
+}
+
+// checkReservedFlags returns whether or not reserved ID3v2 header flag bits must be unset
+func (o Options) checkReservedFlags() bool {
+	return o.CheckReservedFlags || o.strict()
+}
+
+// err returns the error from the context set in these options, if the context has been canceled
+// or its deadline has been exceeded
+func (o Options) err() error {
+	if o.ctx == nil {
+		return nil
+	}
+
+	return o.ctx.Err()
+}
+
+// lenient returns whether or not minor specification violations should be tolerated
+func (o Options) lenient() bool {
+	return o.Strictness == Lenient
+}
+
+// strict returns whether or not all detectable specification violations should be rejected
+func (o Options) strict() bool {
+	return o.Strictness == Strict
+}
+
+// normalizeTags removes null bytes and trims surrounding whitespace from each value of the input tag
+// map in place, if TrimSpace is set
+func (o Options) normalizeTags(tags map[string]string) {
+	if !o.TrimSpace {
+		return
+	}
+
+	for name, value := range tags {
+		tags[name] = o.normalizeTag(value)
+	}
+}
+
+// normalizeTag strips null bytes and leading and trailing whitespace from a tag value, if requested
+func (o Options) normalizeTag(value string) string {
+	if !o.TrimSpace {
+		return value
+	}
+
+	return strings.TrimSpace(strings.Replace(value, "\x00", "", -1))
+}
+
+// validateUTF8 returns whether or not Vorbis comments must be valid UTF-8
+func (o Options) validateUTF8() bool {
+	return o.ValidateUTF8 || o.strict()
 }
 
 // New creates a new audio metadata parser, depending on the magic number detected in the input reader.  If New
 // recognizes the magic number, it will delegate parsing to the appropriate parser.  If it does not recognize the
-// input format, it will return errUnknownFormat, which can be checked using IsUnknownFormat.
+// input format, it will return ErrUnknownFormat, which can be checked using IsUnknownFormat.  If it recognizes
+// a format which taggolib cannot parse, such as MP4 or WavPack, it will return ErrUnsupportedVersion, which can
+// be checked using IsUnsupportedVersion.  In both cases, the reader is returned to its starting position.
 func New(reader io.ReadSeeker) (Parser, error) {
-	// Check for magic numbers
-	magicBuf := make([]byte, 8)
+	return NewWithOptions(reader, Options{})
+}
+
+// NewContext creates a new audio metadata parser in the same way as New, but returns early with the context's
+// error if the input context is canceled or its deadline is exceeded while parsing.  NewContext may be used
+// to bound the time spent parsing a slow or malicious input stream.
+func NewContext(ctx context.Context, reader io.ReadSeeker) (Parser, error) {
+	return NewWithOptions(reader, Options{ctx: ctx})
+}
 
-	// Read first byte to begin checking magic number
-	if _, err := reader.Read(magicBuf[:1]); err != nil {
+// NewReader creates a new audio metadata parser from an input reader which does not support seeking, such as
+// a HTTP response body or a pipe.  NewReader buffers a bounded prefix of the input stream, so tags and properties
+// found in a stream's headers are available, but properties which require seeking to the end of the stream,
+// such as Ogg Vorbis duration and average bitrates, are left unset.
+func NewReader(reader io.Reader) (Parser, error) {
+	// Buffer a bounded prefix of the stream, so a seeker is available to the parsers
+	prefix, err := ioutil.ReadAll(io.LimitReader(reader, streamPrefixSize))
+	if err != nil {
 		return nil, err
 	}
 
-	// Check for FLAC magic number
-	if magicBuf[0] == byte('f') {
-		// Read next 3 bytes for magic number
-		if _, err := reader.Read(magicBuf[1:len(flacMagicNumber)]); err != nil {
-			return nil, err
-		}
+	return NewWithOptions(bytes.NewReader(prefix), Options{streaming: true})
+}
+
+// NewWithOptions creates a new audio metadata parser in the same way as New, but allows the behavior of the
+// parser to be modified using the input options.
+func NewWithOptions(reader io.ReadSeeker, options Options) (Parser, error) {
+	// Stop early if parsing has already been canceled
+	if err := options.err(); err != nil {
+		return nil, err
+	}
+
+	// Record the start of the stream, so magic numbers can be checked at offsets from it
+	start, err := reader.Seek(0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dispatch to the first format with a matching magic number
+	f, err := matchFormat(reader, start)
+	if err != nil {
+		return nil, err
+	}
 
-		// Verify FLAC magic number
-		if bytes.Equal(magicBuf[:len(flacMagicNumber)], flacMagicNumber) {
-			return newFLACParser(reader)
+	return f.newParser(reader, options)
+}
+
+// DetectFormat identifies the format of the input reader using its magic number, without parsing its tags
+// or audio properties.  The reader is returned to its starting position afterward, so it may be passed to
+// New.  If DetectFormat does not recognize the input format, it will return ErrUnknownFormat, which can be
+// checked using IsUnknownFormat.  Formats added using RegisterFormat are identified as FormatUnknown.
+func DetectFormat(reader io.ReadSeeker) (Format, error) {
+	start, err := reader.Seek(0, 1)
+	if err != nil {
+		return FormatUnknown, err
+	}
+
+	f, err := matchFormat(reader, start)
+	if _, serr := reader.Seek(start, 0); serr != nil && err == nil {
+		err = serr
+	}
+	if err != nil {
+		return FormatUnknown, err
+	}
+
+	return f.format, nil
+}
+
+// matchFormat reads the magic number at the start of the input reader, and returns the first registered
+// format which matches it.  The reader is returned to its starting position unless an I/O error occurs.
+// If no registered format matches, matchFormat returns an unsupported version error for a format which
+// can be detected but not parsed, or an unknown format error otherwise.
+func matchFormat(reader io.ReadSeeker, start int64) (formatEntry, error) {
+	// Determine the number of bytes needed to check all registered and unsupported magic numbers
+	formats := registeredFormats()
+	size := 0
+	for _, f := range formats {
+		if n := f.offset + len(f.magic); n > size {
+			size = n
+		}
+	}
+	for _, f := range unsupportedFormats {
+		if n := f.offset + len(f.magic); n > size {
+			size = n
 		}
 	}
 
-	// Check for MP3 magic number
-	if magicBuf[0] == byte('I') {
-		// Read next 2 bytes for magic number
-		if _, err := reader.Read(magicBuf[1:len(mp3MagicNumber)]); err != nil {
-			return nil, err
+	// Read bytes to check magic numbers, allowing streams shorter than the longest magic number
+	magicBuf := make([]byte, size)
+	n, err := io.ReadFull(reader, magicBuf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return formatEntry{}, err
+	}
+	magicBuf = magicBuf[:n]
+
+	// Find the first format with a matching magic number
+	for _, f := range formats {
+		if !f.matches(magicBuf) {
+			continue
 		}
 
-		// Verify MP3 magic number
-		if bytes.Equal(magicBuf[:len(mp3MagicNumber)], mp3MagicNumber) {
-			return newMP3Parser(reader)
+		// Return to the start of the stream, so the parser can read its own magic number
+		if _, err := reader.Seek(start, 0); err != nil {
+			return formatEntry{}, err
 		}
+
+		return f, nil
 	}
 
-	// Check for OGG magic number
-	if magicBuf[0] == byte('O') {
-		// Read next 3 bytes for magic number
-		if _, err := reader.Read(magicBuf[1:len(oggMagicNumber)]); err != nil {
-			return nil, err
-		}
+	// No parser will read the stream, so return to its start, allowing the caller to try other
+	// handling for the stream
+	if _, err := reader.Seek(start, 0); err != nil {
+		return formatEntry{}, err
+	}
 
-		// Verify OGG magic number
-		if bytes.Equal(magicBuf[:len(oggMagicNumber)], oggMagicNumber) {
-			return newOGGVorbisParser(reader)
+	// Report formats which can be detected, but not parsed
+	for _, f := range unsupportedFormats {
+		if f.matches(magicBuf) {
+			return formatEntry{}, TagError{
+				Err:     ErrUnsupportedVersion,
+				Format:  f.name,
+				Details: fmt.Sprintf("detected %s stream, but this format is not supported", f.name),
+			}
 		}
 	}
 
 	// Unrecognized magic number
-	return nil, TagError{
-		Err:     errUnknownFormat,
+	return formatEntry{}, TagError{
+		Err:     ErrUnknownFormat,
 		Format:  "unknown",
 		Details: "unrecognized magic number, cannot parse this stream",
 	}
@@ -2,12 +2,17 @@ package taggolib
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var (
@@ -90,22 +95,22 @@ func TestNew(t *testing.T) {
 		properties []int
 	}{
 		// Check for FLAC file, with hardcoded expected tags and properties
-		{flacFile, &flacParser{}, nil, "reference libFLAC 1.1.4 20070213", []string{"Artist", "Album", "Title"}, []int{5, 202, 16, 44100}},
+		{flacFile, &FLACParser{}, nil, "reference libFLAC 1.1.4 20070213", []string{"Artist", "Album", "Title"}, []int{5, 206, 16, 44100}},
 
 		// Check for MP3 + ID3v2.3 file, with hardcoded expected tags and properties
-		{mp3ID3v23File, &mp3Parser{}, nil, "Lavf53.21.1", []string{"Artist", "Album", "Title"}, []int{5, 32, 16, 44100}},
+		{mp3ID3v23File, &MP3Parser{}, nil, "Lavf53.21.1", []string{"Artist", "Album", "Title"}, []int{5, 32, 16, 44100}},
 
 		// Check for MP3 + ID3v2.4 file, with hardcoded expected tags and properties
-		{mp3ID3v24File, &mp3Parser{}, nil, "MP3FS", []string{"Artist", "Album", "Title"}, []int{5, 320, 16, 44100}},
+		{mp3ID3v24File, &MP3Parser{}, nil, "MP3FS", []string{"Artist", "Album", "Title"}, []int{5, 320, 16, 44100}},
 
 		// Check for MP3 VBR file, with hardcoded expected tags and properties
-		{mp3VBRFile, &mp3Parser{}, nil, "Lavf53.21.1", []string{"Artist", "Album", "Title"}, []int{5, 88, 16, 44100}},
+		{mp3VBRFile, &MP3Parser{}, nil, "Lavf53.21.1", []string{"Artist", "Album", "Title"}, []int{5, 87, 16, 44100}},
 
 		// Check for Ogg Vorbis file, with hardcoded expected tags and properties
-		{oggVorbisFile, &oggVorbisParser{}, nil, "Lavf53.21.1", []string{"Artist", "Album", "Title"}, []int{5, 192, 16, 44100}},
+		{oggVorbisFile, &OggVorbisParser{}, nil, "Lavf53.21.1", []string{"Artist", "Album", "Title"}, []int{5, 192, 16, 44100}},
 
 		// Check for an unknown format
-		{[]byte("nonsense"), nil, errUnknownFormat, "", nil, nil},
+		{[]byte("nonsense"), nil, ErrUnknownFormat, "", nil, nil},
 	}
 
 	// Iterate all tests
@@ -117,7 +122,7 @@ func TestNew(t *testing.T) {
 		parser, err := New(reader)
 		if err != nil {
 			// If an error occurred, check if it was expected
-			if test.err == errUnknownFormat && !IsUnknownFormat(err) {
+			if test.err == ErrUnknownFormat && !IsUnknownFormat(err) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 		}
@@ -180,6 +185,992 @@ func TestNew(t *testing.T) {
 	}
 }
 
+// TestRegisterFormat verifies that New dispatches to formats registered using RegisterFormat
+func TestRegisterFormat(t *testing.T) {
+	// Register a format with a magic number at an offset, which reports the remainder of the stream
+	errRegistered := errors.New("registered format")
+	var remainder []byte
+	RegisterFormat([]byte("TGLB"), 2, func(reader io.ReadSeeker) (Parser, error) {
+		b, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		remainder = b
+		return nil, errRegistered
+	})
+
+	if _, err := New(bytes.NewReader([]byte("xxTGLBdata"))); err != errRegistered {
+		t.Fatalf("expected registered format error, got: %v", err)
+	}
+
+	if string(remainder) != "xxTGLBdata" {
+		t.Fatalf("mismatched stream remainder: %q", remainder)
+	}
+
+	// Streams shorter than the registered magic number must still be detected as unknown
+	if _, err := New(bytes.NewReader([]byte("xxTG"))); !IsUnknownFormat(err) {
+		t.Fatalf("expected unknown format error, got: %v", err)
+	}
+}
+
+// TestDetectFormat verifies that DetectFormat identifies formats using their magic numbers, and
+// returns the reader to its starting position
+func TestDetectFormat(t *testing.T) {
+	var tests = []struct {
+		stream []byte
+		format Format
+		err    bool
+	}{
+		{flacFile, FormatFLAC, false},
+		{mp3ID3v24File, FormatMP3, false},
+		{[]byte{0xff, 0xfb, 0x90, 0x00}, FormatMP3, false},
+		{oggVorbisFile, FormatOggVorbis, false},
+		{[]byte("nonsense"), FormatUnknown, true},
+	}
+
+	for i, test := range tests {
+		// Begin detection at an offset, to ensure the starting position is respected
+		reader := bytes.NewReader(append([]byte("junk"), test.stream...))
+		if _, err := reader.Seek(4, 0); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		format, err := DetectFormat(reader)
+		if test.err && !IsUnknownFormat(err) {
+			t.Fatalf("[%02d] expected unknown format error, got: %v", i, err)
+		}
+		if !test.err && err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if format != test.format {
+			t.Fatalf("[%02d] mismatched Format: %v != %v", i, format, test.format)
+		}
+
+		if pos, _ := reader.Seek(0, 1); pos != 4 {
+			t.Fatalf("[%02d] reader not returned to starting position: %d", i, pos)
+		}
+	}
+}
+
+// TestNewRestoresPosition verifies that New returns the reader to its starting position when the
+// format of a stream is unknown or unsupported
+func TestNewRestoresPosition(t *testing.T) {
+	for i, stream := range [][]byte{[]byte("nonsense"), []byte("wvpk")} {
+		reader := bytes.NewReader(append([]byte("junk"), stream...))
+		if _, err := reader.Seek(4, 0); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if _, err := New(reader); !IsUnknownFormat(err) && !IsUnsupportedVersion(err) {
+			t.Fatalf("[%02d] expected unknown format or unsupported version error, got: %v", i, err)
+		}
+
+		if pos, _ := reader.Seek(0, 1); pos != 4 {
+			t.Fatalf("[%02d] reader not returned to starting position: %d", i, pos)
+		}
+	}
+}
+
+// TestNewUnsupportedFormat verifies that New reports streams of formats which it can detect, but
+// cannot parse, as unsupported rather than unknown
+func TestNewUnsupportedFormat(t *testing.T) {
+	var tests = []struct {
+		stream []byte
+		format string
+	}{
+		{[]byte("FORM\x00\x00\x00\x00AIFF"), "AIFF"},
+		{[]byte("\x00\x00\x00\x20ftypM4A "), "MP4"},
+		{[]byte("MPCK"), "Musepack"},
+		{[]byte("RIFF\x00\x00\x00\x00WAVE"), "RIFF"},
+		{[]byte("wvpk"), "WavPack"},
+	}
+
+	for i, test := range tests {
+		_, err := New(bytes.NewReader(test.stream))
+		if !IsUnsupportedVersion(err) {
+			t.Fatalf("[%02d] expected unsupported version error, got: %v", i, err)
+		}
+
+		if format := err.(TagError).Format; format != test.format {
+			t.Fatalf("[%02d] mismatched format: %v != %v", i, format, test.format)
+		}
+	}
+}
+
+// TestTagErrorUnwrap verifies that errors returned by New may be checked against the exported
+// sentinel errors using errors.Is, including when wrapped by another error
+func TestTagErrorUnwrap(t *testing.T) {
+	var tests = []struct {
+		stream []byte
+		err    error
+	}{
+		{[]byte("xxxx"), ErrUnknownFormat},
+		{[]byte("wvpk"), ErrUnsupportedVersion},
+		{append([]byte("TTA1\x01\x00"), make([]byte, 16)...), ErrInvalidStream},
+	}
+
+	for i, test := range tests {
+		_, err := New(bytes.NewReader(test.stream))
+		if !errors.Is(err, test.err) {
+			t.Fatalf("[%02d] expected %v, got: %v", i, test.err, err)
+		}
+
+		wrapped := fmt.Errorf("parse: %w", err)
+		if !errors.Is(wrapped, test.err) {
+			t.Fatalf("[%02d] expected wrapped %v, got: %v", i, test.err, wrapped)
+		}
+
+		// The convenience methods classify wrapped errors in the same way
+		checks := map[error]func(error) bool{
+			ErrInvalidStream:      IsInvalidStream,
+			ErrUnknownFormat:      IsUnknownFormat,
+			ErrUnsupportedVersion: IsUnsupportedVersion,
+		}
+		for e, check := range checks {
+			if check(wrapped) != (e == test.err) {
+				t.Fatalf("[%02d] mismatched classification of wrapped %v as %v", i, test.err, e)
+			}
+		}
+
+		var tagErr TagError
+		if !errors.As(err, &tagErr) || tagErr.Unwrap() != test.err {
+			t.Fatalf("[%02d] expected TagError, got: %v", i, err)
+		}
+	}
+}
+
+// TestWalk verifies that Walk parses each recognized file in a file tree, and only reports files
+// which are not in a recognized format when requested
+func TestWalk(t *testing.T) {
+	root, err := ioutil.TempDir("", "taggolib")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string][]byte{
+		"tone.flac":           flacFile,
+		"notes.txt":           []byte("nonsense"),
+		"album/tone.ogg":      oggVorbisFile,
+		"album/disc/tone.mp3": mp3ID3v24File,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var tests = []struct {
+		options WalkOptions
+		formats map[string]Format
+	}{
+		{WalkOptions{}, map[string]Format{
+			"tone.flac":           FormatFLAC,
+			"album/tone.ogg":      FormatOggVorbis,
+			"album/disc/tone.mp3": FormatMP3,
+		}},
+		{WalkOptions{ReportUnknown: true}, map[string]Format{
+			"tone.flac":           FormatFLAC,
+			"notes.txt":           FormatUnknown,
+			"album/tone.ogg":      FormatOggVorbis,
+			"album/disc/tone.mp3": FormatMP3,
+		}},
+	}
+
+	for i, test := range tests {
+		formats := map[string]Format{}
+		err := WalkWithOptions(root, test.options, func(path string, p Parser, err error) error {
+			name, _ := filepath.Rel(root, path)
+			if err != nil {
+				if !IsUnknownFormat(err) {
+					return err
+				}
+
+				formats[filepath.ToSlash(name)] = FormatUnknown
+				return nil
+			}
+
+			formats[filepath.ToSlash(name)] = p.FormatID()
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(formats, test.formats) {
+			t.Fatalf("[%02d] unexpected formats:\n- want: %v\n-  got: %v", i, test.formats, formats)
+		}
+	}
+
+	// Errors returned by the callback stop the walk
+	errStop := errors.New("stop")
+	if err := Walk(root, func(string, Parser, error) error { return errStop }); err != errStop {
+		t.Fatalf("expected stop error, got: %v", err)
+	}
+}
+
+// TestParseFiles verifies that ParseFiles parses each file concurrently, reporting errors for each file
+// which cannot be parsed, and stops when its context is canceled
+func TestParseFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "taggolib")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string][]byte{
+		"tone.flac": flacFile,
+		"tone.mp3":  mp3ID3v24File,
+		"tone.ogg":  oggVorbisFile,
+		"notes.txt": []byte("nonsense"),
+	}
+
+	var paths []string
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	paths = append(paths, filepath.Join(root, "missing.flac"))
+
+	results := map[string]Result{}
+	for r := range ParseFiles(paths, 2) {
+		results[filepath.Base(r.Path)] = r
+	}
+
+	if len(results) != len(paths) {
+		t.Fatalf("mismatched result count: %v != %v", len(results), len(paths))
+	}
+
+	for name, format := range map[string]Format{"tone.flac": FormatFLAC, "tone.mp3": FormatMP3, "tone.ogg": FormatOggVorbis} {
+		if r := results[name]; r.Err != nil || r.Parser.FormatID() != format {
+			t.Fatalf("unexpected result for %s: %v, %v", name, r.Parser, r.Err)
+		}
+	}
+
+	if !IsUnknownFormat(results["notes.txt"].Err) {
+		t.Fatalf("expected unknown format error, got: %v", results["notes.txt"].Err)
+	}
+
+	if !os.IsNotExist(results["missing.flac"].Err) {
+		t.Fatalf("expected not exist error, got: %v", results["missing.flac"].Err)
+	}
+
+	// A canceled context stops parsing, and any results sent report cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for r := range ParseFilesContext(ctx, paths, 2) {
+		if r.Err != context.Canceled {
+			t.Fatalf("expected context canceled error, got: %v", r.Err)
+		}
+	}
+}
+
+// TestParserMagicNumber verifies that parsers read and verify their own magic numbers
+func TestParserMagicNumber(t *testing.T) {
+	var tests = []struct {
+		stream    []byte
+		newParser func(io.ReadSeeker) error
+	}{
+		{flacFile, func(r io.ReadSeeker) error {
+			_, err := newFLACParser(r, Options{})
+			return err
+		}},
+		{mp3ID3v24File, func(r io.ReadSeeker) error {
+			_, err := newMP3Parser(r, Options{})
+			return err
+		}},
+		{oggVorbisFile, func(r io.ReadSeeker) error {
+			_, err := newOGGVorbisParser(r, Options{})
+			return err
+		}},
+	}
+
+	for i, test := range tests {
+		// Parsers accept streams positioned at the start of the magic number
+		if err := test.newParser(bytes.NewReader(test.stream)); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		// Parsers reject streams with a corrupt magic number
+		stream := make([]byte, len(test.stream))
+		copy(stream, test.stream)
+		stream[1] = 'X'
+
+		if err := test.newParser(bytes.NewReader(stream)); !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+	}
+}
+
+// TestNewContext verifies that NewContext stops parsing when its context is canceled
+func TestNewContext(t *testing.T) {
+	for _, stream := range [][]byte{flacFile, mp3ID3v23File, mp3ID3v24File, mp3VBRFile, oggVorbisFile} {
+		if _, err := NewContext(context.Background(), bytes.NewReader(stream)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := NewContext(ctx, bytes.NewReader(stream)); err != context.Canceled {
+			t.Fatalf("expected context canceled error, got: %v", err)
+		}
+	}
+}
+
+// TestNewReader verifies that NewReader parses tags and header properties from streams which
+// do not support seeking
+func TestNewReader(t *testing.T) {
+	for _, stream := range [][]byte{flacFile, mp3ID3v23File, mp3ID3v24File, mp3VBRFile, oggVorbisFile} {
+		parser, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// bytes.Buffer does not implement io.Seeker
+		streamParser, err := NewReader(bytes.NewBuffer(stream))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if streamParser.Format() != parser.Format() {
+			t.Fatalf("mismatched property Format: %v != %v", streamParser.Format(), parser.Format())
+		}
+
+		if streamParser.Artist() != parser.Artist() {
+			t.Fatalf("mismatched tag Artist: %v != %v", streamParser.Artist(), parser.Artist())
+		}
+
+		if streamParser.SampleRate() != parser.SampleRate() {
+			t.Fatalf("mismatched property SampleRate: %v != %v", streamParser.SampleRate(), parser.SampleRate())
+		}
+
+		if streamParser.Channels() != parser.Channels() {
+			t.Fatalf("mismatched property Channels: %v != %v", streamParser.Channels(), parser.Channels())
+		}
+	}
+
+	// Ogg Vorbis duration requires seeking to the end of the stream, so it is left unset
+	ogg, err := NewReader(bytes.NewBuffer(oggVorbisFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ogg.Duration() != 0 {
+		t.Fatalf("mismatched property Duration: %v", ogg.Duration())
+	}
+}
+
+// TestParserVisitTags verifies that VisitTags visits each tag exactly as Tag returns it,
+// and that iteration stops when the callback returns false
+func TestParserVisitTags(t *testing.T) {
+	// Check all available test files
+	for _, stream := range [][]byte{flacFile, mp3ID3v23File, mp3ID3v24File, mp3VBRFile, oggVorbisFile} {
+		parser, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Visit all tags, verifying they match the raw tag accessor
+		var visited int
+		parser.VisitTags(func(name, value string) bool {
+			if parser.Tag(name) != value {
+				t.Fatalf("mismatched visited tag %s: %v != %v", name, value, parser.Tag(name))
+			}
+
+			visited++
+			return true
+		})
+
+		// Every test file contains at least an artist, album, and title
+		if visited < 3 {
+			t.Fatalf("too few tags visited: %d", visited)
+		}
+
+		// Stop after the first tag
+		visited = 0
+		parser.VisitTags(func(name, value string) bool {
+			visited++
+			return false
+		})
+
+		if visited != 1 {
+			t.Fatalf("VisitTags did not stop early: visited %d tags", visited)
+		}
+	}
+}
+
+// TestParserHeaderFingerprint verifies that HeaderFingerprint is stable for the same input stream,
+// and differs between input streams
+func TestParserHeaderFingerprint(t *testing.T) {
+	seen := map[string]bool{}
+
+	// Check all available test files
+	for _, stream := range [][]byte{flacFile, mp3ID3v23File, mp3ID3v24File, mp3VBRFile, oggVorbisFile} {
+		// Parse the same stream twice
+		first, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		second, err := New(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Fingerprint should be stable
+		fingerprint := first.HeaderFingerprint()
+		if !bytes.Equal(fingerprint, second.HeaderFingerprint()) {
+			t.Fatalf("unstable fingerprint for %s: %x != %x", first.Format(), fingerprint, second.HeaderFingerprint())
+		}
+
+		// Fingerprint should be unique among test files
+		if seen[string(fingerprint)] {
+			t.Fatalf("duplicate fingerprint for %s: %x", first.Format(), fingerprint)
+		}
+		seen[string(fingerprint)] = true
+	}
+}
+
+// TestIsRenamed verifies that IsRenamed properly compares an embedded original filename
+// against an input path
+func TestIsRenamed(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		original string
+		path     string
+		renamed  bool
+	}{
+		// No original filename embedded
+		{"", "/music/song.flac", false},
+		// Original filename matches
+		{"song.flac", "/music/song.flac", false},
+		// Original filename with directory matches
+		{"C:/rips/song.flac", "/music/song.flac", false},
+		// Original filename differs
+		{"song.flac", "/music/01 - Song.flac", true},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		parser := &FLACParser{tags: map[string]string{
+			tagOriginalFilename: test.original,
+		}}
+
+		if renamed := IsRenamed(parser, test.path); renamed != test.renamed {
+			t.Fatalf("unexpected IsRenamed result for %q and %q: %v != %v", test.original, test.path, renamed, test.renamed)
+		}
+	}
+}
+
+// TestParserPublisher verifies that Publisher resolves through all record label tag variants
+func TestParserPublisher(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		tags      map[string]string
+		publisher string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{tagPublisher: "Publisher"}, "Publisher"},
+		{map[string]string{tagLabel: "Label"}, "Label"},
+		{map[string]string{tagOrganization: "Organization"}, "Organization"},
+		{map[string]string{tagPublisher: "", tagLabel: "Label", tagOrganization: "Organization"}, "Label"},
+		{map[string]string{tagPublisher: "Publisher", tagLabel: "Label"}, "Publisher"},
+	}
+
+	// Iterate all tests, checking each parser
+	for _, test := range tests {
+		for _, parser := range []Parser{&FLACParser{tags: test.tags}, &MP3Parser{tags: test.tags}, &OggVorbisParser{tags: test.tags}} {
+			if parser.Publisher() != test.publisher {
+				t.Fatalf("mismatched tag Publisher: %v != %v", parser.Publisher(), test.publisher)
+			}
+		}
+	}
+}
+
+// TestParserInitialKey verifies that InitialKey resolves through all musical key tag variants
+func TestParserInitialKey(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		tags map[string]string
+		key  string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{tagInitialKey: "Am"}, "Am"},
+		{map[string]string{tagKey: "8A"}, "8A"},
+		{map[string]string{tagInitialKey: "Am", tagKey: "8A"}, "Am"},
+	}
+
+	// Iterate all tests, checking each parser
+	for _, test := range tests {
+		for _, parser := range []Parser{&FLACParser{tags: test.tags}, &MP3Parser{tags: test.tags}, &OggVorbisParser{tags: test.tags}} {
+			if parser.InitialKey() != test.key {
+				t.Fatalf("mismatched tag InitialKey: %v != %v", parser.InitialKey(), test.key)
+			}
+		}
+	}
+}
+
+// TestParserRemixer verifies that Remixer resolves through all remixer tag variants
+func TestParserRemixer(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		tags    map[string]string
+		remixer string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{tagRemixer: "Remixer"}, "Remixer"},
+		{map[string]string{tagMixArtist: "MixArtist"}, "MixArtist"},
+		{map[string]string{tagRemixer: "Remixer", tagMixArtist: "MixArtist"}, "Remixer"},
+	}
+
+	// Iterate all tests, checking each parser
+	for _, test := range tests {
+		for _, parser := range []Parser{&FLACParser{tags: test.tags}, &MP3Parser{tags: test.tags}, &OggVorbisParser{tags: test.tags}} {
+			if parser.Remixer() != test.remixer {
+				t.Fatalf("mismatched tag Remixer: %v != %v", parser.Remixer(), test.remixer)
+			}
+		}
+	}
+}
+
+// TestParserString verifies that String summarizes a parsed stream, and that all parsers
+// implement fmt.Stringer
+func TestParserString(t *testing.T) {
+	parser, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var stringer fmt.Stringer = parser
+	if s := stringer.String(); s != "Artist - Album - Title [FLAC/206kbps/16bit/44kHz/00:05]" {
+		t.Fatalf("unexpected String result: %v", s)
+	}
+}
+
+// TestParserProperties verifies that Properties returns the same values as the individual
+// property methods
+func TestParserProperties(t *testing.T) {
+	for _, file := range [][]byte{flacFile, mp3ID3v23File, oggVorbisFile} {
+		parser, err := New(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		properties := AudioProperties{
+			BitDepth:      parser.BitDepth(),
+			Bitrate:       parser.Bitrate(),
+			ChannelLayout: parser.ChannelLayout(),
+			Channels:      parser.Channels(),
+			Duration:      parser.Duration(),
+			Encoder:       parser.Encoder(),
+			Format:        parser.Format(),
+			SampleCount:   parser.SampleCount(),
+			SampleFormat:  parser.SampleFormat(),
+			SampleRate:    parser.SampleRate(),
+		}
+		if p := parser.Properties(); p != properties {
+			t.Fatalf("mismatched Properties for %s: %+v != %+v", parser.Format(), p, properties)
+		}
+	}
+}
+
+// TestParserRaw verifies that Raw returns copies of the format-specific headers of each test file
+func TestParserRaw(t *testing.T) {
+	flac, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	streamInfo, ok := flac.Raw().(FLACStreamInfo)
+	if !ok {
+		t.Fatalf("unexpected Raw type: %T", flac.Raw())
+	}
+	if streamInfo.SampleRate != 44100 || streamInfo.MD5Checksum == "" {
+		t.Fatalf("mismatched FLACStreamInfo: %+v", streamInfo)
+	}
+
+	// Modifying the copy must not modify the parser
+	streamInfo.SampleRate = 0
+	if flac.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", flac.SampleRate())
+	}
+
+	mp3, err := New(bytes.NewReader(mp3ID3v23File))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header, ok := mp3.Raw().(MP3Header); !ok || header.MPEGVersionID != 3 || header.MPEGLayerID != 1 {
+		t.Fatalf("mismatched MP3Header: %+v", mp3.Raw())
+	}
+
+	ogg, err := New(bytes.NewReader(oggVorbisFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header, ok := ogg.Raw().(OggVorbisIDHeader); !ok || header.SampleRate != 44100 || header.Blocksize0 > header.Blocksize1 {
+		t.Fatalf("mismatched OggVorbisIDHeader: %+v", ogg.Raw())
+	}
+}
+
+// TestParserReset verifies that Reset parses a new stream, discarding the state of the previous one
+func TestParserReset(t *testing.T) {
+	var tests = []struct {
+		first  []byte
+		second []byte
+	}{
+		{flacFile, flacFile},
+		{mp3ID3v23File, mp3ID3v24File},
+		{oggVorbisFile, oggVorbisFile},
+	}
+
+	for i, test := range tests {
+		parser, err := New(bytes.NewReader(test.first))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		fingerprint := parser.HeaderFingerprint()
+
+		// Reset with the second stream, and compare against a new parser for it
+		if err := parser.Reset(bytes.NewReader(test.second)); err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		expected, err := New(bytes.NewReader(test.second))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(parser.Properties(), expected.Properties()) {
+			t.Fatalf("[%02d] mismatched Properties: %+v != %+v", i, parser.Properties(), expected.Properties())
+		}
+
+		if !bytes.Equal(parser.HeaderFingerprint(), expected.HeaderFingerprint()) {
+			t.Fatalf("[%02d] mismatched HeaderFingerprint: %x != %x", i, parser.HeaderFingerprint(), expected.HeaderFingerprint())
+		}
+
+		if bytes.Equal(test.first, test.second) != bytes.Equal(fingerprint, parser.HeaderFingerprint()) {
+			t.Fatalf("[%02d] unexpected HeaderFingerprint after Reset: %x", i, parser.HeaderFingerprint())
+		}
+	}
+
+	// Reset with a stream of a different format fails
+	parser, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := parser.Reset(bytes.NewReader(oggVorbisFile)); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestParserSampleCount verifies that SampleCount returns the number of samples in each test file
+func TestParserSampleCount(t *testing.T) {
+	var tests = []struct {
+		stream  []byte
+		samples uint64
+	}{
+		{flacFile, 220500},
+		{mp3ID3v23File, 222336},
+		{mp3ID3v24File, 220500},
+		{mp3VBRFile, 222336},
+		{oggVorbisFile, 220544},
+	}
+
+	for i, test := range tests {
+		parser, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if samples := parser.SampleCount(); samples != test.samples {
+			t.Fatalf("[%02d] mismatched property SampleCount: %v != %v", i, samples, test.samples)
+		}
+	}
+}
+
+// TestParserFormatID verifies that each parser returns the Format of its stream, and that the name
+// of each Format matches the name returned by Format
+func TestParserFormatID(t *testing.T) {
+	var tests = []struct {
+		stream      []byte
+		format      Format
+		contentType string
+	}{
+		{flacFile, FormatFLAC, "audio/flac"},
+		{mp3ID3v23File, FormatMP3, "audio/mpeg"},
+		{oggVorbisFile, FormatOggVorbis, "audio/ogg"},
+	}
+
+	for i, test := range tests {
+		parser, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if parser.ContentType() != test.contentType {
+			t.Fatalf("[%02d] mismatched ContentType: %v != %v", i, parser.ContentType(), test.contentType)
+		}
+
+		if parser.FormatID() != test.format {
+			t.Fatalf("[%02d] mismatched FormatID: %v != %v", i, parser.FormatID(), test.format)
+		}
+
+		if parser.Format() != test.format.String() {
+			t.Fatalf("[%02d] mismatched Format: %v != %v", i, parser.Format(), test.format.String())
+		}
+	}
+
+	if FormatUnknown.String() != "unknown" {
+		t.Fatalf("mismatched FormatUnknown string: %v", FormatUnknown.String())
+	}
+
+	if FormatUnknown.ContentType() != "application/octet-stream" {
+		t.Fatalf("mismatched FormatUnknown content type: %v", FormatUnknown.ContentType())
+	}
+}
+
+// TestParserRawTagBlock verifies that parsers return the raw bytes of the metadata region of their
+// streams, which directly precede the audio data
+func TestParserRawTagBlock(t *testing.T) {
+	// The ID3v2 tag of the MP3 test file has no footer, so its size is its header and frames
+	id3Size := 10 + (int(mp3ID3v23File[6])<<21 | int(mp3ID3v23File[7])<<14 | int(mp3ID3v23File[8])<<7 | int(mp3ID3v23File[9]))
+
+	// The headers of the Ogg Vorbis test file are followed by audio pages
+	oggHeaders := bytes.Index(oggVorbisFile[2919:], oggMagicNumber) + 2919
+
+	var tests = []struct {
+		stream []byte
+		size   int
+	}{
+		{flacFile, 8304},
+		{mp3ID3v23File, id3Size},
+		{mp3ID3v23File[bytes.IndexByte(mp3ID3v23File, 255):], 0},
+		{oggVorbisFile, oggHeaders},
+	}
+
+	for i, test := range tests {
+		parser, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		block, err := parser.(interface {
+			RawTagBlock() ([]byte, error)
+		}).RawTagBlock()
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if !bytes.Equal(block, test.stream[:test.size]) {
+			t.Fatalf("[%02d] mismatched RawTagBlock: %d bytes != %d bytes", i, len(block), test.size)
+		}
+	}
+}
+
+// TestParserSampleFormat verifies that each parser returns the format of its decoded samples
+func TestParserSampleFormat(t *testing.T) {
+	var tests = []struct {
+		stream []byte
+		format SampleFormat
+	}{
+		{flacFile, SampleFormatSignedInt},
+		{mp3ID3v23File, SampleFormatUnknown},
+		{oggVorbisFile, SampleFormatUnknown},
+	}
+
+	for i, test := range tests {
+		parser, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if format := parser.SampleFormat(); format != test.format {
+			t.Fatalf("[%02d] mismatched SampleFormat: %v != %v", i, format, test.format)
+		}
+	}
+
+	// Samples from WAV files are unsigned only at 8 bits
+	if format := wavSampleFormat(8); format != SampleFormatUnsignedInt {
+		t.Fatalf("mismatched 8-bit WAV sample format: %v", format)
+	}
+	if format := wavSampleFormat(16); format != SampleFormatSignedInt {
+		t.Fatalf("mismatched 16-bit WAV sample format: %v", format)
+	}
+}
+
+// TestParserChannelLayout verifies that each parser returns the name of its channel layout, and that
+// MP3 streams distinguish joint stereo from stereo
+func TestParserChannelLayout(t *testing.T) {
+	var tests = []struct {
+		stream []byte
+		layout string
+	}{
+		{flacFile, "stereo"},
+		{mp3ID3v23File, "stereo"},
+		{mp3ID3v24File, "joint stereo"},
+		{oggVorbisFile, "stereo"},
+	}
+
+	for i, test := range tests {
+		parser, err := New(bytes.NewReader(test.stream))
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if layout := parser.ChannelLayout(); layout != test.layout {
+			t.Fatalf("[%02d] mismatched ChannelLayout: %v != %v", i, layout, test.layout)
+		}
+	}
+
+	for channels, layout := range map[int]string{0: "", 1: "mono", 6: "5.1", 8: "7.1", 9: ""} {
+		if l := channelLayout(channels); l != layout {
+			t.Fatalf("mismatched channel layout for %d channels: %v != %v", channels, l, layout)
+		}
+	}
+}
+
+// TestSamplesDuration verifies that samplesDuration calculates durations with sub-second precision
+func TestSamplesDuration(t *testing.T) {
+	var tests = []struct {
+		samples    uint64
+		sampleRate uint64
+		duration   time.Duration
+	}{
+		{220500, 44100, 5 * time.Second},
+		{238140, 44100, 5400 * time.Millisecond},
+		{22050, 44100, 500 * time.Millisecond},
+		{1 << 36, 48000, 1431655765333333},
+		{44100, 0, 0},
+	}
+
+	for i, test := range tests {
+		if duration := samplesDuration(test.samples, test.sampleRate); duration != test.duration {
+			t.Fatalf("[%02d] mismatched duration: %v != %v", i, duration, test.duration)
+		}
+	}
+}
+
+// TestParseTotal verifies that parseTotal properly reconciles combined and explicit totals
+func TestParseTotal(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		combined string
+		total    string
+		result   int
+	}{
+		// Neither present
+		{"", "", 0},
+		// Number with no total
+		{"3", "", 0},
+		// Combined form only
+		{"3/12", "", 12},
+		// Explicit total only
+		{"3", "12", 12},
+		// Both present and in agreement
+		{"3/12", "12", 12},
+		// Both present and in disagreement, explicit total preferred
+		{"3/12", "13", 13},
+		// Invalid explicit total, combined form used
+		{"3/12", "twelve", 12},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		if result := parseTotal(test.combined, test.total); result != test.result {
+			t.Fatalf("unexpected total for %q and %q: %d != %d", test.combined, test.total, result, test.result)
+		}
+	}
+}
+
+// TestParseYear verifies that parseYear extracts years from various date formats
+func TestParseYear(t *testing.T) {
+	var tests = []struct {
+		date string
+		year int
+	}{
+		{"2014", 2014},
+		{"2014-01-02", 2014},
+		{"2014-01-02T03:04:05", 2014},
+		{"02/01/2014", 2014},
+		{"20140102", 0},
+		{"14", 0},
+		{"", 0},
+		{"unknown", 0},
+	}
+
+	for _, test := range tests {
+		if year := parseYear(test.date); year != test.year {
+			t.Fatalf("unexpected year for %q: %v != %v", test.date, year, test.year)
+		}
+	}
+}
+
+// TestParseReplayGain verifies that parseReplayGain properly parses ReplayGain tag values
+func TestParseReplayGain(t *testing.T) {
+	// Table of tests
+	var tests = []struct {
+		value string
+		gain  float64
+		ok    bool
+	}{
+		{"-3.21 dB", -3.21, true},
+		{"+1.50 dB", 1.5, true},
+		{"-7.03dB", -7.03, true},
+		{"0.988553", 0.988553, true},
+		{"", 0, false},
+		{"loud", 0, false},
+	}
+
+	// Iterate all tests
+	for _, test := range tests {
+		gain, ok := parseReplayGain(test.value)
+		if gain != test.gain || ok != test.ok {
+			t.Fatalf("unexpected ReplayGain for %q: %v, %v != %v, %v", test.value, gain, ok, test.gain, test.ok)
+		}
+	}
+}
+
+// TestParseVorbisComment verifies that parseVorbisComment properly splits Vorbis comments
+func TestParseVorbisComment(t *testing.T) {
+	var tests = []struct {
+		comment string
+		options Options
+		name    string
+		tag     string
+		ok      bool
+	}{
+		{"artist=Artist", Options{}, "ARTIST", "Artist", true},
+		{"TITLE=a=b", Options{}, "TITLE", "a=b", true},
+		{"TITLE=", Options{}, "TITLE", "", true},
+		{"TITLE", Options{}, "", "", false},
+		{"TITLE=\xff", Options{}, "TITLE", "\xff", true},
+		{"TITLE=\xff", Options{Strictness: Strict}, "", "", false},
+		{"TITLE=\xff", Options{ValidateUTF8: true}, "", "", false},
+	}
+
+	for i, test := range tests {
+		name, tag, ok := parseVorbisComment(test.comment, test.options)
+		if name != test.name || tag != test.tag || ok != test.ok {
+			t.Fatalf("[%02d] unexpected result: %q, %q, %v != %q, %q, %v",
+				i, name, tag, ok, test.name, test.tag, test.ok)
+		}
+	}
+}
+
 // BenchmarkNewFLAC checks the performance of the New() function with a FLAC file
 func BenchmarkNewFLAC(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -214,3 +1205,99 @@ func BenchmarkNewOGGVorbis(b *testing.B) {
 		New(bytes.NewReader(oggVorbisFile))
 	}
 }
+
+// TestNewWithOptionsTrimSpace verifies that TrimSpace removes surrounding whitespace and null bytes
+// from tag values, and that tag values are unmodified by default
+func TestNewWithOptionsTrimSpace(t *testing.T) {
+	stream := mp3ID3v23Stream(
+		mp3ID3v23Frame("TIT2", []byte("\x00 Title \t")),
+		mp3ID3v23Frame("TPE1", []byte("\x00Art\x00ist\x00")),
+	)
+
+	// Table of tests
+	var tests = []struct {
+		options Options
+		title   string
+		artist  string
+	}{
+		{Options{}, " Title \t", "Art\x00ist"},
+		{Options{TrimSpace: true}, "Title", "Artist"},
+	}
+
+	// Iterate all tests
+	for i, test := range tests {
+		parser, err := NewWithOptions(bytes.NewReader(stream), test.options)
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if parser.Title() != test.title {
+			t.Fatalf("[%02d] mismatched tag Title: %q != %q", i, parser.Title(), test.title)
+		}
+
+		if parser.Artist() != test.artist {
+			t.Fatalf("[%02d] mismatched tag Artist: %q != %q", i, parser.Artist(), test.artist)
+		}
+	}
+}
+
+// TestNewWithOptionsTagsOnly verifies that tags are parsed, but durations are left unset, when
+// only tags are requested
+func TestNewWithOptionsTagsOnly(t *testing.T) {
+	for i, file := range [][]byte{flacFile, mp3VBRFile, oggVorbisFile} {
+		parser, err := NewWithOptions(bytes.NewReader(file), Options{TagsOnly: true})
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		if parser.Title() == "" {
+			t.Fatalf("[%02d] empty tag Title", i)
+		}
+
+		if parser.Duration() != 0 {
+			t.Fatalf("[%02d] unexpected property Duration: %v", i, parser.Duration())
+		}
+	}
+}
+
+// BenchmarkResetFLAC checks the performance of reusing a parser with Reset() with a FLAC file
+func BenchmarkResetFLAC(b *testing.B) {
+	parser, err := New(bytes.NewReader(flacFile))
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser.Reset(bytes.NewReader(flacFile))
+	}
+}
+
+// BenchmarkResetOGGVorbis checks the performance of reusing a parser with Reset() with a Ogg Vorbis file
+func BenchmarkResetOGGVorbis(b *testing.B) {
+	parser, err := New(bytes.NewReader(oggVorbisFile))
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser.Reset(bytes.NewReader(oggVorbisFile))
+	}
+}
+
+// BenchmarkResetMP3ID3v24 checks the performance of reusing a parser with Reset() with a MP3 + ID3v2.4 file
+func BenchmarkResetMP3ID3v24(b *testing.B) {
+	parser, err := New(bytes.NewReader(mp3ID3v24File))
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser.Reset(bytes.NewReader(mp3ID3v24File))
+	}
+}
@@ -0,0 +1,454 @@
+package taggolib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// True Audio audio formats
+	ttaFormatPCM       = 1
+	ttaFormatEncrypted = 2
+)
+
+var (
+	// ttaMagicNumber is the magic number used to identify a True Audio stream
+	ttaMagicNumber = []byte("TTA1")
+)
+
+// TTAParser represents a True Audio audio metadata tag parser.  A *TTAParser is returned by New for
+// True Audio streams, and may be type-asserted to access methods specific to the format.
+type TTAParser struct {
+	endPos  int64
+	header  *TTAHeader
+	options Options
+	reader  io.ReadSeeker
+	tags    map[string]string
+}
+
+// init registers the True Audio format with New
+func init() {
+	registerFormat(FormatTTA, ttaMagicNumber, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newTTAParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
+// Album returns the Album tag for this stream
+func (t TTAParser) Album() string {
+	return t.tags[tagAlbum]
+}
+
+// AlbumArtist returns the AlbumArtist tag for this stream
+func (t TTAParser) AlbumArtist() string {
+	return t.tags[tagAlbumArtist]
+}
+
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (t TTAParser) AlbumArtistSort() string {
+	return t.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (t TTAParser) AlbumSort() string {
+	return t.tags[tagAlbumSort]
+}
+
+// Artist returns the Artist tag for this stream
+func (t TTAParser) Artist() string {
+	return t.tags[tagArtist]
+}
+
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (t TTAParser) ArtistSort() string {
+	return t.tags[tagArtistSort]
+}
+
+// BitDepth returns the bits-per-sample of this stream
+func (t TTAParser) BitDepth() int {
+	return int(t.header.BitsPerSample)
+}
+
+// Bitrate calculates the audio bitrate for this stream
+func (t TTAParser) Bitrate() int {
+	// Check for zero duration or end position, to prevent a division-by-zero panic
+	seconds := int64(t.Duration().Seconds())
+	if t.endPos == 0 || seconds == 0 {
+		return 0
+	}
+
+	return int(((t.endPos * 8) / seconds) / 1024)
+}
+
+// BPM returns the BPM (beats per minute) tag for this stream
+func (t TTAParser) BPM() int {
+	bpm, err := strconv.Atoi(t.tags[tagBPM])
+	if err != nil {
+		return 0
+	}
+
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, such as "mono", "stereo", or "5.1"
+func (t TTAParser) ChannelLayout() string {
+	return channelLayout(t.Channels())
+}
+
+// Channels returns the number of channels for this stream
+func (t TTAParser) Channels() int {
+	return int(t.header.Channels)
+}
+
+// Comment returns the Comment tag for this stream
+func (t TTAParser) Comment() string {
+	return t.tags[tagComment]
+}
+
+// Composer returns the Composer tag for this stream
+func (t TTAParser) Composer() string {
+	return t.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (t TTAParser) Conductor() string {
+	return t.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (t TTAParser) ContentType() string {
+	return t.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (t TTAParser) Copyright() string {
+	return t.tags[tagCopyright]
+}
+
+// Date returns the Date tag for this stream
+func (t TTAParser) Date() string {
+	return t.tags[tagDate]
+}
+
+// DiscNumber returns the DiscNumber tag for this stream
+func (t TTAParser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(t.tags[tagDiscNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return disc
+}
+
+// Duration returns the time duration for this stream
+func (t TTAParser) Duration() time.Duration {
+	return samplesDuration(uint64(t.header.SampleCount), uint64(t.header.SampleRate))
+}
+
+// EncodedBy returns the EncodedBy tag for this stream
+func (t TTAParser) EncodedBy() string {
+	return t.tags[tagEncodedBy]
+}
+
+// Encoder returns the encoder for this stream
+func (t TTAParser) Encoder() string {
+	return t.tags[tagEncoder]
+}
+
+// Format returns the name of the True Audio format
+func (t TTAParser) Format() string {
+	return t.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (t TTAParser) FormatID() Format {
+	return FormatTTA
+}
+
+// Genre returns the Genre tag for this stream
+func (t TTAParser) Genre() string {
+	return t.tags[tagGenre]
+}
+
+// Grouping returns the Grouping (content group) tag for this stream
+func (t TTAParser) Grouping() string {
+	return t.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+// BUG(mdlayher): True Audio: cover art stored in binary APEv2 items is not detected
+func (t TTAParser) HasPicture() bool {
+	return false
+}
+
+// HeaderFingerprint returns a stable identifier derived from the header and size of this stream
+func (t TTAParser) HeaderFingerprint() []byte {
+	return headerFingerprint(t.endPos, *t.header)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (t TTAParser) InitialKey() string {
+	return firstTag(t.tags, tagInitialKey, tagKey)
+}
+
+// Language returns the Language tag for this stream
+func (t TTAParser) Language() string {
+	return t.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream
+func (t TTAParser) Lyrics() string {
+	return t.tags[tagLyrics]
+}
+
+// Mood returns the Mood tag for this stream
+func (t TTAParser) Mood() string {
+	return t.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (t TTAParser) OriginalFilename() string {
+	return t.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (t TTAParser) Properties() AudioProperties {
+	return audioProperties(&t)
+}
+
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (t TTAParser) Publisher() string {
+	return firstTag(t.tags, tagPublisher, tagLabel, tagOrganization)
+}
+
+// Raw returns a copy of the format-specific header parsed from this stream, as a TTAHeader
+func (t TTAParser) Raw() interface{} {
+	return *t.header
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (t TTAParser) Remixer() string {
+	return firstTag(t.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (t TTAParser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(t.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (t TTAParser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(t.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (t TTAParser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(t.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (t TTAParser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(t.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new True Audio stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (t *TTAParser) Reset(reader io.ReadSeeker) error {
+	*t = TTAParser{
+		options: t.options,
+		reader:  reader,
+	}
+
+	return t.parse()
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (t TTAParser) SampleCount() uint64 {
+	return uint64(t.header.SampleCount)
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (t TTAParser) SampleFormat() SampleFormat {
+	return wavSampleFormat(t.BitDepth())
+}
+
+// SampleRate returns the sample rate in Hertz for this stream
+func (t TTAParser) SampleRate() int {
+	return int(t.header.SampleRate)
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (t TTAParser) String() string {
+	return summarize(&t)
+}
+
+// Tag attempts to return the raw, unprocessed tag with the specified name for this stream
+func (t TTAParser) Tag(name string) string {
+	return t.tags[strings.ToUpper(name)]
+}
+
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream
+func (t TTAParser) TagMulti(name string) []string {
+	return tagValues(t.tags, name)
+}
+
+// Title returns the Title tag for this stream
+func (t TTAParser) Title() string {
+	return t.tags[tagTitle]
+}
+
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (t TTAParser) TitleSort() string {
+	return t.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (t TTAParser) TotalDiscs() int {
+	return parseTotal(t.tags[tagDiscNumber], firstTag(t.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (t TTAParser) TotalTracks() int {
+	return parseTotal(t.tags[tagTrackNumber], firstTag(t.tags, tagTrackTotal, tagTotalTracks))
+}
+
+// TrackNumber returns the TrackNumber tag for this stream
+func (t TTAParser) TrackNumber() int {
+	// Check for a /, such as 2/8
+	track, err := strconv.Atoi(strings.Split(t.tags[tagTrackNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return track
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (t TTAParser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range t.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (t TTAParser) Work() string {
+	return t.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (t TTAParser) Year() int {
+	return parseYear(t.tags[tagDate])
+}
+
+// newTTAParser creates a parser for True Audio streams
+func newTTAParser(reader io.ReadSeeker, options Options) (*TTAParser, error) {
+	// Create True Audio parser
+	parser := &TTAParser{
+		options: options,
+		reader:  reader,
+	}
+
+	// Parse the stream
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a True Audio stream from the reader of this parser
+func (t *TTAParser) parse() error {
+	// Verify the magic number at the start of the stream
+	if err := readMagicNumber(t.reader, ttaMagicNumber, t.Format()); err != nil {
+		return err
+	}
+
+	// Parse the header for stream properties
+	if err := t.parseHeader(); err != nil {
+		return err
+	}
+
+	// If only a prefix of the stream is available, the APEv2 tag at the end of the stream
+	// cannot be read
+	if t.options.streaming {
+		return nil
+	}
+
+	// Determine the size of the stream
+	n, err := streamSize(t.reader)
+	if err != nil {
+		return err
+	}
+	t.endPos = n
+
+	// Parse tags from the APEv2 tag at the end of the stream
+	// BUG(mdlayher): True Audio: ID3v2 tags, which precede the True Audio header, are not parsed
+	tags, err := parseAPEv2(t.reader)
+	if err != nil && !t.options.lenient() {
+		return err
+	}
+	t.options.normalizeTags(tags)
+	t.tags = tags
+
+	return nil
+}
+
+// TTAHeader represents the stream properties contained in a True Audio header.  A copy of the
+// TTAHeader for a True Audio stream is returned by Raw.
+type TTAHeader struct {
+	AudioFormat   uint16
+	Channels      uint16
+	BitsPerSample uint16
+	SampleRate    uint32
+	SampleCount   uint32
+	CRC32         uint32
+}
+
+// parseHeader parses the header at the start of a True Audio stream
+func (t *TTAParser) parseHeader() error {
+	// Read header fields, following the magic number
+	header := new(TTAHeader)
+	if err := binary.Read(t.reader, binary.LittleEndian, header); err != nil {
+		return err
+	}
+
+	// Ensure audio format is supported
+	if header.AudioFormat != ttaFormatPCM && header.AudioFormat != ttaFormatEncrypted {
+		return TagError{
+			Err:     ErrUnsupportedVersion,
+			Format:  t.Format(),
+			Details: fmt.Sprintf("unsupported audio format: %d", header.AudioFormat),
+		}
+	}
+
+	// Ensure sample rate and channel count are greater than 0, to prevent a division-by-zero
+	// panic when calculating duration
+	if header.SampleRate == 0 || header.Channels == 0 {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  t.Format(),
+			Details: "sample rate and channel count must be greater than 0",
+		}
+	}
+
+	// Store header
+	t.header = header
+	return nil
+}
@@ -0,0 +1,77 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// ttaStream generates a True Audio stream with the specified properties, followed by an APEv2 tag
+// containing the specified items
+func ttaStream(format uint16, sampleRate uint32, sampleCount uint32, items ...[]byte) []byte {
+	header := make([]byte, 22)
+	copy(header, ttaMagicNumber)
+	binary.LittleEndian.PutUint16(header[4:6], format)
+	binary.LittleEndian.PutUint16(header[6:8], 2)
+	binary.LittleEndian.PutUint16(header[8:10], 16)
+	binary.LittleEndian.PutUint32(header[10:14], sampleRate)
+	binary.LittleEndian.PutUint32(header[14:18], sampleCount)
+
+	stream := append(header, make([]byte, 128)...)
+	return append(stream, apev2Tag(items...)...)
+}
+
+// TestTTA verifies that all TTAParser methods work properly
+func TestTTA(t *testing.T) {
+	tta, err := New(bytes.NewReader(ttaStream(ttaFormatPCM, 44100, 44100*5,
+		apev2Item(0, "Artist", "Artist"),
+		apev2Item(0, "Album", "Album"),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := tta.(*TTAParser); !ok {
+		t.Fatalf("unexpected parser type: %T", tta)
+	}
+
+	if tta.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", tta.Artist())
+	}
+
+	if tta.Album() != "Album" {
+		t.Fatalf("mismatched tag Album: %v", tta.Album())
+	}
+
+	if tta.BitDepth() != 16 {
+		t.Fatalf("mismatched property BitDepth: %v", tta.BitDepth())
+	}
+
+	if tta.Channels() != 2 {
+		t.Fatalf("mismatched property Channels: %v", tta.Channels())
+	}
+
+	if tta.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", tta.SampleRate())
+	}
+
+	if tta.Duration() != 5*time.Second {
+		t.Fatalf("mismatched property Duration: %v", tta.Duration())
+	}
+
+	if tta.Format() != "True Audio" {
+		t.Fatalf("mismatched property Format: %v", tta.Format())
+	}
+}
+
+// TestTTAInvalidHeader verifies that True Audio streams with invalid headers are rejected
+func TestTTAInvalidHeader(t *testing.T) {
+	if _, err := New(bytes.NewReader(ttaStream(3, 44100, 44100))); !IsUnsupportedVersion(err) {
+		t.Fatalf("expected unsupported version error, got: %v", err)
+	}
+
+	if _, err := New(bytes.NewReader(ttaStream(ttaFormatPCM, 0, 44100))); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package taggolib
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WalkFunc is the type of the function called by Walk for each file it visits.  If the file was parsed,
+// p is its parser, and err is nil.  Otherwise, err is the error which occurred while opening or parsing
+// the file, which may be checked using IsInvalidStream, IsUnknownFormat, and IsUnsupportedVersion.
+// If WalkFunc returns an error, the walk stops and Walk returns that error.  WalkFunc may return
+// filepath.SkipDir to skip the remaining files in the current directory.
+type WalkFunc func(path string, p Parser, err error) error
+
+// WalkOptions specifies options which modify the behavior of WalkWithOptions
+type WalkOptions struct {
+	// Options specifies the options used to parse each file
+	Options Options
+
+	// ReportUnknown invokes the callback for files which are not in a recognized format, with an
+	// unknown format error, rather than skipping them
+	ReportUnknown bool
+}
+
+// Walk recursively walks the file tree rooted at root, attempting to parse each regular file it finds,
+// and invokes fn with the result.  Files which are not in a recognized format are skipped.  Each file
+// is closed when fn returns, so methods which read from the file, such as Save, must be called within fn.
+func Walk(root string, fn WalkFunc) error {
+	return WalkWithOptions(root, WalkOptions{}, fn)
+}
+
+// WalkWithOptions walks a file tree in the same way as Walk, but allows the behavior of the walk to be
+// modified using the input options.
+func WalkWithOptions(root string, options WalkOptions, fn WalkFunc) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		// Report errors which occur while walking the tree, such as unreadable directories
+		if err != nil {
+			return fn(path, nil, err)
+		}
+
+		// Skip directories and special files
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		return walkFile(path, options, fn)
+	})
+}
+
+// walkFile opens and parses a single file for WalkWithOptions, and invokes fn with the result
+func walkFile(path string, options WalkOptions, fn WalkFunc) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fn(path, nil, err)
+	}
+	defer file.Close()
+
+	parser, err := NewWithOptions(file, options.Options)
+	if err != nil && IsUnknownFormat(err) && !options.ReportUnknown {
+		return nil
+	}
+
+	return fn(path, parser, err)
+}
+
+// Result is the result of parsing a single file using ParseFiles
+type Result struct {
+	// Path is the path of the file
+	Path string
+
+	// Parser is the parser for the file, if it was parsed.  The file is closed once it has been parsed,
+	// so methods which read from the file, such as Save, may not be used.
+	Parser Parser
+
+	// Err is the error which occurred while opening or parsing the file, if any
+	Err error
+}
+
+// ParseFiles opens and parses each of the files in paths concurrently, using the specified number of
+// worker goroutines, and sends the result for each file on the returned channel as it is parsed.  If
+// workers is less than 1, a single worker is used.  The channel is closed once all files have been parsed.
+func ParseFiles(paths []string, workers int) <-chan Result {
+	return ParseFilesContext(context.Background(), paths, workers)
+}
+
+// ParseFilesContext parses files in the same way as ParseFiles, but stops parsing files when the input
+// context is canceled or its deadline is exceeded, and closes the channel without sending results for
+// the remaining files.
+func ParseFilesContext(ctx context.Context, paths []string, workers int) <-chan Result {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make(chan Result)
+	jobs := make(chan string)
+
+	// Dispatch each path to the workers, stopping early if parsing is canceled
+	go func() {
+		defer close(jobs)
+		for _, path := range paths {
+			select {
+			case jobs <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Parse files in each worker, closing the results channel once all workers are finished
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				select {
+				case results <- parseFile(ctx, path):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// parseFile opens and parses a single file for ParseFilesContext, closing the file when finished
+func parseFile(ctx context.Context, path string) Result {
+	// Stop early if parsing has already been canceled
+	if err := ctx.Err(); err != nil {
+		return Result{Path: path, Err: err}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Result{Path: path, Err: err}
+	}
+	defer file.Close()
+
+	parser, err := NewWithOptions(file, Options{ctx: ctx})
+	return Result{
+		Path:   path,
+		Parser: parser,
+		Err:    err,
+	}
+}
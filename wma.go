@@ -0,0 +1,746 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// wmaObjectHeaderSize is the size of the GUID and size fields which begin every ASF object
+	wmaObjectHeaderSize = 24
+	// wmaHeaderObjectSize is the size of the fields which begin the ASF Header Object, before the
+	// objects it contains
+	wmaHeaderObjectSize = wmaObjectHeaderSize + 6
+	// wmaMaxHeaderSize is the maximum size of the objects in an ASF Header Object which are parsed
+	// when the size of the stream is unknown
+	wmaMaxHeaderSize = 64 << 20
+
+	// ASF Extended Content Description value types
+	wmaTypeString = 0
+	wmaTypeBytes  = 1
+	wmaTypeBool   = 2
+	wmaTypeDWORD  = 3
+	wmaTypeQWORD  = 4
+	wmaTypeWORD   = 5
+
+	// Tags specific to WMA
+	wmaTagPicture = "WM/PICTURE"
+)
+
+var (
+	// wmaMagicNumber is the magic number used to identify a WMA stream, which is the GUID of the
+	// ASF Header Object
+	wmaMagicNumber = []byte{0x30, 0x26, 0xb2, 0x75, 0x8e, 0x66, 0xcf, 0x11, 0xa6, 0xd9, 0x00, 0xaa, 0x00, 0x62, 0xce, 0x6c}
+	// wmaFilePropertiesObject is the GUID of the ASF File Properties Object
+	wmaFilePropertiesObject = []byte{0xa1, 0xdc, 0xab, 0x8c, 0x47, 0xa9, 0xcf, 0x11, 0x8e, 0xe4, 0x00, 0xc0, 0x0c, 0x20, 0x53, 0x65}
+	// wmaStreamPropertiesObject is the GUID of the ASF Stream Properties Object
+	wmaStreamPropertiesObject = []byte{0x91, 0x07, 0xdc, 0xb7, 0xb7, 0xa9, 0xcf, 0x11, 0x8e, 0xe6, 0x00, 0xc0, 0x0c, 0x20, 0x53, 0x65}
+	// wmaContentDescriptionObject is the GUID of the ASF Content Description Object
+	wmaContentDescriptionObject = []byte{0x33, 0x26, 0xb2, 0x75, 0x8e, 0x66, 0xcf, 0x11, 0xa6, 0xd9, 0x00, 0xaa, 0x00, 0x62, 0xce, 0x6c}
+	// wmaExtendedContentDescriptionObject is the GUID of the ASF Extended Content Description Object
+	wmaExtendedContentDescriptionObject = []byte{0x40, 0xa4, 0xd0, 0xd2, 0x07, 0xe3, 0xd2, 0x11, 0x97, 0xf0, 0x00, 0xa0, 0xc9, 0x5e, 0xa8, 0x50}
+	// wmaAudioMedia is the GUID which identifies an audio stream in a Stream Properties Object
+	wmaAudioMedia = []byte{0x40, 0x9e, 0x69, 0xf8, 0x4d, 0x5b, 0xcf, 0x11, 0xa8, 0xfd, 0x00, 0x80, 0x5f, 0x5c, 0x44, 0x2b}
+)
+
+// wmaAttributeToTag maps ASF Extended Content Description attribute names to tags
+var wmaAttributeToTag = map[string]string{
+	"WM/ALBUMARTIST":             tagAlbumArtist,
+	"WM/ALBUMARTISTSORTORDER":    tagAlbumArtistSort,
+	"WM/ALBUMSORTORDER":          tagAlbumSort,
+	"WM/ALBUMTITLE":              tagAlbum,
+	"WM/ARTISTSORTORDER":         tagArtistSort,
+	"WM/BEATSPERMINUTE":          tagBPM,
+	"WM/COMPOSER":                tagComposer,
+	"WM/CONDUCTOR":               tagConductor,
+	"WM/CONTENTGROUPDESCRIPTION": tagGrouping,
+	"WM/ENCODEDBY":               tagEncodedBy,
+	"WM/GENRE":                   tagGenre,
+	"WM/INITIALKEY":              tagInitialKey,
+	"WM/LANGUAGE":                tagLanguage,
+	"WM/LYRICS":                  tagLyrics,
+	"WM/MODIFIEDBY":              tagRemixer,
+	"WM/MOOD":                    tagMood,
+	"WM/PARTOFSET":               tagDiscNumber,
+	"WM/PUBLISHER":               tagPublisher,
+	"WM/TITLESORTORDER":          tagTitleSort,
+	"WM/TOOLNAME":                tagEncoder,
+	"WM/TRACKNUMBER":             tagTrackNumber,
+	"WM/YEAR":                    tagDate,
+}
+
+// WMAParser represents a WMA audio metadata tag parser.  A *WMAParser is returned by New for
+// WMA streams, and may be type-asserted to access methods specific to the format.
+type WMAParser struct {
+	endPos           int64
+	fileProperties   *WMAFileProperties
+	hasPicture       bool
+	options          Options
+	reader           io.ReadSeeker
+	streamProperties *WMAStreamProperties
+	tags             map[string]string
+}
+
+// init registers the WMA format with New
+func init() {
+	registerFormat(FormatWMA, wmaMagicNumber, 0, func(reader io.ReadSeeker, options Options) (Parser, error) {
+		parser, err := newWMAParser(reader, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return parser, nil
+	})
+}
+
+// Album returns the Album tag for this stream
+func (w WMAParser) Album() string {
+	return w.tags[tagAlbum]
+}
+
+// AlbumArtist returns the AlbumArtist tag for this stream
+func (w WMAParser) AlbumArtist() string {
+	return w.tags[tagAlbumArtist]
+}
+
+// AlbumArtistSort returns the AlbumArtistSort (album artist sort order) tag for this stream
+func (w WMAParser) AlbumArtistSort() string {
+	return w.tags[tagAlbumArtistSort]
+}
+
+// AlbumSort returns the AlbumSort (album sort order) tag for this stream
+func (w WMAParser) AlbumSort() string {
+	return w.tags[tagAlbumSort]
+}
+
+// Artist returns the Artist tag for this stream
+func (w WMAParser) Artist() string {
+	return w.tags[tagArtist]
+}
+
+// ArtistSort returns the ArtistSort (artist sort order) tag for this stream
+func (w WMAParser) ArtistSort() string {
+	return w.tags[tagArtistSort]
+}
+
+// BitDepth returns the bits-per-sample of this stream
+func (w WMAParser) BitDepth() int {
+	return int(w.streamProperties.BitsPerSample)
+}
+
+// Bitrate returns the audio bitrate for this stream, from the stream's file properties
+func (w WMAParser) Bitrate() int {
+	return int(w.fileProperties.MaxBitrate / 1000)
+}
+
+// BPM returns the BPM (beats per minute) tag for this stream
+func (w WMAParser) BPM() int {
+	bpm, err := strconv.Atoi(w.tags[tagBPM])
+	if err != nil {
+		return 0
+	}
+
+	return bpm
+}
+
+// ChannelLayout returns the name of the channel layout of this stream, such as "mono", "stereo", or "5.1"
+func (w WMAParser) ChannelLayout() string {
+	return channelLayout(w.Channels())
+}
+
+// Channels returns the number of channels for this stream
+func (w WMAParser) Channels() int {
+	return int(w.streamProperties.Channels)
+}
+
+// Comment returns the Comment tag for this stream
+func (w WMAParser) Comment() string {
+	return w.tags[tagComment]
+}
+
+// Composer returns the Composer tag for this stream
+func (w WMAParser) Composer() string {
+	return w.tags[tagComposer]
+}
+
+// Conductor returns the Conductor tag for this stream
+func (w WMAParser) Conductor() string {
+	return w.tags[tagConductor]
+}
+
+// ContentType returns the MIME type of this stream
+func (w WMAParser) ContentType() string {
+	return w.FormatID().ContentType()
+}
+
+// Copyright returns the Copyright tag for this stream
+func (w WMAParser) Copyright() string {
+	return w.tags[tagCopyright]
+}
+
+// Date returns the Date tag for this stream
+func (w WMAParser) Date() string {
+	return w.tags[tagDate]
+}
+
+// DiscNumber returns the DiscNumber tag for this stream
+func (w WMAParser) DiscNumber() int {
+	// Check for a /, such as 1/2
+	disc, err := strconv.Atoi(strings.Split(w.tags[tagDiscNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return disc
+}
+
+// Duration returns the time duration for this stream, excluding the preroll time which is included
+// in the stream's play duration
+func (w WMAParser) Duration() time.Duration {
+	// Play duration is specified in 100-nanosecond units, and preroll in milliseconds
+	duration := time.Duration(w.fileProperties.PlayDuration)*100 - time.Duration(w.fileProperties.Preroll)*time.Millisecond
+	if duration < 0 {
+		return 0
+	}
+
+	return duration
+}
+
+// EncodedBy returns the EncodedBy tag for this stream
+func (w WMAParser) EncodedBy() string {
+	return w.tags[tagEncodedBy]
+}
+
+// Encoder returns the encoder for this stream
+func (w WMAParser) Encoder() string {
+	return w.tags[tagEncoder]
+}
+
+// Format returns the name of the WMA format
+func (w WMAParser) Format() string {
+	return w.FormatID().String()
+}
+
+// FormatID returns the Format of this stream
+func (w WMAParser) FormatID() Format {
+	return FormatWMA
+}
+
+// Genre returns the Genre tag for this stream
+func (w WMAParser) Genre() string {
+	return w.tags[tagGenre]
+}
+
+// Grouping returns the Grouping (content group) tag for this stream
+func (w WMAParser) Grouping() string {
+	return w.tags[tagGrouping]
+}
+
+// HasPicture returns whether or not this stream contains embedded cover art
+func (w WMAParser) HasPicture() bool {
+	return w.hasPicture
+}
+
+// HeaderFingerprint returns a stable identifier derived from the file and stream properties, and
+// size of this stream
+func (w WMAParser) HeaderFingerprint() []byte {
+	return headerFingerprint(w.endPos, *w.fileProperties, *w.streamProperties)
+}
+
+// InitialKey returns the InitialKey (musical key) tag for this stream, checking the InitialKey and
+// Key tags in order
+func (w WMAParser) InitialKey() string {
+	return firstTag(w.tags, tagInitialKey, tagKey)
+}
+
+// Language returns the Language tag for this stream
+func (w WMAParser) Language() string {
+	return w.tags[tagLanguage]
+}
+
+// Lyrics returns the Lyrics tag for this stream
+func (w WMAParser) Lyrics() string {
+	return w.tags[tagLyrics]
+}
+
+// Mood returns the Mood tag for this stream
+func (w WMAParser) Mood() string {
+	return w.tags[tagMood]
+}
+
+// OriginalFilename returns the OriginalFilename tag for this stream
+func (w WMAParser) OriginalFilename() string {
+	return w.tags[tagOriginalFilename]
+}
+
+// Properties returns the audio properties of this stream
+func (w WMAParser) Properties() AudioProperties {
+	return audioProperties(&w)
+}
+
+// Publisher returns the Publisher (record-label) tag for this stream, checking the Publisher,
+// Label, and Organization tags in order
+func (w WMAParser) Publisher() string {
+	return firstTag(w.tags, tagPublisher, tagLabel, tagOrganization)
+}
+
+// Raw returns a copy of the format-specific header parsed from this stream, as a WMAHeader
+func (w WMAParser) Raw() interface{} {
+	return WMAHeader{
+		FileProperties:   *w.fileProperties,
+		StreamProperties: *w.streamProperties,
+	}
+}
+
+// Remixer returns the Remixer tag for this stream, checking the Remixer and MixArtist tags in order
+func (w WMAParser) Remixer() string {
+	return firstTag(w.tags, tagRemixer, tagMixArtist)
+}
+
+// ReplayGainAlbumGain returns the ReplayGain album gain for this stream, and whether it is present
+func (w WMAParser) ReplayGainAlbumGain() (float64, bool) {
+	return parseReplayGain(w.tags[tagReplayGainAlbumGain])
+}
+
+// ReplayGainAlbumPeak returns the ReplayGain album peak for this stream, and whether it is present
+func (w WMAParser) ReplayGainAlbumPeak() (float64, bool) {
+	return parseReplayGain(w.tags[tagReplayGainAlbumPeak])
+}
+
+// ReplayGainTrackGain returns the ReplayGain track gain for this stream, and whether it is present
+func (w WMAParser) ReplayGainTrackGain() (float64, bool) {
+	return parseReplayGain(w.tags[tagReplayGainTrackGain])
+}
+
+// ReplayGainTrackPeak returns the ReplayGain track peak for this stream, and whether it is present
+func (w WMAParser) ReplayGainTrackPeak() (float64, bool) {
+	return parseReplayGain(w.tags[tagReplayGainTrackPeak])
+}
+
+// Reset discards the state of this parser, and parses a new WMA stream from the start of the
+// input reader, using the same options.  Reset reuses memory allocated by this parser, and may be
+// used to reduce allocations when parsing many streams.
+func (w *WMAParser) Reset(reader io.ReadSeeker) error {
+	// Clear the tag map so it may be reused
+	tags := w.tags
+	for name := range tags {
+		delete(tags, name)
+	}
+
+	*w = WMAParser{
+		options: w.options,
+		reader:  reader,
+		tags:    tags,
+	}
+
+	return w.parse()
+}
+
+// SampleCount returns the total number of samples per channel in this stream
+func (w WMAParser) SampleCount() uint64 {
+	// Estimate the sample count using the duration, since ASF does not store it
+	return uint64(w.Duration().Seconds() * float64(w.SampleRate()))
+}
+
+// SampleFormat returns the format of the decoded samples of this stream
+func (w WMAParser) SampleFormat() SampleFormat {
+	// WMA is a lossy format, so its decoded samples have no inherent format
+	return SampleFormatUnknown
+}
+
+// SampleRate returns the sample rate in Hertz for this stream
+func (w WMAParser) SampleRate() int {
+	return int(w.streamProperties.SampleRate)
+}
+
+// String returns a one-line summary of the tags and properties of this stream
+func (w WMAParser) String() string {
+	return summarize(&w)
+}
+
+// Tag attempts to return the raw, unprocessed tag with the specified name for this stream
+func (w WMAParser) Tag(name string) string {
+	return w.tags[strings.ToUpper(name)]
+}
+
+// TagMulti returns every value of the raw, unprocessed tag with the specified name for this stream
+func (w WMAParser) TagMulti(name string) []string {
+	return tagValues(w.tags, name)
+}
+
+// Title returns the Title tag for this stream
+func (w WMAParser) Title() string {
+	return w.tags[tagTitle]
+}
+
+// TitleSort returns the TitleSort (title sort order) tag for this stream
+func (w WMAParser) TitleSort() string {
+	return w.tags[tagTitleSort]
+}
+
+// TotalDiscs returns the total number of discs for this stream, from the DiscTotal tag or
+// the DiscNumber tag in "disc/total" form
+func (w WMAParser) TotalDiscs() int {
+	return parseTotal(w.tags[tagDiscNumber], firstTag(w.tags, tagDiscTotal, tagTotalDiscs))
+}
+
+// TotalTracks returns the total number of tracks for this stream, from the TrackTotal tag or
+// the TrackNumber tag in "track/total" form
+func (w WMAParser) TotalTracks() int {
+	return parseTotal(w.tags[tagTrackNumber], firstTag(w.tags, tagTrackTotal, tagTotalTracks))
+}
+
+// TrackNumber returns the TrackNumber tag for this stream
+func (w WMAParser) TrackNumber() int {
+	// Check for a /, such as 2/8
+	track, err := strconv.Atoi(strings.Split(w.tags[tagTrackNumber], "/")[0])
+	if err != nil {
+		return 0
+	}
+
+	return track
+}
+
+// VisitTags invokes fn for each raw tag in this stream, stopping if fn returns false
+func (w WMAParser) VisitTags(fn func(name, value string) bool) {
+	for name, value := range w.tags {
+		if !fn(name, value) {
+			return
+		}
+	}
+}
+
+// Work returns the Work tag for this stream, which names the work containing a movement
+func (w WMAParser) Work() string {
+	return w.tags[tagWork]
+}
+
+// Year returns the four-digit year from the Date tag for this stream, or 0 if no year is present
+func (w WMAParser) Year() int {
+	return parseYear(w.tags[tagDate])
+}
+
+// newWMAParser creates a parser for WMA audio streams
+func newWMAParser(reader io.ReadSeeker, options Options) (*WMAParser, error) {
+	// Create WMA parser
+	parser := &WMAParser{
+		options: options,
+		reader:  reader,
+		tags:    map[string]string{},
+	}
+
+	// Parse the stream
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	// Return parser
+	return parser, nil
+}
+
+// parse parses a WMA stream from the reader of this parser
+func (w *WMAParser) parse() error {
+	// Determine the size of the stream before parsing, unless only a prefix is available
+	if !w.options.streaming {
+		n, err := streamSize(w.reader)
+		if err != nil {
+			return err
+		}
+		w.endPos = n
+	}
+
+	// Verify the magic number at the start of the stream
+	if err := readMagicNumber(w.reader, wmaMagicNumber, w.Format()); err != nil {
+		return err
+	}
+
+	// Walk the objects contained in the ASF header
+	if err := w.parseHeaderObjects(); err != nil {
+		return err
+	}
+
+	w.options.normalizeTags(w.tags)
+	return nil
+}
+
+// WMAHeader contains the information parsed from the ASF header objects of a WMA stream.  A copy
+// of the WMAHeader for a WMA stream is returned by Raw.
+type WMAHeader struct {
+	FileProperties   WMAFileProperties
+	StreamProperties WMAStreamProperties
+}
+
+// WMAFileProperties represents the information contained in an ASF File Properties Object
+type WMAFileProperties struct {
+	FileID        [16]byte
+	FileSize      uint64
+	CreationDate  uint64
+	PacketCount   uint64
+	PlayDuration  uint64
+	SendDuration  uint64
+	Preroll       uint64
+	Flags         uint32
+	MinPacketSize uint32
+	MaxPacketSize uint32
+	MaxBitrate    uint32
+}
+
+// WMAStreamProperties represents the audio format information contained in an ASF Stream
+// Properties Object for an audio stream
+type WMAStreamProperties struct {
+	FormatTag      uint16
+	Channels       uint16
+	SampleRate     uint32
+	AvgBytesPerSec uint32
+	BlockAlign     uint16
+	BitsPerSample  uint16
+}
+
+// parseHeaderObjects walks the objects contained in the ASF Header Object, parsing those which
+// contain stream properties or tags
+func (w *WMAParser) parseHeaderObjects() error {
+	// Read the remainder of the Header Object, following its GUID
+	var header struct {
+		Size        uint64
+		ObjectCount uint32
+		Reserved1   uint8
+		Reserved2   uint8
+	}
+	if err := binary.Read(w.reader, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	if header.Size < wmaHeaderObjectSize {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  w.Format(),
+			Details: fmt.Sprintf("invalid ASF header size: %d", header.Size),
+		}
+	}
+
+	// The objects contained in the header cannot extend past the end of the stream, or past a
+	// fixed maximum if the size of the stream is unknown
+	remaining := header.Size - wmaHeaderObjectSize
+	limit := uint64(wmaMaxHeaderSize)
+	if !w.options.streaming {
+		pos, err := w.reader.Seek(0, 1)
+		if err != nil {
+			return err
+		}
+		limit = uint64(w.endPos - pos)
+	}
+	if remaining > limit {
+		remaining = limit
+	}
+
+	// Read each object's GUID and size, and parse objects of interest
+	guid := make([]byte, 16)
+	for i := uint32(0); i < header.ObjectCount; i++ {
+		// Stop if parsing has been canceled
+		if err := w.options.err(); err != nil {
+			return err
+		}
+
+		if _, err := io.ReadFull(w.reader, guid); err != nil {
+			return err
+		}
+
+		var size uint64
+		if err := binary.Read(w.reader, binary.LittleEndian, &size); err != nil {
+			return err
+		}
+
+		// Ensure object fits in the bytes remaining in the header, so a malformed stream cannot
+		// cause a huge allocation
+		if size < wmaObjectHeaderSize || size > remaining {
+			return TagError{
+				Err:     ErrInvalidStream,
+				Format:  w.Format(),
+				Details: fmt.Sprintf("invalid ASF object size: %d", size),
+			}
+		}
+		remaining -= size
+		length := int64(size - wmaObjectHeaderSize)
+
+		// Determine which parser, if any, handles this object
+		var parse func(data []byte) error
+		switch {
+		case bytes.Equal(guid, wmaFilePropertiesObject):
+			parse = w.parseFileProperties
+		case bytes.Equal(guid, wmaStreamPropertiesObject):
+			parse = w.parseStreamProperties
+		case bytes.Equal(guid, wmaContentDescriptionObject):
+			parse = w.parseContentDescription
+		case bytes.Equal(guid, wmaExtendedContentDescriptionObject):
+			parse = w.parseExtendedContentDescription
+		default:
+			// Seek past objects which are not of interest
+			if _, err := w.reader.Seek(length, 1); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Read object data and parse it
+		data := make([]byte, length)
+		if _, err := io.ReadFull(w.reader, data); err != nil {
+			return err
+		}
+
+		if err := parse(data); err != nil {
+			return err
+		}
+	}
+
+	// Ensure required objects were found
+	if w.fileProperties == nil || w.streamProperties == nil {
+		return TagError{
+			Err:     ErrInvalidStream,
+			Format:  w.Format(),
+			Details: "missing ASF file properties or audio stream properties",
+		}
+	}
+
+	return nil
+}
+
+// parseFileProperties parses an ASF File Properties Object
+func (w *WMAParser) parseFileProperties(data []byte) error {
+	properties := new(WMAFileProperties)
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, properties); err != nil {
+		return err
+	}
+
+	w.fileProperties = properties
+	return nil
+}
+
+// parseStreamProperties parses an ASF Stream Properties Object, storing audio format information
+// from the first audio stream
+func (w *WMAParser) parseStreamProperties(data []byte) error {
+	// Stream properties begin with:
+	//   16 - Stream type GUID
+	//   16 - Error correction type GUID
+	//    8 - Time offset
+	//    4 - Type-specific data length
+	//    4 - Error correction data length
+	//    2 - Flags
+	//    4 - Reserved
+	// Followed by type-specific data, which is a WAVEFORMATEX structure for audio streams
+	if len(data) < 54 || !bytes.Equal(data[:16], wmaAudioMedia) || w.streamProperties != nil {
+		return nil
+	}
+
+	properties := new(WMAStreamProperties)
+	if err := binary.Read(bytes.NewReader(data[54:]), binary.LittleEndian, properties); err != nil {
+		return err
+	}
+
+	w.streamProperties = properties
+	return nil
+}
+
+// parseContentDescription parses the tags stored in an ASF Content Description Object
+func (w *WMAParser) parseContentDescription(data []byte) error {
+	// Lengths of each field, in order
+	lengths := make([]uint16, 5)
+	reader := bytes.NewReader(data)
+	if err := binary.Read(reader, binary.LittleEndian, lengths); err != nil {
+		return err
+	}
+
+	// Fields are UTF-16 strings, stored in order:
+	//   - Title
+	//   - Author
+	//   - Copyright
+	//   - Description
+	//   - Rating
+	names := []string{tagTitle, tagArtist, tagCopyright, tagComment, "RATING"}
+	for i, length := range lengths {
+		field := make([]byte, length)
+		if _, err := io.ReadFull(reader, field); err != nil {
+			return err
+		}
+
+		if value := mp3DecodeUTF16(binary.LittleEndian, field); value != "" {
+			w.tags[names[i]] = value
+		}
+	}
+
+	return nil
+}
+
+// parseExtendedContentDescription parses the tags stored in an ASF Extended Content Description Object
+func (w *WMAParser) parseExtendedContentDescription(data []byte) error {
+	reader := bytes.NewReader(data)
+
+	var count uint16
+	if err := binary.Read(reader, binary.LittleEndian, &count); err != nil {
+		return err
+	}
+
+	// Each descriptor consists of:
+	//   2 - Name length
+	//   N - Name, UTF-16 string
+	//   2 - Value type
+	//   2 - Value length
+	//   N - Value
+	var length, valueType uint16
+	for i := 0; i < int(count); i++ {
+		if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+			return err
+		}
+
+		name := make([]byte, length)
+		if _, err := io.ReadFull(reader, name); err != nil {
+			return err
+		}
+
+		if err := binary.Read(reader, binary.LittleEndian, &valueType); err != nil {
+			return err
+		}
+
+		if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+			return err
+		}
+
+		value := make([]byte, length)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return err
+		}
+
+		// Note the presence of attached pictures
+		tag := strings.ToUpper(mp3DecodeUTF16(binary.LittleEndian, name))
+		if tag == wmaTagPicture {
+			w.hasPicture = true
+			continue
+		}
+
+		// Map attribute name to tag name, when needed
+		if mapped, ok := wmaAttributeToTag[tag]; ok {
+			tag = mapped
+		}
+
+		// Decode value according to its type, skipping binary data
+		switch {
+		case valueType == wmaTypeString:
+			w.tags[tag] = mp3DecodeUTF16(binary.LittleEndian, value)
+		case valueType == wmaTypeBool && len(value) == 4:
+			w.tags[tag] = strconv.FormatBool(binary.LittleEndian.Uint32(value) != 0)
+		case valueType == wmaTypeDWORD && len(value) == 4:
+			w.tags[tag] = strconv.FormatUint(uint64(binary.LittleEndian.Uint32(value)), 10)
+		case valueType == wmaTypeQWORD && len(value) == 8:
+			w.tags[tag] = strconv.FormatUint(binary.LittleEndian.Uint64(value), 10)
+		case valueType == wmaTypeWORD && len(value) == 2:
+			w.tags[tag] = strconv.FormatUint(uint64(binary.LittleEndian.Uint16(value)), 10)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package taggolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// wmaObject generates an ASF object with the specified GUID and data
+func wmaObject(guid []byte, data []byte) []byte {
+	object := make([]byte, wmaObjectHeaderSize)
+	copy(object, guid)
+	binary.LittleEndian.PutUint64(object[16:24], uint64(wmaObjectHeaderSize+len(data)))
+
+	return append(object, data...)
+}
+
+// wmaString generates a null-terminated UTF-16 string, as used in ASF objects
+func wmaString(s string) []byte {
+	var b []byte
+	for _, unit := range append(utf16.Encode([]rune(s)), 0) {
+		b = append(b, byte(unit), byte(unit>>8))
+	}
+
+	return b
+}
+
+// wmaStream generates an ASF Header Object containing the specified objects
+func wmaStream(objects ...[]byte) []byte {
+	var data []byte
+	for _, object := range objects {
+		data = append(data, object...)
+	}
+
+	header := make([]byte, 6)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(objects)))
+
+	return wmaObject(wmaMagicNumber, append(header, data...))
+}
+
+// TestWMA verifies that all WMAParser methods work properly
+func TestWMA(t *testing.T) {
+	// File properties: 10 second play duration, with 3 second preroll, at 128kbps
+	fileProperties := make([]byte, 80)
+	binary.LittleEndian.PutUint64(fileProperties[40:48], 130000000)
+	binary.LittleEndian.PutUint64(fileProperties[56:64], 3000)
+	binary.LittleEndian.PutUint32(fileProperties[76:80], 128000)
+
+	// Audio stream properties: 2 channels, 44.1kHz, 16 bit
+	streamProperties := make([]byte, 54+16)
+	copy(streamProperties, wmaAudioMedia)
+	binary.LittleEndian.PutUint16(streamProperties[56:58], 2)
+	binary.LittleEndian.PutUint32(streamProperties[58:62], 44100)
+	binary.LittleEndian.PutUint16(streamProperties[68:70], 16)
+
+	// Content description: title and author
+	title, author := wmaString("Title"), wmaString("Artist")
+	contentDescription := make([]byte, 10)
+	binary.LittleEndian.PutUint16(contentDescription[0:2], uint16(len(title)))
+	binary.LittleEndian.PutUint16(contentDescription[2:4], uint16(len(author)))
+	contentDescription = append(append(contentDescription, title...), author...)
+
+	// Extended content description: album, track number, and picture
+	descriptor := func(name string, valueType uint16, value []byte) []byte {
+		n := wmaString(name)
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, uint16(len(n)))
+		b = append(b, n...)
+		b = append(b, byte(valueType), byte(valueType>>8), byte(len(value)), byte(len(value)>>8))
+		return append(b, value...)
+	}
+	extendedContentDescription := []byte{3, 0}
+	extendedContentDescription = append(extendedContentDescription, descriptor("WM/AlbumTitle", wmaTypeString, wmaString("Album"))...)
+	extendedContentDescription = append(extendedContentDescription, descriptor("WM/TrackNumber", wmaTypeDWORD, []byte{5, 0, 0, 0})...)
+	extendedContentDescription = append(extendedContentDescription, descriptor("WM/Picture", wmaTypeBytes, []byte{1, 2, 3})...)
+
+	wma, err := New(bytes.NewReader(wmaStream(
+		wmaObject(wmaFilePropertiesObject, fileProperties),
+		wmaObject(wmaStreamPropertiesObject, streamProperties),
+		wmaObject(wmaContentDescriptionObject, contentDescription),
+		wmaObject(wmaExtendedContentDescriptionObject, extendedContentDescription),
+	)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if wma.Title() != "Title" {
+		t.Fatalf("mismatched tag Title: %v", wma.Title())
+	}
+
+	if wma.Artist() != "Artist" {
+		t.Fatalf("mismatched tag Artist: %v", wma.Artist())
+	}
+
+	if wma.Album() != "Album" {
+		t.Fatalf("mismatched tag Album: %v", wma.Album())
+	}
+
+	if wma.TrackNumber() != 5 {
+		t.Fatalf("mismatched tag TrackNumber: %v", wma.TrackNumber())
+	}
+
+	if !wma.HasPicture() {
+		t.Fatalf("mismatched property HasPicture: %v", wma.HasPicture())
+	}
+
+	if wma.BitDepth() != 16 {
+		t.Fatalf("mismatched property BitDepth: %v", wma.BitDepth())
+	}
+
+	if wma.Bitrate() != 128 {
+		t.Fatalf("mismatched property Bitrate: %v", wma.Bitrate())
+	}
+
+	if wma.Channels() != 2 {
+		t.Fatalf("mismatched property Channels: %v", wma.Channels())
+	}
+
+	if wma.Duration() != 10*time.Second {
+		t.Fatalf("mismatched property Duration: %v", wma.Duration())
+	}
+
+	if wma.Format() != "WMA" {
+		t.Fatalf("mismatched property Format: %v", wma.Format())
+	}
+
+	if wma.SampleRate() != 44100 {
+		t.Fatalf("mismatched property SampleRate: %v", wma.SampleRate())
+	}
+}
+
+// TestWMAMissingProperties verifies that a WMA stream without stream properties is rejected
+func TestWMAMissingProperties(t *testing.T) {
+	if _, err := New(bytes.NewReader(wmaStream())); !IsInvalidStream(err) {
+		t.Fatalf("expected invalid stream error, got: %v", err)
+	}
+}
+
+// TestWMAObjectSize verifies that objects which extend past the end of the ASF Header Object or the
+// stream are rejected, rather than used to allocate a buffer
+func TestWMAObjectSize(t *testing.T) {
+	// A content description object, whose size is modified by each test
+	object := wmaObject(wmaContentDescriptionObject, make([]byte, 10))
+
+	var tests = []struct {
+		objectSize uint64
+		headerSize uint64
+	}{
+		// Object extends past the end of the header
+		{uint64(len(object)) + 1, 0},
+		// Object and header both claim to extend far past the end of the stream
+		{1 << 40, 1 << 41},
+		// Header is too small to contain its own fields
+		{uint64(len(object)), 1},
+	}
+
+	for i, test := range tests {
+		stream := wmaStream(object)
+		binary.LittleEndian.PutUint64(stream[wmaHeaderObjectSize+16:wmaHeaderObjectSize+24], test.objectSize)
+		if test.headerSize > 0 {
+			binary.LittleEndian.PutUint64(stream[16:24], test.headerSize)
+		}
+
+		if _, err := New(bytes.NewReader(stream)); !IsInvalidStream(err) {
+			t.Fatalf("[%02d] expected invalid stream error, got: %v", i, err)
+		}
+	}
+}